@@ -0,0 +1,462 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/gRPC/gRPCClient"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/transfer"
+	"urlShortener/pkg/logger"
+)
+
+const adminTokenEnv = "URLSHORTENER_ADMIN_TOKEN"
+
+type clientFlags struct {
+	*flags
+	offline      bool
+	grpcAddr     string
+	adminNetwork string
+	adminAddr    string
+	token        string
+	output       string
+	file         string
+	format       string
+	mode         string
+	dryRun       bool
+	timeout      time.Duration
+}
+
+// backend is a running instance or, with -offline, the storage opened directly.
+type backend interface {
+	Shorten(ctx context.Context, fullURL string) (string, error)
+	Resolve(ctx context.Context, code string) (string, error)
+	Delete(ctx context.Context, code string) error
+	// Stats with a code also returns the clicks of the variants and the targets of the link.
+	Stats(ctx context.Context, code string) (statsOutput, error)
+	Export(ctx context.Context, w transfer.Writer) error
+	Import(ctx context.Context, r transfer.Reader, opts transfer.Options) (transfer.Result, error)
+	Close() error
+}
+
+func runClient(command string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	f := &clientFlags{flags: addServerFlags(fs)}
+	fs.BoolVar(&f.offline, "offline", false, "open the storage from the config instead of calling a running instance")
+	fs.StringVar(&f.grpcAddr, "grpc", "localhost:3030", "gRPC address of a running instance")
+	fs.StringVar(&f.adminNetwork, "admin-network", "unix", "admin gRPC network: tcp or unix")
+	fs.StringVar(&f.adminAddr, "admin-addr", "/tmp/urlShortener-admin.sock", "admin gRPC address or socket path")
+	fs.StringVar(&f.token, "token", "", "admin token, "+adminTokenEnv+" is used if empty")
+	fs.StringVar(&f.output, "output", tableOutput, "output format: table or json")
+	fs.StringVar(&f.file, "file", "", "file for import and export instead of stdin and stdout")
+	fs.StringVar(&f.format, "format", transfer.JSONLines, "import and export format: jsonl or csv, import also reads bitly, rebrandly and shortio CSV")
+	fs.StringVar(&f.mode, "mode", string(transfer.ModeSkip), "import mode for existing codes: skip, update or strict")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "import: only count what would change")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "timeout of the whole command")
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if f.output != tableOutput && f.output != jsonOutput {
+		fmt.Fprintf(stderr, "unknown output format %q\n", f.output)
+		return exitUsage
+	}
+	if _, err := transfer.ParseMode(f.mode); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if err := checkFormat(command, f.format); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if f.token == "" {
+		f.token = os.Getenv(adminTokenEnv)
+	}
+	if !validArgs(command, fs.NArg()) {
+		fmt.Fprintf(stderr, "wrong number of arguments for %s\n\n%s", command, usage)
+		return exitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	err := execute(ctx, command, fs.Args(), f, stdin, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		if errors.Is(err, errWrongStorage) {
+			return exitUsage
+		}
+	}
+	return exitCode(err)
+}
+
+func validArgs(command string, n int) bool {
+	switch command {
+	case shortenCommand, resolveCommand, deleteCommand:
+		return n > 0
+	case statsCommand:
+		return n <= 1
+	default:
+		return n == 0
+	}
+}
+
+// checkFormat rejects the format before connecting, writers exist only for the native formats.
+func checkFormat(command string, format string) error {
+	var err error
+	switch command {
+	case exportCommand:
+		_, err = transfer.NewWriter(format, io.Discard)
+	case importCommand:
+		_, err = transfer.NewReader(format, strings.NewReader(""))
+	}
+	return err
+}
+
+func execute(ctx context.Context, command string, args []string, f *clientFlags, stdin io.Reader, stdout io.Writer) error {
+	var b backend
+	var err error
+	if f.offline {
+		b, err = openLocal(ctx, f)
+	} else {
+		b, err = openRemote(f)
+	}
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	switch command {
+	case shortenCommand:
+		results := make([]linkOutput, 0, len(args))
+		for _, fullURL := range args {
+			code, err := b.Shorten(ctx, fullURL)
+			if err != nil {
+				return err
+			}
+			results = append(results, linkOutput{Code: code, URL: fullURL})
+		}
+		return writeOutput(stdout, f.output, results, linksTable(results))
+	case resolveCommand:
+		results := make([]linkOutput, 0, len(args))
+		for _, code := range args {
+			fullURL, err := b.Resolve(ctx, code)
+			if err != nil {
+				return err
+			}
+			results = append(results, linkOutput{Code: code, URL: fullURL})
+		}
+		return writeOutput(stdout, f.output, results, linksTable(results))
+	case deleteCommand:
+		results := make([]linkOutput, 0, len(args))
+		for _, code := range args {
+			if err := b.Delete(ctx, code); err != nil {
+				return err
+			}
+			results = append(results, linkOutput{Code: code})
+		}
+		return writeOutput(stdout, f.output, results, deletedTable(results))
+	case statsCommand:
+		var code string
+		if len(args) > 0 {
+			code = args[0]
+		}
+		stats, err := b.Stats(ctx, code)
+		if err != nil {
+			return err
+		}
+		return writeOutput(stdout, f.output, stats, stats.table())
+	case exportCommand:
+		return exportToFile(ctx, b, f, stdout)
+	case importCommand:
+		return importFromFile(ctx, b, f, stdin, stdout)
+	}
+	return nil
+}
+
+func exportToFile(ctx context.Context, b backend, f *clientFlags, stdout io.Writer) error {
+	if f.file == "" {
+		w, err := transfer.NewWriter(f.format, stdout)
+		if err != nil {
+			return err
+		}
+		return b.Export(ctx, w)
+	}
+
+	file, err := os.Create(f.file)
+	if err != nil {
+		return err
+	}
+	w, err := transfer.NewWriter(f.format, file)
+	if err == nil {
+		err = b.Export(ctx, w)
+	}
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func importFromFile(ctx context.Context, b backend, f *clientFlags, stdin io.Reader, stdout io.Writer) error {
+	in := stdin
+	if f.file != "" {
+		file, err := os.Open(f.file)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	r, err := transfer.NewReader(f.format, in)
+	if err != nil {
+		return err
+	}
+	result, err := b.Import(ctx, r, transfer.Options{Mode: transfer.Mode(f.mode), DryRun: f.dryRun})
+	if err != nil {
+		return err
+	}
+	return writeOutput(stdout, f.output, result, importTable(result))
+}
+
+type remoteBackend struct {
+	conn      *grpc.ClientConn
+	adminConn *grpc.ClientConn
+	client    proto.URLShortenerClient
+	admin     proto.AdminClient
+}
+
+func openRemote(f *clientFlags) (*remoteBackend, error) {
+	conn, err := grpc.Dial(f.grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	admin, adminConn, err := gRPCClient.DialAdmin(f.adminNetwork, f.adminAddr, f.token)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &remoteBackend{
+		conn:      conn,
+		adminConn: adminConn,
+		client:    proto.NewURLShortenerClient(conn),
+		admin:     admin,
+	}, nil
+}
+
+func (r *remoteBackend) Shorten(ctx context.Context, fullURL string) (string, error) {
+	resp, err := r.client.Save(ctx, &proto.FullURL{URL: fullURL})
+	if err != nil {
+		return "", err
+	}
+	return resp.URL, nil
+}
+
+// Resolve reads the link through the admin API: a redirect would use up a click of the link and
+// a password attempt of the client.
+func (r *remoteBackend) Resolve(ctx context.Context, code string) (string, error) {
+	link, err := r.admin.GetLink(ctx, &proto.LinkCode{Code: code})
+	if err != nil {
+		return "", err
+	}
+	if link.Disabled {
+		return "", domainError.URLDisabled(nil)
+	}
+	return link.FullUrl, nil
+}
+
+func (r *remoteBackend) Delete(ctx context.Context, code string) error {
+	_, err := r.admin.DeleteLink(ctx, &proto.LinkCode{Code: code})
+	return err
+}
+
+func (r *remoteBackend) Stats(ctx context.Context, code string) (statsOutput, error) {
+	stats, err := r.admin.GetStats(ctx, &proto.StatsRequest{Code: code})
+	if err != nil {
+		return statsOutput{}, err
+	}
+	output := statsOutput{
+		Links:         stats.Links,
+		DisabledLinks: stats.DisabledLinks,
+		CurrentID:     stats.Counter.GetCurrentId(),
+		MaxID:         stats.Counter.GetMaxId(),
+		Headroom:      stats.Counter.GetHeadroom(),
+	}
+	for _, variant := range stats.Variants {
+		output.Variants = append(output.Variants, variantOutput{
+			Name:   variant.Variant.GetName(),
+			URL:    variant.Variant.GetURL(),
+			Weight: variant.Variant.GetWeight(),
+			Clicks: variant.Clicks,
+		})
+	}
+	for _, target := range stats.Targets {
+		output.Targets = append(output.Targets, targetOutput{Target: target.Target, Clicks: target.Clicks})
+	}
+	return output, nil
+}
+
+func (r *remoteBackend) Export(ctx context.Context, w transfer.Writer) error {
+	return gRPCClient.ExportLinks(ctx, r.admin, w)
+}
+
+func (r *remoteBackend) Import(ctx context.Context, rd transfer.Reader, opts transfer.Options) (transfer.Result, error) {
+	return gRPCClient.ImportLinks(ctx, r.admin, rd, opts)
+}
+
+func (r *remoteBackend) Close() error {
+	return errors.Join(r.conn.Close(), r.adminConn.Close())
+}
+
+type localBackend struct {
+	service *service.Service
+	admin   *service.Admin
+	closer  io.Closer
+}
+
+func openLocal(ctx context.Context, f *clientFlags) (*localBackend, error) {
+	cfg, err := f.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Storage.Type != postgresStorage {
+		return nil, fmt.Errorf("%w: -offline needs the %s storage, the in-memory storage lives only inside the server", errWrongStorage, postgresStorage)
+	}
+	appLogger, err := logger.New(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return nil, err
+	}
+	appLogger.SetOutput(io.Discard)
+
+	db, hashGen, err := openStorage(ctx, cfg, cfg.Storage.Type, appLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	closer, _ := db.(io.Closer)
+	manager, ok := db.(storage.Manager)
+	if !ok {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, storage.ErrNotSupported
+	}
+	return &localBackend{
+		service: service.New(db, hashGen),
+		admin:   service.NewAdmin(manager, hashGen),
+		closer:  closer,
+	}, nil
+}
+
+func (l *localBackend) Shorten(ctx context.Context, fullURL string) (string, error) {
+	return l.service.GetShortenURL(ctx, fullURL, storage.Options{}, "")
+}
+
+func (l *localBackend) Resolve(ctx context.Context, code string) (string, error) {
+	link, err := l.service.Resolve(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	return link.FullURL, nil
+}
+
+func (l *localBackend) Delete(ctx context.Context, code string) error {
+	return l.admin.DeleteLink(ctx, code)
+}
+
+func (l *localBackend) Stats(ctx context.Context, code string) (statsOutput, error) {
+	stats, err := l.admin.Stats(ctx)
+	if err != nil {
+		return statsOutput{}, err
+	}
+	output := statsOutput{
+		Links:         stats.Links,
+		DisabledLinks: stats.DisabledLinks,
+		CurrentID:     stats.Counter.CurrentID,
+		MaxID:         stats.Counter.MaxID,
+		Headroom:      stats.Counter.Headroom,
+	}
+	if code == "" {
+		return output, nil
+	}
+
+	variants, err := l.admin.VariantStats(ctx, code)
+	if err != nil {
+		return statsOutput{}, err
+	}
+	for _, variant := range variants {
+		output.Variants = append(output.Variants, variantOutput{Name: variant.Name, URL: variant.URL, Weight: variant.Weight, Clicks: variant.Clicks})
+	}
+
+	targets, err := l.admin.TargetStats(ctx, code)
+	if err != nil {
+		return statsOutput{}, err
+	}
+	for _, target := range targets {
+		output.Targets = append(output.Targets, targetOutput{Target: target.Target, Clicks: target.Clicks})
+	}
+	return output, nil
+}
+
+func (l *localBackend) Export(ctx context.Context, w transfer.Writer) error {
+	return l.admin.ExportLinks(ctx, w)
+}
+
+func (l *localBackend) Import(ctx context.Context, r transfer.Reader, opts transfer.Options) (transfer.Result, error) {
+	return l.admin.ImportLinks(ctx, r, opts)
+}
+
+func (l *localBackend) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// exitCode maps domain errors of the offline mode and gRPC statuses of the online mode to the same exit codes.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var domainErr *domainError.Error
+	if errors.As(err, &domainErr) {
+		switch domainErr.Code {
+		case domainError.CodeURLNotFound:
+			return exitNotFound
+		case domainError.CodeInvalidArgument:
+			return exitInvalid
+		case domainError.CodeUnavailable:
+			return exitUnavailable
+		}
+		return exitError
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.NotFound:
+			return exitNotFound
+		case codes.InvalidArgument:
+			return exitInvalid
+		case codes.Unavailable, codes.DeadlineExceeded:
+			return exitUnavailable
+		}
+	}
+	return exitError
+}
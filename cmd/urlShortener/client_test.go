@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/gRPC/gRPCHandlers/admin"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"ok", nil, exitOK},
+		{"domain not found", domainError.URLNotFound(storage.ErrURLNotFound), exitNotFound},
+		{"domain invalid", domainError.InvalidArgument("URL", "bad"), exitInvalid},
+		{"grpc not found", status.Error(codes.NotFound, "URL not found"), exitNotFound},
+		{"grpc unavailable", status.Error(codes.Unavailable, "connection refused"), exitUnavailable},
+		{"grpc unauthenticated", status.Error(codes.Unauthenticated, "invalid admin token"), exitError},
+		{"other", errors.New("boom"), exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, exitCode(tt.err))
+		})
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	links := []linkOutput{{Code: "qqqqqqqqqq", URL: "https://ozon.ru"}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeOutput(&buf, tableOutput, links, linksTable(links)))
+	assert.Equal(t, "CODE        URL\nqqqqqqqqqq  https://ozon.ru\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, writeOutput(&buf, jsonOutput, links, linksTable(links)))
+	assert.JSONEq(t, `[{"code": "qqqqqqqqqq", "url": "https://ozon.ru"}]`, buf.String())
+}
+
+func TestStatsTableVariants(t *testing.T) {
+	stats := statsOutput{Links: 2, CurrentID: 2, MaxID: 10, Headroom: 8, Variants: []variantOutput{
+		{Name: "A", URL: "https://ozon.ru/a", Weight: 3, Clicks: 12},
+		{Name: "new", URL: "https://ozon.ru/b", Weight: 1, Clicks: 5},
+	}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeOutput(&buf, tableOutput, stats, stats.table()))
+	assert.Equal(t, "LINKS  DISABLED  CURRENT ID  MAX ID  HEADROOM\n"+
+		"2      0         2           10      8\n"+
+		"\n"+
+		"VARIANT  URL                WEIGHT  CLICKS\n"+
+		"A        https://ozon.ru/a  3       12\n"+
+		"new      https://ozon.ru/b  1       5\n", buf.String())
+}
+
+func TestStatsTableTargets(t *testing.T) {
+	stats := statsOutput{Links: 1, CurrentID: 1, MaxID: 10, Headroom: 9, Targets: []targetOutput{
+		{Target: "default", Clicks: 4},
+		{Target: "ios", Clicks: 12},
+	}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeOutput(&buf, tableOutput, stats, stats.table()))
+	assert.Equal(t, "LINKS  DISABLED  CURRENT ID  MAX ID  HEADROOM\n"+
+		"1      0         1           10      9\n"+
+		"\n"+
+		"TARGET   CLICKS\n"+
+		"default  4\n"+
+		"ios      12\n", buf.String())
+}
+
+func TestRunClientUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, exitUsage, runClient(resolveCommand, nil, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, runClient(statsCommand, []string{"-output", "xml"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, runClient(statsCommand, []string{"qqqqqqqqqq", "qqqqqqqqqw"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, runClient(exportCommand, []string{"-format", "bitly"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, runClient(importCommand, []string{"-mode", "replace"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, runClient(exportCommand, []string{"-offline"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"unknown"}))
+}
+
+// adminClient calls the admin handlers directly, the other methods of proto.AdminClient aren't used.
+type adminClient struct {
+	proto.AdminClient
+	server proto.AdminServer
+}
+
+func (c adminClient) GetLink(ctx context.Context, in *proto.LinkCode, _ ...grpc.CallOption) (*proto.Link, error) {
+	return c.server.GetLink(ctx, in)
+}
+
+func TestRemoteResolveKeepsClicks(t *testing.T) {
+	ctx := context.Background()
+	st := inMemmory.New()
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{MaxClicks: 3}))
+
+	// client без реализации: Redirect списал бы клик и упал бы здесь
+	remote := &remoteBackend{admin: adminClient{server: admin.New(service.NewAdmin(st, nil))}}
+	for i := 0; i < 5; i++ {
+		fullURL, err := remote.Resolve(ctx, "aaaaaaaaaa")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://ozon.ru", fullURL)
+	}
+
+	link, err := st.GetLink(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), link.ClicksLeft)
+
+	assert.NoError(t, st.SetDisabled(ctx, "aaaaaaaaaa", true))
+	_, err = remote.Resolve(ctx, "aaaaaaaaaa")
+	assert.Equal(t, domainError.CodeURLDisabled, domainError.From(err).Code)
+}
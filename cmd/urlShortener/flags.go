@@ -1,6 +1,10 @@
 package main
 
-import "flag"
+import (
+	"flag"
+	"os"
+	"urlShortener/internal/config"
+)
 
 type flags struct {
 	cfgPath     string
@@ -8,16 +12,41 @@ type flags struct {
 }
 
 const defaultConfigPath = "local.yaml"
-const defaultStorageType = "inMemory"
 
-func parseFlags() *flags {
-	var cfgPath = flag.String("path", defaultConfigPath, "path to config")
-	var storageType = flag.String("storage", defaultStorageType, "path to config")
+// addServerFlags adds the flags shared by serve and the offline commands.
+func addServerFlags(fs *flag.FlagSet) *flags {
+	f := &flags{}
+	fs.StringVar(&f.cfgPath, "path", "", "path to config, "+defaultConfigPath+" if it exists; without a file the config comes from "+config.EnvPrefix+"_* variables")
+	fs.StringVar(&f.storageType, "storage", "", "storage type: inMemory or postgres, overrides storage.type of the config")
+	return f
+}
+
+func parseFlags(args []string) (*flags, error) {
+	fs := flag.NewFlagSet(serveCommand, flag.ContinueOnError)
+	f := addServerFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
 
-	flag.Parse()
+// loadConfig reads the config and applies the flags over it.
+func (f *flags) loadConfig() (*config.Config, error) {
+	var overrides []config.Override
+	if f.storageType != "" {
+		overrides = append(overrides, config.Override{Key: "storage.type", Value: f.storageType})
+	}
+	return config.MustParseConfig(configPath(f.cfgPath), overrides...)
+}
 
-	return &flags{
-		cfgPath:     *cfgPath,
-		storageType: *storageType,
+// configPath falls back to local.yaml only if it exists, so the container can run with variables alone.
+func configPath(path string) string {
+	if path != "" {
+		return path
+	}
+	if _, err := os.Stat(defaultConfigPath); err == nil {
+		return defaultConfigPath
 	}
+	return ""
 }
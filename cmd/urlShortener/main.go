@@ -1,109 +1,77 @@
 package main
 
 import (
-	"context"
-	"log"
+	"fmt"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
-	"urlShortener/internal/config"
-	"urlShortener/internal/gRPC/gRPCServer"
-	"urlShortener/internal/http/httpServer"
-	route "urlShortener/internal/http/htttpHandlers/router"
-	"urlShortener/internal/lib/linkShortening"
-	"urlShortener/internal/lib/linkShortening/hashByID"
-	"urlShortener/internal/service"
-	"urlShortener/internal/storage"
-	"urlShortener/internal/storage/inMemmory"
-	"urlShortener/internal/storage/postgres"
-	_ "urlShortener/internal/storage/postgres"
-	"urlShortener/pkg/logger"
+	"strings"
+	// в образе alpine нет базы часовых поясов, а окна ссылок принимают timezone
+	_ "time/tzdata"
 )
 
-const postgresStorage = "postgres"
-const inMemoryStorage = defaultStorageType
+const (
+	serveCommand   = "serve"
+	shortenCommand = "shorten"
+	resolveCommand = "resolve"
+	deleteCommand  = "delete"
+	statsCommand   = "stats"
+	importCommand  = "import"
+	exportCommand  = "export"
+	migrateCommand = "migrate"
+)
 
-func main() {
-	flagsData := parseFlags()
+// Коды выхода стабильны, на них завязываются скрипты.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitInvalid     = 4
+	exitUnavailable = 5
+)
 
-	cfg, err := config.MustParseConfig(flagsData.cfgPath)
-	if err != nil {
-		log.Fatalf("cfg error: %v", err)
-	}
+const usage = `usage: urlShortener [command] [flags] [args]
 
-	appLogger := logger.New()
-	if err != nil {
-		log.Fatalf("log error: %v", err)
-	}
+commands:
+  serve                run the servers (default)
+  shorten <URL>...     shorten URLs
+  resolve <code>...    show destinations of short codes
+  delete <code>...     delete links, needs the admin token online
+  stats [code]         show storage stats and the clicks of the variants and targets
+                       of a link, needs the admin token online
+  export               write all links as JSON Lines or CSV, needs the admin token online
+  import               read links from JSON Lines or CSV, needs the admin token online
+  migrate              bring the postgres schema up to date
 
-	appLogger.Infof("storage: %s", flagsData.storageType)
+Client commands talk to a running instance over gRPC. With -offline they
+open the storage from the config directly, which works with postgres only.
+Run "urlShortener <command> -h" to see the flags of a command.
 
-	ctx, final := context.WithCancel(context.Background())
+exit codes: 0 ok, 1 error, 2 usage, 3 not found, 4 invalid argument, 5 unavailable
+`
 
-	var db storage.Storager
-	var hashGen linkShortening.Hasher
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	switch flagsData.storageType {
-	case postgresStorage:
-		pq, err := postgres.New(ctx, &cfg.Postgres, appLogger)
-		if err != nil {
-			appLogger.Fatalf("can't init storage: %v", err)
-		}
-		maxID, err := pq.MaxID()
-		if maxID != 0 {
-			maxID++
-		}
-		if err != nil {
-			appLogger.Fatalf("can't get maxID: %v", err)
-		}
-		hashGen = hashByID.New(maxID)
-		db = pq
-	case inMemoryStorage:
-		db = inMemmory.New()
-		hashGen = hashByID.New(0)
-	default:
-		appLogger.Fatalf("wrong storage type")
+func run(args []string) int {
+	// без команды или с флагом первым аргументом запускаем сервер, как раньше
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(args)
 	}
 
-	urlShortener := service.New(db, hashGen)
-
-	router := route.New(appLogger, urlShortener)
-
-	appLogger.Info("starting gRPCServer")
-
-	srvGRPC := gRPCServer.New(appLogger)
-
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		err = srvGRPC.Run(ctx, cfg.GRPCAddr, urlShortener)
-		if err != nil {
-			appLogger.Fatalf("can't run grpc %v: ", err)
-		}
-		wg.Done()
-	}()
-
-	srv := httpServer.New(ctx, cfg.HTTPServer, router, appLogger)
-	appLogger.Debug(cfg.HTTPServer)
-	appLogger.Info("starting HTTPServer")
-
-	wg.Add(1)
-	go func() {
-		srv.Run()
-		wg.Done()
-	}()
-
-	signalCh := make(chan os.Signal, 1)
-	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
-
-	select {
-	case <-signalCh:
-		appLogger.Info("Received interrupt signal, shutting down")
+	command, args := args[0], args[1:]
+	switch command {
+	case serveCommand:
+		return serve(args)
+	case shortenCommand, resolveCommand, deleteCommand, statsCommand, importCommand, exportCommand:
+		return runClient(command, args, os.Stdin, os.Stdout, os.Stderr)
+	case migrateCommand:
+		return migrate(args, os.Stdout, os.Stderr)
+	case "help":
+		fmt.Fprint(os.Stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		return exitUsage
 	}
-
-	final()
-	wg.Wait()
-
-	appLogger.Info("Server stopped gracefully")
 }
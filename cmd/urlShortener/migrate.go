@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"urlShortener/internal/config"
+	"urlShortener/internal/storage/postgres"
+	"urlShortener/pkg/logger"
+)
+
+// migrate brings the postgres schema up to date. The server does the same on start,
+// the command allows to do it before rolling out new instances.
+func migrate(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet(migrateCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfgPath := fs.String("path", "", "path to config, "+defaultConfigPath+" if it exists")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return exitUsage
+	}
+
+	cfg, err := config.MustParseConfig(configPath(*cfgPath))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	appLogger, err := logger.New(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	appLogger.SetOutput(stderr)
+
+	applied, err := postgres.Migrate(context.Background(), &cfg.Postgres, appLogger)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "schema is up to date, %d migrations checked\n", applied)
+	return exitOK
+}
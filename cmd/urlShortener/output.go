@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"urlShortener/internal/transfer"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+type linkOutput struct {
+	Code string `json:"code"`
+	URL  string `json:"url,omitempty"`
+}
+
+type statsOutput struct {
+	Links         uint64 `json:"links"`
+	DisabledLinks uint64 `json:"disabledLinks"`
+	CurrentID     uint64 `json:"currentID"`
+	MaxID         uint64 `json:"maxID"`
+	Headroom      uint64 `json:"headroom"`
+	// Variants are the clicks of the variants of the link asked for.
+	Variants []variantOutput `json:"variants,omitempty"`
+	// Targets are the redirects of the link asked for by chosen target.
+	Targets []targetOutput `json:"targets,omitempty"`
+}
+
+type variantOutput struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight uint32 `json:"weight"`
+	Clicks uint64 `json:"clicks"`
+}
+
+type targetOutput struct {
+	Target string `json:"target"`
+	Clicks uint64 `json:"clicks"`
+}
+
+// table is the table form of a result, the first row is the header.
+type table [][]string
+
+// writeOutput writes value as JSON or t as an aligned table.
+func writeOutput(w io.Writer, format string, value interface{}, t table) error {
+	if format == jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range t {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func linksTable(links []linkOutput) table {
+	t := table{{"CODE", "URL"}}
+	for _, link := range links {
+		t = append(t, []string{link.Code, link.URL})
+	}
+	return t
+}
+
+func deletedTable(links []linkOutput) table {
+	t := table{{"CODE", "STATUS"}}
+	for _, link := range links {
+		t = append(t, []string{link.Code, "deleted"})
+	}
+	return t
+}
+
+func (s statsOutput) table() table {
+	t := table{
+		{"LINKS", "DISABLED", "CURRENT ID", "MAX ID", "HEADROOM"},
+		{fmt.Sprint(s.Links), fmt.Sprint(s.DisabledLinks), fmt.Sprint(s.CurrentID), fmt.Sprint(s.MaxID), fmt.Sprint(s.Headroom)},
+	}
+	// пустая строка отделяет следующую таблицу, tabwriter выравнивает их отдельно
+	if len(s.Variants) > 0 {
+		t = append(t, []string{}, []string{"VARIANT", "URL", "WEIGHT", "CLICKS"})
+		for _, variant := range s.Variants {
+			t = append(t, []string{variant.Name, variant.URL, fmt.Sprint(variant.Weight), fmt.Sprint(variant.Clicks)})
+		}
+	}
+	if len(s.Targets) > 0 {
+		t = append(t, []string{}, []string{"TARGET", "CLICKS"})
+		for _, target := range s.Targets {
+			t = append(t, []string{target.Target, fmt.Sprint(target.Clicks)})
+		}
+	}
+	return t
+}
+
+func importTable(result transfer.Result) table {
+	return table{
+		{"IMPORTED", "UPDATED", "UNCHANGED", "SKIPPED"},
+		{fmt.Sprint(result.Imported), fmt.Sprint(result.Updated), fmt.Sprint(result.Unchanged), fmt.Sprint(result.Skipped)},
+	}
+}
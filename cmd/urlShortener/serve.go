@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"urlShortener/internal/config"
+	"urlShortener/internal/gRPC/gRPCHandlers/interceptors"
+	"urlShortener/internal/gRPC/gRPCServer"
+	"urlShortener/internal/health"
+	"urlShortener/internal/http/httpServer"
+	"urlShortener/internal/http/httpUtils"
+	"urlShortener/internal/http/htttpHandlers/httpRedirect"
+	"urlShortener/internal/http/htttpHandlers/middleware"
+	route "urlShortener/internal/http/htttpHandlers/router"
+	"urlShortener/internal/lib/geoIP"
+	"urlShortener/internal/metrics"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage/instrumented"
+	"urlShortener/internal/tracing"
+	"urlShortener/pkg/logger"
+)
+
+// serve runs the HTTP, gRPC and admin servers until SIGINT or SIGTERM.
+func serve(args []string) int {
+	flagsData, err := parseFlags(args)
+	if err != nil {
+		return exitUsage
+	}
+
+	cfg, err := flagsData.loadConfig()
+	if err != nil {
+		log.Fatalf("cfg error: %v", err)
+	}
+
+	appLogger, err := logger.New(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatalf("log error: %v", err)
+	}
+
+	appLogger.Infof("storage: %s", cfg.Storage.Type)
+
+	ctx, final := context.WithCancel(context.Background())
+
+	shutdownTracing, err := tracing.New(ctx, cfg.Tracing)
+	if err != nil {
+		appLogger.Fatalf("can't init tracing: %v", err)
+	}
+
+	db, hashGen, err := openStorage(ctx, cfg, cfg.Storage.Type, appLogger)
+	if err != nil {
+		appLogger.Fatalf("can't init storage: %v", err)
+	}
+
+	wg := sync.WaitGroup{}
+	migration := newMigration(ctx, db, cfg.Storage.Type, hashGen, &wg, appLogger)
+	if err = migration.Apply(cfg); err != nil {
+		appLogger.Fatalf("can't init migration: %v", err)
+	}
+
+	appMetrics := metrics.New()
+	appMetrics.RegisterHashCounter(hashGen)
+
+	instrumentedDB := instrumented.New(migration.Storage(), appMetrics)
+	urlShortener := service.New(instrumentedDB, hashGen, service.WithPasswordAttempts(cfg.Passwords.MaxAttempts, cfg.Passwords.AttemptWindow))
+	healthChecker := health.New(instrumentedDB, hashGen, cfg.Health)
+
+	visitors, err := openVisitors(cfg)
+	if err != nil {
+		appLogger.Fatalf("can't init geo database: %v", err)
+	}
+	if visitors.Countries == nil {
+		appLogger.Info("geo database is not set, country targets are not used")
+	}
+
+	redirects := httpRedirect.NewPolicy(cfg.Redirect)
+	router := route.New(appLogger, urlShortener, redirects, visitors, cfg.PublicURL, appMetrics, middleware.MetricsMiddleware(appMetrics))
+
+	appLogger.Info("starting gRPCServer")
+
+	srvGRPC := gRPCServer.New(appLogger,
+		gRPCServer.WithUnaryInterceptors(interceptors.MetricsInterceptor(appMetrics)),
+		gRPCServer.WithPanicObserver(appMetrics),
+		gRPCServer.WithPublicURL(cfg.PublicURL))
+	// gRPC балансировщики получают то же окно ShutdownDelay, что и HTTP
+	healthChecker.OnShutdown(srvGRPC.StartShutdown)
+
+	wg.Add(1)
+	go func() {
+		err = srvGRPC.Run(ctx, cfg.GRPCAddr, urlShortener)
+		if err != nil {
+			appLogger.Fatalf("can't run grpc %v: ", err)
+		}
+		wg.Done()
+	}()
+
+	if cfg.AdminGRPC.Token == "" {
+		appLogger.Warn("admin gRPC token is not set, admin gRPC is disabled")
+	} else {
+		appLogger.Info("starting admin gRPCServer")
+
+		adminGRPC := gRPCServer.NewAdmin(appLogger, cfg.AdminGRPC.Token,
+			gRPCServer.WithUnaryInterceptors(interceptors.MetricsInterceptor(appMetrics)),
+			gRPCServer.WithPanicObserver(appMetrics))
+		admin := service.NewAdmin(instrumentedDB, hashGen)
+
+		wg.Add(1)
+		go func() {
+			err := adminGRPC.Run(ctx, cfg.AdminGRPC.Network, cfg.AdminGRPC.Address, admin)
+			if err != nil {
+				appLogger.Fatalf("can't run admin grpc %v: ", err)
+			}
+			wg.Done()
+		}()
+	}
+
+	srv := httpServer.New(ctx, cfg.HTTPServer, router, appLogger)
+	appLogger.WithField("address", cfg.HTTPServer.Address).Debug("HTTPServer config")
+	appLogger.Info("starting HTTPServer")
+
+	wg.Add(1)
+	go func() {
+		srv.Run()
+		wg.Done()
+	}()
+
+	adminSrv := httpServer.New(ctx, cfg.AdminServer, route.NewAdmin(appLogger, appMetrics.Handler(), healthChecker), appLogger)
+	appLogger.Info("starting admin HTTPServer")
+
+	wg.Add(1)
+	go func() {
+		adminSrv.Run()
+		wg.Done()
+	}()
+
+	reloader := config.NewReloader(cfg, flagsData.loadConfig, appLogger)
+	reloader.OnReload(func(cfg *config.Config) {
+		if err := logger.Configure(appLogger, cfg.Log.Level, cfg.Log.Format); err != nil {
+			appLogger.Errorf("can't reconfigure logger: %v", err)
+		}
+		srv.SetTimeout(cfg.HTTPServer.Timeout)
+		adminSrv.SetTimeout(cfg.AdminServer.Timeout)
+		healthChecker.SetConfig(cfg.Health)
+		redirects.SetConfig(cfg.Redirect)
+		urlShortener.SetPasswordAttempts(cfg.Passwords.MaxAttempts, cfg.Passwords.AttemptWindow)
+	})
+	reloader.OnReload(func(cfg *config.Config) {
+		// подключение к target может ждать базу, сигналы в это время должны обрабатываться
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := migration.Apply(cfg); err != nil {
+				appLogger.Errorf("can't apply migration: %v", err)
+			}
+		}()
+	})
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	for sig := range signalCh {
+		if sig == syscall.SIGHUP {
+			appLogger.Info("Received SIGHUP, reloading config")
+			// ошибку уже записал reloader, работаем со старым конфигом
+			_, _ = reloader.Reload()
+			continue
+		}
+		appLogger.Info("Received interrupt signal, shutting down")
+		break
+	}
+
+	healthChecker.StartShutdown()
+	time.Sleep(reloader.Current().Health.ShutdownDelay)
+
+	final()
+	wg.Wait()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelShutdown()
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		appLogger.Errorf("can't flush spans: %v", err)
+	}
+
+	appLogger.Info("Server stopped gracefully")
+	return exitOK
+}
+
+// openVisitors reads the trusted proxies and loads the geo database of the config.
+func openVisitors(cfg *config.Config) (httpRedirect.Visitors, error) {
+	proxies, err := httpUtils.ParseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return httpRedirect.Visitors{}, err
+	}
+	visitors := httpRedirect.Visitors{Proxies: proxies}
+	if cfg.Geo.Database == "" {
+		return visitors, nil
+	}
+
+	db, err := geoIP.Open(cfg.Geo.Database, geoIP.Format(cfg.Geo.Format))
+	if err != nil {
+		return httpRedirect.Visitors{}, err
+	}
+	visitors.Countries = db
+	return visitors, nil
+}
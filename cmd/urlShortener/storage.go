@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"urlShortener/internal/config"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/breaker"
+	"urlShortener/internal/storage/dualWrite"
+	"urlShortener/internal/storage/inMemmory"
+	"urlShortener/internal/storage/postgres"
+)
+
+const postgresStorage = "postgres"
+const inMemoryStorage = "inMemory"
+
+const readFromTarget = "target"
+
+var errWrongStorage = errors.New("wrong storage type")
+
+// openStorage opens the storage of storageType, it's cfg.Storage.Type or the migration target.
+// The hasher continues after the stored IDs.
+func openStorage(ctx context.Context, cfg *config.Config, storageType string, logger *logrus.Logger) (storage.Storager, *hashByID.HashGenerator, error) {
+	switch storageType {
+	case postgresStorage:
+		pq, err := postgres.New(ctx, &cfg.Postgres, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		maxID, err := pq.MaxID()
+		if err != nil {
+			return nil, nil, err
+		}
+		if maxID != 0 {
+			maxID++
+		}
+		db := breaker.New(pq, cfg.Postgres.BreakerThreshold, cfg.Postgres.BreakerCooldown, logger)
+		return db, hashByID.New(maxID), nil
+	case inMemoryStorage:
+		return inMemmory.New(), hashByID.New(0), nil
+	default:
+		return nil, nil, errWrongStorage
+	}
+}
+
+// migration moves the links to cfg.Migration.Target while the server runs. It's started on start
+// or later by a reload, so the links of the in-memory storage are copied without a restart.
+type migration struct {
+	ctx         context.Context
+	source      storage.Storager
+	storageType string
+	hashGen     *hashByID.HashGenerator
+	storages    *dualWrite.Switch
+	wg          *sync.WaitGroup
+	logger      *logrus.Logger
+
+	mu         sync.Mutex
+	target     storage.Storager
+	targetType string
+	readFrom   string
+}
+
+// newMigration serves from source until a migration target is applied. The job runs with ctx and is
+// counted in wg.
+func newMigration(ctx context.Context, source storage.Storager, storageType string, hashGen *hashByID.HashGenerator, wg *sync.WaitGroup, logger *logrus.Logger) *migration {
+	return &migration{
+		ctx:         ctx,
+		source:      source,
+		storageType: storageType,
+		hashGen:     hashGen,
+		storages:    dualWrite.NewSwitch(source),
+		wg:          wg,
+		logger:      logger,
+	}
+}
+
+// Storage is what the service uses, the source or the dual-write storage once the migration started.
+func (m *migration) Storage() storage.Storager {
+	return m.storages
+}
+
+// Apply starts the migration to cfg.Migration.Target, then only readFrom can change until a restart.
+// The target is opened with the postgres settings of cfg.
+func (m *migration) Apply(cfg *config.Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	settings := cfg.Migration
+	switch {
+	case m.target == nil && settings.Target == "":
+		return nil
+	case m.target == nil:
+		return m.start(cfg)
+	case settings.Target != m.targetType:
+		return fmt.Errorf("%w: migration to %s is running, restart to change the target", errWrongStorage, m.targetType)
+	case settings.ReadFrom != m.readFrom:
+		m.switchReads(settings.ReadFrom)
+		m.logger.Infof("migration to %s: reading from %s first", m.targetType, settings.ReadFrom)
+	}
+	return nil
+}
+
+func (m *migration) start(cfg *config.Config) error {
+	settings := cfg.Migration
+	if settings.Target == m.storageType {
+		return fmt.Errorf("%w: migration target is the current storage %s", errWrongStorage, m.storageType)
+	}
+
+	target, targetHashGen, err := openStorage(m.ctx, cfg, settings.Target, m.logger)
+	if err != nil {
+		return err
+	}
+	// в target могут быть ссылки от прошлой попытки, новые коды не должны с ними совпасть
+	m.hashGen.Advance(targetHashGen.CurrentID())
+
+	m.target, m.targetType = target, settings.Target
+	m.switchReads(settings.ReadFrom)
+	m.logger.Infof("migration to %s: writing to both storages, reading from %s first", settings.Target, settings.ReadFrom)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		// ошибка миграции не останавливает сервис: двойная запись продолжается
+		err := dualWrite.RunJob(m.ctx, m.source, target, dualWrite.JobOptions{
+			Backfill:      settings.Backfill,
+			Verify:        settings.Verify,
+			ProgressEvery: settings.ProgressEvery,
+		}, m.logger)
+		if err != nil && m.ctx.Err() == nil {
+			m.logger.Errorf("migration failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (m *migration) switchReads(readFrom string) {
+	primary, secondary := m.source, m.target
+	if readFrom == readFromTarget {
+		primary, secondary = m.target, m.source
+	}
+	m.storages.Set(dualWrite.New(primary, secondary, m.logger))
+	m.readFrom = readFrom
+}
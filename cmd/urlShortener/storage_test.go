@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"urlShortener/internal/config"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/dualWrite"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+func TestMigrationStartedByReload(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// источник только притворяется postgres, чтобы целью мог быть inMemory
+	source := inMemmory.New()
+	assert.NoError(t, source.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	wg := sync.WaitGroup{}
+	m := newMigration(ctx, source, postgresStorage, hashByID.New(1), &wg, logger)
+
+	cfg := &config.Config{Storage: config.StorageConfig{Type: postgresStorage}}
+	cfg.Migration = config.MigrationConfig{ReadFrom: "source", Backfill: true, ProgressEvery: 1}
+	assert.NoError(t, m.Apply(cfg))
+	assert.Same(t, storage.Storager(source), m.storages.Current())
+
+	cfg.Migration.Target = inMemoryStorage
+	assert.NoError(t, m.Apply(cfg))
+	wg.Wait()
+
+	// ссылка, сохраненная до миграции, скопирована без перезапуска
+	target := m.target.(*inMemmory.Storage)
+	link, err := target.GetLink(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullURL)
+
+	// новые ссылки пишутся в оба хранилища
+	assert.NoError(t, m.Storage().SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+	_, err = target.GetLink(ctx, "bbbbbbbbbb")
+	assert.NoError(t, err)
+
+	cfg.Migration.ReadFrom = readFromTarget
+	assert.NoError(t, m.Apply(cfg))
+	assert.IsType(t, &dualWrite.Storage{}, m.storages.Current())
+
+	cfg.Migration.Target = ""
+	assert.ErrorIs(t, m.Apply(cfg), errWrongStorage)
+}
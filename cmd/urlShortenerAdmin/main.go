@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+	"urlShortener/internal/gRPC/gRPCClient"
+	"urlShortener/internal/gRPC/proto"
+)
+
+const tokenEnv = "URLSHORTENER_ADMIN_TOKEN"
+
+const usage = `usage: urlShortenerAdmin [flags] <command> [args]
+
+commands:
+  get <code>              show the link by its short code
+  find <URL>              show the link by its destination
+  delete <code>           delete the link
+  disable <code>          stop redirecting the link
+  enable <code>           redirect the link again
+  reassign <from> <to>    move all destinations from one host to another
+  counter                 show the ID counter
+  stats [code]            show storage stats, with a code also the clicks of its variants and targets
+
+flags:
+`
+
+func main() {
+	network := flag.String("network", "unix", "admin listener network: tcp or unix")
+	addr := flag.String("addr", "/tmp/urlShortener-admin.sock", "admin listener address or socket path")
+	token := flag.String("token", "", "admin token, "+tokenEnv+" is used if empty")
+	timeout := flag.Duration("timeout", 10*time.Second, "request timeout")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *token == "" {
+		*token = os.Getenv(tokenEnv)
+	}
+
+	client, conn, err := gRPCClient.DialAdmin(*network, *addr, *token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err = run(ctx, client, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if err == errUsage {
+			flag.Usage()
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("wrong command or arguments")
+
+func run(ctx context.Context, client proto.AdminClient, command string, args []string) error {
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer out.Flush()
+
+	switch {
+	case command == "get" && len(args) == 1:
+		link, err := client.GetLink(ctx, &proto.LinkCode{Code: args[0]})
+		if err != nil {
+			return err
+		}
+		printLink(out, link)
+	case command == "find" && len(args) == 1:
+		link, err := client.FindLink(ctx, &proto.FindLinkRequest{FullUrl: args[0]})
+		if err != nil {
+			return err
+		}
+		printLink(out, link)
+	case command == "delete" && len(args) == 1:
+		if _, err := client.DeleteLink(ctx, &proto.LinkCode{Code: args[0]}); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "deleted\t%s\n", args[0])
+	case (command == "disable" || command == "enable") && len(args) == 1:
+		link, err := client.SetDisabled(ctx, &proto.SetDisabledRequest{Code: args[0], Disabled: command == "disable"})
+		if err != nil {
+			return err
+		}
+		printLink(out, link)
+	case command == "reassign" && len(args) == 2:
+		resp, err := client.ReassignDomain(ctx, &proto.ReassignDomainRequest{From: args[0], To: args[1]})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "changed\t%d\n", resp.Changed)
+	case command == "counter" && len(args) == 0:
+		counter, err := client.GetCounterStatus(ctx, &proto.CounterStatusRequest{})
+		if err != nil {
+			return err
+		}
+		printCounter(out, counter)
+	case command == "stats" && len(args) <= 1:
+		req := &proto.StatsRequest{}
+		if len(args) == 1 {
+			req.Code = args[0]
+		}
+		stats, err := client.GetStats(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "links\t%d\n", stats.Links)
+		fmt.Fprintf(out, "disabled links\t%d\n", stats.DisabledLinks)
+		printCounter(out, stats.Counter)
+		for _, variant := range stats.Variants {
+			fmt.Fprintf(out, "variant %s\t%d clicks, weight %d, %s\n", variant.Variant.GetName(), variant.Clicks,
+				variant.Variant.GetWeight(), variant.Variant.GetURL())
+		}
+		for _, target := range stats.Targets {
+			fmt.Fprintf(out, "target %s\t%d clicks\n", target.Target, target.Clicks)
+		}
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+func printLink(out *tabwriter.Writer, link *proto.Link) {
+	fmt.Fprintf(out, "id\t%d\n", link.Id)
+	fmt.Fprintf(out, "code\t%s\n", link.Code)
+	fmt.Fprintf(out, "URL\t%s\n", link.FullUrl)
+	fmt.Fprintf(out, "created at\t%s\n", link.CreatedAt.AsTime().Format(time.RFC3339))
+	fmt.Fprintf(out, "disabled\t%t\n", link.Disabled)
+	fmt.Fprintf(out, "redirect\t%d\n", link.Redirect)
+	fmt.Fprintf(out, "query\t%s\n", link.Query)
+	fmt.Fprintf(out, "path passthrough\t%t\n", link.PathPassthrough)
+	fmt.Fprintf(out, "protected\t%t\n", link.PasswordHash != "")
+	if link.MaxClicks > 0 {
+		fmt.Fprintf(out, "clicks left\t%d of %d\n", link.ClicksLeft, link.MaxClicks)
+	}
+	if link.NotBefore != nil {
+		fmt.Fprintf(out, "not before\t%s\n", link.NotBefore.AsTime().Format(time.RFC3339))
+	}
+	if link.NotAfter != nil {
+		fmt.Fprintf(out, "not after\t%s\n", link.NotAfter.AsTime().Format(time.RFC3339))
+	}
+	if link.FallbackUrl != "" {
+		fmt.Fprintf(out, "fallback URL\t%s\n", link.FallbackUrl)
+	}
+	for _, target := range []struct{ device, url string }{
+		{"iOS", link.GetTargets().GetIos()},
+		{"Android", link.GetTargets().GetAndroid()},
+		{"desktop", link.GetTargets().GetDesktop()},
+		{"bot", link.GetTargets().GetBot()},
+	} {
+		if target.url != "" {
+			fmt.Fprintf(out, "%s URL\t%s\n", target.device, target.url)
+		}
+	}
+	countries := make([]string, 0, len(link.Countries))
+	for country := range link.Countries {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	for _, country := range countries {
+		fmt.Fprintf(out, "%s URL\t%s\n", country, link.Countries[country])
+	}
+	for _, variant := range link.Variants {
+		fmt.Fprintf(out, "variant %s\t%s, weight %d\n", variant.Name, variant.URL, variant.Weight)
+	}
+	if len(link.Variants) > 0 {
+		fmt.Fprintf(out, "sticky\t%s\n", link.Sticky)
+	}
+}
+
+func printCounter(out *tabwriter.Writer, counter *proto.CounterStatus) {
+	fmt.Fprintf(out, "current ID\t%d\n", counter.CurrentId)
+	fmt.Fprintf(out, "max ID\t%d\n", counter.MaxId)
+	fmt.Fprintf(out, "headroom\t%d\n", counter.Headroom)
+}
@@ -1,27 +1,98 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 	"urlShortener/utils"
 	"urlShortener/utils/e"
 )
 
+const (
+	inMemoryStorage = "inMemory"
+	postgresStorage = "postgres"
+)
+
+var (
+	ErrPasswordTwice = errors.New("postgres password is set both in the DSN and separately")
+	dsnPassword      = regexp.MustCompile(`(^|\s)password\s*=`)
+)
+
 type Config struct {
-	Postgres   PostgresConfig   `yaml:"postgres"`
+	Storage StorageConfig `yaml:"storage"`
+	// Postgres is validated only when the postgres storage is used.
+	Postgres   PostgresConfig   `yaml:"postgres" validate:"-"`
 	HTTPServer HTTPServerConfig `yaml:"httpServer"`
-	GRPCAddr   string           `yaml:"grpcAddr" validate:"required"`
+	Redirect   RedirectConfig   `yaml:"redirect"`
+	Passwords  PasswordConfig   `yaml:"passwords"`
+	// TrustedProxies - addresses or CIDR networks of the reverse proxies in front of the HTTP server,
+	// the address of the client is taken from X-Forwarded-For behind them. They are read on start.
+	TrustedProxies []string  `yaml:"trustedProxies" validate:"dive,cidr|ip"`
+	Geo            GeoConfig `yaml:"geo"`
+	// PublicURL is the address short links are shared with, like https://sho.rt. QR codes encode it,
+	// without it HTTP uses the host of the request and gRPC can't render them.
+	PublicURL   string           `yaml:"publicURL" validate:"omitempty,url"`
+	AdminServer HTTPServerConfig `yaml:"adminServer"`
+	GRPCAddr    string           `yaml:"grpcAddr" validate:"required"`
+	AdminGRPC   AdminGRPCConfig  `yaml:"adminGRPC"`
+	Tracing     TracingConfig    `yaml:"tracing"`
+	Health      HealthConfig     `yaml:"health"`
+	Log         LogConfig        `yaml:"log"`
+	Migration   MigrationConfig  `yaml:"migration"`
 }
 
+type StorageConfig struct {
+	Type string `yaml:"type" validate:"oneof=inMemory postgres"`
+}
+
+// PostgresConfig - connection parameters or DSN. With DSN the other connection fields are not needed,
+// only Password is added to a DSN without one, so it can come from a secret file.
 type PostgresConfig struct {
-	Login    string `yaml:"login" validate:"required"`
-	Password string `yaml:"password" validate:"required"`
-	Host     string `yaml:"host" validate:"required"`
-	Port     string `yaml:"port" validate:"required,numeric"`
-	DBName   string `yaml:"dbname" validate:"required"`
-	SSLMode  string `yaml:"sslMode" validate:"required"`
+	DSN      string `yaml:"dsn"`
+	Login    string `yaml:"login" validate:"required_without=DSN"`
+	Password string `yaml:"password" validate:"required_without=DSN"`
+	Host     string `yaml:"host" validate:"required_without=DSN"`
+	Port     string `yaml:"port" validate:"required_without=DSN,omitempty,numeric"`
+	DBName   string `yaml:"dbname" validate:"required_without=DSN"`
+	SSLMode  string `yaml:"sslMode" validate:"required_without=DSN"`
+	// ConnectMaxWait limits the wait for the database on start, ConnectBackoff is the first pause between attempts.
+	ConnectMaxWait time.Duration `yaml:"connectMaxWait"`
+	ConnectBackoff time.Duration `yaml:"connectBackoff" validate:"gt=0"`
+	// After BreakerThreshold failed queries in a row requests fail fast for BreakerCooldown.
+	BreakerThreshold int           `yaml:"breakerThreshold" validate:"gt=0"`
+	BreakerCooldown  time.Duration `yaml:"breakerCooldown"`
+	// Replicas - DSNs of read replicas, redirect lookups go to them while they answer pings.
+	// Password is added to the replica DSNs without one.
+	Replicas             []string      `yaml:"replicas" validate:"dive,required"`
+	ReplicaCheckInterval time.Duration `yaml:"replicaCheckInterval" validate:"gt=0"`
+}
+
+// RedirectConfig - redirects of links created without an explicit status. Clients keep permanent redirects
+// for PermanentMaxAge: a link disabled or changed later still redirects them until it runs out.
+type RedirectConfig struct {
+	Status          int           `yaml:"status" validate:"oneof=301 302 307 308"`
+	PermanentMaxAge time.Duration `yaml:"permanentMaxAge" validate:"gte=0"`
+}
+
+// PasswordConfig - a client that enters MaxAttempts wrong passwords of a link can't open it for AttemptWindow.
+type PasswordConfig struct {
+	MaxAttempts   int           `yaml:"maxAttempts" validate:"gt=0"`
+	AttemptWindow time.Duration `yaml:"attemptWindow" validate:"gt=0"`
+}
+
+// GeoConfig - offline IP database for the country targets of links, it is loaded on start. Without
+// Database every visitor goes to the other targets.
+type GeoConfig struct {
+	Database string `yaml:"database"`
+	// Format is mmdb for MaxMind DB or csv for IP ranges, empty chooses it by the extension of Database.
+	Format string `yaml:"format" validate:"omitempty,oneof=mmdb csv"`
 }
 
 type HTTPServerConfig struct {
@@ -30,42 +101,225 @@ type HTTPServerConfig struct {
 	IdleTimeout time.Duration `yaml:"idleTimeout"`
 }
 
-func MustParseConfig(configPath string) (*Config, error) {
+// AdminGRPCConfig - admin gRPC service. It is not started while Token is empty.
+type AdminGRPCConfig struct {
+	Network string `yaml:"network" validate:"oneof=tcp unix"`
+	Address string `yaml:"address" validate:"required"`
+	Token   string `yaml:"token"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" validate:"oneof=none stdout file otlp"`
+	Endpoint    string  `yaml:"endpoint" validate:"required_if=Exporter otlp"`
+	Insecure    bool    `yaml:"insecure"`
+	FilePath    string  `yaml:"filePath" validate:"required_if=Exporter file"`
+	ServiceName string  `yaml:"serviceName"`
+	SampleRatio float64 `yaml:"sampleRatio" validate:"gte=0,lte=1"`
+}
+
+type HealthConfig struct {
+	MinIDHeadroom uint64        `yaml:"minIDHeadroom"`
+	CheckTimeout  time.Duration `yaml:"checkTimeout"`
+	ShutdownDelay time.Duration `yaml:"shutdownDelay"`
+}
+
+// MigrationConfig - online migration from the storage chosen with -storage to Target.
+// While Target is set, every write goes to both storages. Setting Target on a running server with
+// a reload starts the migration without losing the links of the in-memory storage.
+type MigrationConfig struct {
+	Target string `yaml:"target" validate:"omitempty,oneof=inMemory postgres"`
+	// ReadFrom is the storage asked first, the other one is asked for links it doesn't have.
+	ReadFrom      string `yaml:"readFrom" validate:"oneof=source target"`
+	Backfill      bool   `yaml:"backfill"`
+	Verify        bool   `yaml:"verify"`
+	ProgressEvery uint64 `yaml:"progressEvery" validate:"gt=0"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" validate:"oneof=trace debug info warn warning error fatal panic"`
+	Format string `yaml:"format" validate:"oneof=json text"`
+}
+
+// Override sets a key over the file and the variables, command line flags are applied this way.
+type Override struct {
+	Key   string
+	Value any
+}
+
+// MustParseConfig reads the file, applies URLSHORTENER_* variables and overrides over it and validates the result.
+// With an empty configPath the config comes from the variables and the defaults only.
+func MustParseConfig(configPath string, overrides ...Override) (*Config, error) {
 	const fn = "internal.config.MustParseConfig"
 
-	if !fileExists(configPath) {
+	if configPath != "" && !fileExists(configPath) {
 		return nil, e.WrapError(fn, os.ErrNotExist)
 	}
 
-	cfg, err := readConfig(configPath)
+	cfg, err := readConfig(configPath, overrides...)
 	if err != nil {
 		return nil, e.WrapError(fn, err)
 	}
 
-	if err = validator.New().Struct(cfg); err != nil {
-		err, ok := err.(validator.ValidationErrors)
+	if err = validateConfig(cfg); err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	return cfg, nil
+}
+
+func validateConfig(cfg *Config) error {
+	validate := validator.New()
+
+	err := validate.Struct(cfg)
+	if err == nil && cfg.UsesPostgres() {
+		err = validate.Struct(cfg.Postgres)
+		if err == nil {
+			if _, err = cfg.Postgres.ConnString(); err != nil {
+				return err
+			}
+			if _, err = cfg.Postgres.ReplicaConnStrings(); err != nil {
+				return err
+			}
+		}
+	}
+	if err != nil {
+		validationErrs, ok := err.(validator.ValidationErrors)
 		if !ok {
-			return nil, e.WrapError(fn, err)
+			return err
 		}
-		return nil, e.WrapError(fn, utils.ValidateErrors(err))
+		return utils.ValidateErrors(validationErrs)
 	}
-	return cfg, nil
+	return nil
+}
+
+// ConnString returns the connection string of the database. DSN is a postgres:// URL or key=value pairs.
+func (c *PostgresConfig) ConnString() (string, error) {
+	if c.DSN == "" {
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			c.Host, c.Port, c.Login, quoteConnValue(c.Password), c.DBName, c.SSLMode), nil
+	}
+	if c.Password == "" {
+		return c.DSN, nil
+	}
+
+	hasPassword, err := dsnHasPassword(c.DSN)
+	if err != nil {
+		return "", err
+	}
+	if hasPassword {
+		return "", ErrPasswordTwice
+	}
+	return addPassword(c.DSN, c.Password)
+}
+
+// ReplicaConnStrings returns the connection strings of the replicas. Password is added to the DSNs
+// without one like to the primary DSN, a replica with its own password keeps it.
+func (c *PostgresConfig) ReplicaConnStrings() ([]string, error) {
+	dsns := make([]string, 0, len(c.Replicas))
+	for i, dsn := range c.Replicas {
+		hasPassword, err := dsnHasPassword(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		if c.Password != "" && !hasPassword {
+			if dsn, err = addPassword(dsn, c.Password); err != nil {
+				return nil, fmt.Errorf("replica %d: %w", i+1, err)
+			}
+		}
+		dsns = append(dsns, dsn)
+	}
+	return dsns, nil
+}
+
+func isURLConn(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+func dsnHasPassword(dsn string) (bool, error) {
+	if !isURLConn(dsn) {
+		return dsnPassword.MatchString(dsn), nil
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return false, err
+	}
+	_, ok := parsed.User.Password()
+	return ok, nil
+}
+
+// addPassword adds password to a DSN without one.
+func addPassword(dsn string, password string) (string, error) {
+	if !isURLConn(dsn) {
+		return dsn + " password=" + quoteConnValue(password), nil
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	parsed.User = url.UserPassword(parsed.User.Username(), password)
+	return parsed.String(), nil
 }
 
-func readConfig(configPath string) (*Config, error) {
+// quoteConnValue quotes a value of key=value connection strings, so spaces and quotes in passwords survive.
+func quoteConnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// UsesPostgres reports whether the postgres storage is used directly or as the migration target.
+func (c *Config) UsesPostgres() bool {
+	return c.Storage.Type == postgresStorage || c.Migration.Target == postgresStorage
+}
+
+func readConfig(configPath string, overrides ...Override) (*Config, error) {
 	const fn = "internal.config.readConfig"
 
-	viper.SetConfigFile(configPath)
-	viper.SetDefault("httpServer.timeout", time.Second*10)
-	viper.SetDefault("httpServer.idleTimeout", time.Minute)
+	v := viper.New()
+	v.SetDefault("storage.type", inMemoryStorage)
+	v.SetDefault("httpServer.address", ":3000")
+	v.SetDefault("grpcAddr", "0.0.0.0:3030")
+	v.SetDefault("postgres.connectMaxWait", 30*time.Second)
+	v.SetDefault("postgres.connectBackoff", 500*time.Millisecond)
+	v.SetDefault("postgres.breakerThreshold", 5)
+	v.SetDefault("postgres.breakerCooldown", 5*time.Second)
+	v.SetDefault("postgres.replicaCheckInterval", 5*time.Second)
+	v.SetDefault("httpServer.timeout", time.Second*10)
+	v.SetDefault("httpServer.idleTimeout", time.Minute)
+	v.SetDefault("redirect.status", http.StatusFound)
+	v.SetDefault("redirect.permanentMaxAge", 30*24*time.Hour)
+	v.SetDefault("passwords.maxAttempts", 5)
+	v.SetDefault("passwords.attemptWindow", 15*time.Minute)
+	v.SetDefault("adminServer.address", ":9090")
+	v.SetDefault("adminServer.timeout", time.Second*10)
+	v.SetDefault("adminServer.idleTimeout", time.Minute)
+	v.SetDefault("adminGRPC.network", "tcp")
+	v.SetDefault("adminGRPC.address", "localhost:3031")
+	v.SetDefault("tracing.exporter", "none")
+	v.SetDefault("tracing.serviceName", "urlShortener")
+	v.SetDefault("tracing.sampleRatio", 1.0)
+	v.SetDefault("health.minIDHeadroom", uint64(1_000_000))
+	v.SetDefault("health.checkTimeout", time.Second)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "json")
+	v.SetDefault("migration.readFrom", "source")
+	v.SetDefault("migration.backfill", true)
+	v.SetDefault("migration.verify", true)
+	v.SetDefault("migration.progressEvery", uint64(1000))
 
-	if err := viper.ReadInConfig(); err != nil {
+	if configPath != "" {
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, e.WrapError(fn, err)
+		}
+	}
+	if err := bindEnv(v); err != nil {
 		return nil, e.WrapError(fn, err)
 	}
+	for _, override := range overrides {
+		v.Set(override.Key, override.Value)
+	}
 
 	var cfg Config
 
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, e.WrapError(fn, err)
 	}
 
@@ -84,7 +84,7 @@ func TestMustParseConfigInvalidJSON(t *testing.T) {
 }
 
 func TestMustParseConfigValidateErrorRequired(t *testing.T) {
-	tempFile := createTempFile(t, []byte("postgres:\n  login: \"postgres\"\n  "+
+	tempFile := createTempFile(t, []byte("storage:\n  type: \"postgres\"\npostgres:\n  login: \"postgres\"\n  "+
 		"password: \"dfsdf\"\n  host: \"localhost\"\n"))
 	defer clearTempFile(t, tempFile.Name())
 
@@ -94,7 +94,7 @@ func TestMustParseConfigValidateErrorRequired(t *testing.T) {
 }
 
 func TestMustParseConfigValidateErrorNumeric(t *testing.T) {
-	tempFile := createTempFile(t, []byte("postgres:\n  login: \"postgres\"\n  "+
+	tempFile := createTempFile(t, []byte("storage:\n  type: \"postgres\"\npostgres:\n  login: \"postgres\"\n  "+
 		"password: \"123123\"\n  host: \"localhost\"\n  port: \"dfdfd\"\n  dbname:"+
 		" \"urlshortener\"\n  sslMode: \"disable\"\nhttpServer:\n  "+
 		"address: \"localhost:8081\"\n  timeout: 4s\n  "+
@@ -106,6 +106,15 @@ func TestMustParseConfigValidateErrorNumeric(t *testing.T) {
 	assert.Nil(t, cfg)
 }
 
+func TestMustParseConfigValidateErrorTrustedProxy(t *testing.T) {
+	tempFile := createTempFile(t, []byte("trustedProxies:\n  - \"10.0.0.0/8\"\n  - \"lb.local\"\n"))
+	defer clearTempFile(t, tempFile.Name())
+
+	cfg, err := MustParseConfig(tempFile.Name())
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+}
+
 func TestMustParseConfigSuccess(t *testing.T) {
 	tempCfg := createTempFile(t, []byte("postgres:\n  login: \"postgres\"\n  "+
 		"password: \"123123\"\n  host: \"localhost\"\n  port: \"5432\"\n  dbname:"+
@@ -119,6 +128,9 @@ func TestMustParseConfigSuccess(t *testing.T) {
 	assert.NotNil(t, cfg)
 
 	absoluteCfg := Config{
+		Storage: StorageConfig{
+			Type: "inMemory",
+		},
 		Postgres: PostgresConfig{
 			Login:    "postgres",
 			Password: "123123",
@@ -126,13 +138,55 @@ func TestMustParseConfigSuccess(t *testing.T) {
 			Port:     "5432",
 			DBName:   "urlshortener",
 			SSLMode:  "disable",
+
+			ConnectMaxWait:       30 * time.Second,
+			ConnectBackoff:       500 * time.Millisecond,
+			BreakerThreshold:     5,
+			BreakerCooldown:      5 * time.Second,
+			ReplicaCheckInterval: 5 * time.Second,
 		},
 		HTTPServer: HTTPServerConfig{
 			Address:     "localhost:8081",
 			Timeout:     4 * time.Second,
 			IdleTimeout: time.Minute,
 		},
+		Redirect: RedirectConfig{
+			Status:          302,
+			PermanentMaxAge: 30 * 24 * time.Hour,
+		},
+		Passwords: PasswordConfig{
+			MaxAttempts:   5,
+			AttemptWindow: 15 * time.Minute,
+		},
+		AdminServer: HTTPServerConfig{
+			Address:     ":9090",
+			Timeout:     10 * time.Second,
+			IdleTimeout: time.Minute,
+		},
 		GRPCAddr: "127.0.0.1:8082",
+		AdminGRPC: AdminGRPCConfig{
+			Network: "tcp",
+			Address: "localhost:3031",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "urlShortener",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			MinIDHeadroom: 1_000_000,
+			CheckTimeout:  time.Second,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Migration: MigrationConfig{
+			ReadFrom:      "source",
+			Backfill:      true,
+			Verify:        true,
+			ProgressEvery: 1000,
+		},
 	}
 
 	assert.Equal(t, absoluteCfg, *cfg)
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	// EnvPrefix starts the variables overriding the config: postgres.password is URLSHORTENER_POSTGRES_PASSWORD.
+	EnvPrefix = "URLSHORTENER"
+	// FileSuffix marks a variable holding the path to a file with the value, as Docker and Kubernetes secrets are mounted.
+	FileSuffix = "_FILE"
+)
+
+// EnvName returns the variable that overrides the config key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindEnv makes every config field overridable by its variable or by the file the _FILE variable points to.
+// Keys are bound explicitly, so the variables work without a config file too.
+func bindEnv(v *viper.Viper) error {
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		name := EnvName(key)
+		if err := v.BindEnv(key, name); err != nil {
+			return err
+		}
+
+		path, ok := os.LookupEnv(name + FileSuffix)
+		if !ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			return fmt.Errorf("both %s and %s are set", name, name+FileSuffix)
+		}
+
+		value, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name+FileSuffix, err)
+		}
+		// файлы секретов обычно заканчиваются переводом строки
+		v.Set(key, strings.TrimRight(string(value), "\r\n"))
+	}
+	return nil
+}
+
+// configKeys lists the keys of the leaf fields named by their yaml tags, like "postgres.sslMode".
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == t.PkgPath() {
+			keys = append(keys, configKeys(field.Type, key+".")...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"urlShortener/utils/e"
+)
+
+// ReloadableKeys are applied to the running instance by Reloader. Changes of other keys are
+// logged and wait for a restart. The postgres keys are also applied while postgres is not used, so a
+// migration to it can be started by a reload.
+var ReloadableKeys = []string{
+	"httpServer.timeout",
+	"adminServer.timeout",
+	"redirect.status",
+	"redirect.permanentMaxAge",
+	"health.minIDHeadroom",
+	"health.checkTimeout",
+	"health.shutdownDelay",
+	"passwords.maxAttempts",
+	"passwords.attemptWindow",
+	"log.level",
+	"log.format",
+	"migration.target",
+	"migration.readFrom",
+	"migration.backfill",
+	"migration.verify",
+	"migration.progressEvery",
+}
+
+// secretKeys are not written to the log when they change.
+var secretKeys = []string{"password", "token", "dsn", "replicas"}
+
+type Change struct {
+	Key string
+	Old any
+	New any
+}
+
+// Diff lists the keys whose values differ, in the order of Config fields.
+func Diff(old *Config, new *Config) []Change {
+	var changes []Change
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		oldValue := fieldByKey(reflect.ValueOf(old).Elem(), key).Interface()
+		newValue := fieldByKey(reflect.ValueOf(new).Elem(), key).Interface()
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, Change{Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// Reloader keeps the live config. Reload reads it again and applies the reloadable keys.
+type Reloader struct {
+	mu       sync.Mutex
+	current  atomic.Pointer[Config]
+	load     func() (*Config, error)
+	appliers []func(cfg *Config)
+	logger   *logrus.Logger
+}
+
+// NewReloader starts with cfg, load must read and validate the config the same way as on start.
+func NewReloader(cfg *Config, load func() (*Config, error), logger *logrus.Logger) *Reloader {
+	r := &Reloader{
+		load:   load,
+		logger: logger,
+	}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current returns the config with the reloaded keys applied. It must not be modified.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registers a component. apply gets every new config and must not fail: the config is already valid.
+func (r *Reloader) OnReload(apply func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.appliers = append(r.appliers, apply)
+}
+
+// Reload reads the config and applies the changed reloadable keys. An invalid config is rejected
+// as a whole, the current one stays in effect.
+func (r *Reloader) Reload() ([]Change, error) {
+	const fn = "internal.config.Reloader.Reload"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.load()
+	if err != nil {
+		r.logger.Errorf("%s: config is invalid, keeping the current one: %v", fn, err)
+		return nil, e.WrapError(fn, err)
+	}
+
+	current := r.current.Load()
+	next := *current
+	var applied []Change
+	for _, change := range Diff(current, loaded) {
+		entry := r.logger.WithFields(logrus.Fields{
+			"key": change.Key,
+			"old": logValue(change.Key, change.Old),
+			"new": logValue(change.Key, change.New),
+		})
+		if !reloadable(current, change.Key) {
+			entry.Warn("config: the change needs a restart")
+			continue
+		}
+
+		fieldByKey(reflect.ValueOf(&next).Elem(), change.Key).Set(reflect.ValueOf(change.New))
+		applied = append(applied, change)
+		entry.Info("config: changed")
+	}
+
+	if len(applied) == 0 {
+		r.logger.Info("config: nothing to reload")
+		return nil, nil
+	}
+
+	r.current.Store(&next)
+	for _, apply := range r.appliers {
+		apply(&next)
+	}
+	return applied, nil
+}
+
+func reloadable(cfg *Config, key string) bool {
+	if strings.HasPrefix(key, "postgres.") && !cfg.UsesPostgres() {
+		return true
+	}
+	for _, reloadableKey := range ReloadableKeys {
+		if key == reloadableKey {
+			return true
+		}
+	}
+	return false
+}
+
+func logValue(key string, value any) string {
+	lowerKey := strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.HasSuffix(lowerKey, secret) {
+			return "***"
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// fieldByKey finds the field of a Config value by its key, like "postgres.sslMode".
+func fieldByKey(v reflect.Value, key string) reflect.Value {
+	for _, name := range strings.Split(key, ".") {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tagName, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			if tagName == name {
+				v = v.Field(i)
+				break
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testConfig() *Config {
+	return &Config{
+		Storage:    StorageConfig{Type: postgresStorage},
+		Postgres:   PostgresConfig{Password: "old"},
+		HTTPServer: HTTPServerConfig{Address: ":3000", Timeout: 4 * time.Second},
+		Log:        LogConfig{Level: "info", Format: "json"},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old, new := testConfig(), testConfig()
+	new.HTTPServer.Timeout = 10 * time.Second
+	new.Log.Level = "debug"
+
+	assert.Equal(t, []Change{
+		{Key: "httpServer.timeout", Old: 4 * time.Second, New: 10 * time.Second},
+		{Key: "log.level", Old: "info", New: "debug"},
+	}, Diff(old, new))
+}
+
+func TestReloadAppliesReloadableKeys(t *testing.T) {
+	loaded := testConfig()
+	loaded.HTTPServer.Timeout = 10 * time.Second
+	loaded.HTTPServer.Address = ":4000"
+	loaded.Postgres.Password = "new"
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	reloader := NewReloader(testConfig(), func() (*Config, error) { return loaded, nil }, logger)
+
+	var applied *Config
+	reloader.OnReload(func(cfg *Config) { applied = cfg })
+
+	changes, err := reloader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Key: "httpServer.timeout", Old: 4 * time.Second, New: 10 * time.Second}}, changes)
+
+	assert.Same(t, reloader.Current(), applied)
+	assert.Equal(t, 10*time.Second, applied.HTTPServer.Timeout)
+	// адрес и пароль применяются только после перезапуска
+	assert.Equal(t, ":3000", applied.HTTPServer.Address)
+	assert.Equal(t, "old", applied.Postgres.Password)
+}
+
+func TestReloadStartsMigrationToPostgres(t *testing.T) {
+	current := testConfig()
+	current.Storage.Type = inMemoryStorage
+	loaded := testConfig()
+	loaded.Storage.Type = inMemoryStorage
+	loaded.Postgres.Password = "new"
+	loaded.Migration.Target = postgresStorage
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	reloader := NewReloader(current, func() (*Config, error) { return loaded, nil }, logger)
+
+	_, err := reloader.Reload()
+	assert.NoError(t, err)
+	// postgres еще не использовался, поэтому его настройки применяются вместе с миграцией
+	assert.Equal(t, "new", reloader.Current().Postgres.Password)
+	assert.Equal(t, postgresStorage, reloader.Current().Migration.Target)
+
+	next := testConfig()
+	next.Storage.Type = inMemoryStorage
+	next.Postgres.Password = "newer"
+	next.Migration.Target = postgresStorage
+	loaded = next
+	_, err = reloader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, "new", reloader.Current().Postgres.Password)
+}
+
+func TestReloadKeepsConfigOnError(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	current := testConfig()
+	reloader := NewReloader(current, func() (*Config, error) { return nil, errors.New("field Level must be one of") }, logger)
+
+	called := false
+	reloader.OnReload(func(cfg *Config) { called = true })
+
+	_, err := reloader.Reload()
+	assert.Error(t, err)
+	assert.False(t, called)
+	assert.Same(t, current, reloader.Current())
+}
+
+func TestLogValueHidesSecrets(t *testing.T) {
+	assert.Equal(t, "***", logValue("postgres.password", "123"))
+	assert.Equal(t, "***", logValue("adminGRPC.token", "123"))
+	assert.Equal(t, "info", logValue("log.level", "info"))
+}
@@ -0,0 +1,153 @@
+package domainError
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Code - стабильный машиночитаемый идентификатор ошибки, одинаковый для HTTP и gRPC.
+type Code string
+
+const (
+	CodeInvalidArgument  Code = "INVALID_ARGUMENT"
+	CodeURLNotFound      Code = "URL_NOT_FOUND"
+	CodeURLConflict      Code = "URL_CONFLICT"
+	CodeLinkExists       Code = "LINK_EXISTS"
+	CodeURLDisabled      Code = "URL_DISABLED"
+	CodePasswordRequired Code = "PASSWORD_REQUIRED"
+	CodeTooManyAttempts  Code = "TOO_MANY_ATTEMPTS"
+	CodeClicksExhausted  Code = "CLICKS_EXHAUSTED"
+	CodeLinkNotActive    Code = "LINK_NOT_ACTIVE"
+	CodeLinkExpired      Code = "LINK_EXPIRED"
+	CodeIDSpaceExhausted Code = "ID_SPACE_EXHAUSTED"
+	CodeUnavailable      Code = "UNAVAILABLE"
+	CodeNotSupported     Code = "NOT_SUPPORTED"
+	CodeInternal         Code = "INTERNAL"
+)
+
+// Error is returned by the service layer. Message and Details are safe to show to clients,
+// Err keeps the internal cause for logs and errors.Is.
+type Error struct {
+	Code      Code
+	Message   string
+	Details   map[string]string
+	Retryable bool
+	// RetryAt is when the same request is expected to succeed, zero if unknown.
+	RetryAt time.Time
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Fields returns field names from Details in a stable order.
+func (e *Error) Fields() []string {
+	fields := make([]string, 0, len(e.Details))
+	for field := range e.Details {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func InvalidArgument(field, description string) *Error {
+	return &Error{
+		Code:    CodeInvalidArgument,
+		Message: "request is invalid",
+		Details: map[string]string{field: description},
+	}
+}
+
+func URLNotFound(err error) *Error {
+	return &Error{Code: CodeURLNotFound, Message: "URL not found", Err: err}
+}
+
+// URLConflict - один и тот же URL сохранили конкурентно, повторный запрос вернет уже сохраненную ссылку.
+func URLConflict(err error) *Error {
+	return &Error{Code: CodeURLConflict, Message: "URL was saved concurrently, retry the request", Retryable: true, Err: err}
+}
+
+// OptionsConflict - URL уже сокращен с другими настройками, повтор запроса не поможет.
+func OptionsConflict(err error) *Error {
+	return &Error{Code: CodeURLConflict, Message: "URL is already shortened with other options", Err: err}
+}
+
+// LinkExists - код или URL уже заняты другой ссылкой, а перезаписывать ее нельзя.
+func LinkExists(err error) *Error {
+	return &Error{Code: CodeLinkExists, Message: "link already exists", Err: err}
+}
+
+func URLDisabled(err error) *Error {
+	return &Error{Code: CodeURLDisabled, Message: "URL is disabled", Err: err}
+}
+
+// PasswordRequired - ссылка защищена паролем, а пароль не передан.
+func PasswordRequired(err error) *Error {
+	return &Error{Code: CodePasswordRequired, Message: "link is protected with a password", Err: err}
+}
+
+func WrongPassword(err error) *Error {
+	return &Error{Code: CodePasswordRequired, Message: "wrong password", Err: err}
+}
+
+// TooManyAttempts - клиент слишком часто ошибался в пароле, попытки временно заблокированы.
+func TooManyAttempts(err error) *Error {
+	return &Error{Code: CodeTooManyAttempts, Message: "too many wrong passwords, try again later", Err: err}
+}
+
+// ClicksExhausted - ссылка с ограничением переходов использована максимальное число раз.
+func ClicksExhausted(err error) *Error {
+	return &Error{Code: CodeClicksExhausted, Message: "link has no clicks left", Err: err}
+}
+
+// LinkNotActive - ссылка заработает только в notBefore.
+func LinkNotActive(notBefore time.Time, err error) *Error {
+	return &Error{Code: CodeLinkNotActive, Message: "link is not available yet", RetryAt: notBefore, Err: err}
+}
+
+// LinkExpired - окно работы ссылки закончилось.
+func LinkExpired(err error) *Error {
+	return &Error{Code: CodeLinkExpired, Message: "link has expired", Err: err}
+}
+
+func IDSpaceExhausted(err error) *Error {
+	return &Error{Code: CodeIDSpaceExhausted, Message: "no more short URLs can be generated", Err: err}
+}
+
+func Unavailable(err error) *Error {
+	return &Error{Code: CodeUnavailable, Message: "service is temporarily unavailable", Retryable: true, Err: err}
+}
+
+// NotSupported - операция не поддерживается выбранным хранилищем.
+func NotSupported(err error) *Error {
+	return &Error{Code: CodeNotSupported, Message: "operation is not supported by the storage", Err: err}
+}
+
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal error", Err: err}
+}
+
+// From returns the domain error from err's chain, anything else is treated as internal.
+func From(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+	return Internal(err)
+}
+
+// ServerFault tells whether the request failed because of the server rather than the request itself,
+// handlers log such errors as errors and the rest at info level.
+func ServerFault(err error) bool {
+	code := From(err).Code
+	return code == CodeInternal || code == CodeUnavailable
+}
@@ -0,0 +1,38 @@
+package domainError
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"urlShortener/utils/e"
+)
+
+func TestFromDomainError(t *testing.T) {
+	cause := errors.New("not found")
+	err := e.WrapError("fn", URLNotFound(cause))
+
+	domainErr := From(err)
+	assert.Equal(t, CodeURLNotFound, domainErr.Code)
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestFromUnknownError(t *testing.T) {
+	cause := errors.New("unknown")
+
+	domainErr := From(cause)
+	assert.Equal(t, CodeInternal, domainErr.Code)
+	assert.False(t, domainErr.Retryable)
+	assert.True(t, errors.Is(domainErr, cause))
+}
+
+func TestFieldsSorted(t *testing.T) {
+	err := &Error{Code: CodeInvalidArgument, Details: map[string]string{"b": "", "a": "", "c": ""}}
+	assert.Equal(t, []string{"a", "b", "c"}, err.Fields())
+}
+
+func TestServerFault(t *testing.T) {
+	assert.True(t, ServerFault(errors.New("connection reset")))
+	assert.True(t, ServerFault(e.WrapError("fn", Unavailable(nil))))
+	assert.False(t, ServerFault(InvalidArgument("url", "must be an absolute URL")))
+	assert.False(t, ServerFault(URLConflict(nil)))
+}
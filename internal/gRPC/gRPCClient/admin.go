@@ -0,0 +1,42 @@
+package gRPCClient
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"urlShortener/internal/gRPC/gRPCHandlers/interceptors"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/utils/e"
+)
+
+// tokenCredentials attaches the admin token to every call.
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{interceptors.AuthorizationKey: interceptors.BearerPrefix + string(t)}, nil
+}
+
+// RequireTransportSecurity is false: the admin listener is expected on localhost or on a unix socket.
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// DialAdmin connects to the Admin service on a tcp address or a unix socket path.
+func DialAdmin(network string, addr string, token string) (proto.AdminClient, *grpc.ClientConn, error) {
+	const fn = "grpc.gRPCClient.DialAdmin"
+
+	target := addr
+	if network == "unix" {
+		target = "unix://" + addr
+	}
+
+	conn, err := grpc.Dial(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(tokenCredentials(token)),
+	)
+	if err != nil {
+		return nil, nil, e.WrapError(fn, err)
+	}
+
+	return proto.NewAdminClient(conn), conn, nil
+}
@@ -0,0 +1,87 @@
+package gRPCClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"urlShortener/internal/gRPC/gRPCUtils"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/transfer"
+	"urlShortener/utils/e"
+)
+
+// ExportLinks receives the export stream of the Admin service and writes it to w.
+func ExportLinks(ctx context.Context, client proto.AdminClient, w transfer.Writer) error {
+	const fn = "grpc.gRPCClient.ExportLinks"
+
+	stream, err := client.ExportLinks(ctx, &proto.ExportLinksRequest{})
+	if err != nil {
+		return e.WrapError(fn, err)
+	}
+
+	for {
+		link, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return e.WrapError(fn, err)
+		}
+		if err = w.Write(gRPCUtils.FromProtoRecord(link)); err != nil {
+			return e.WrapError(fn, err)
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+// ImportLinks streams records from r to the Admin service. Records are read before sending,
+// so a malformed file fails on the client with the record number.
+func ImportLinks(ctx context.Context, client proto.AdminClient, r transfer.Reader, opts transfer.Options) (transfer.Result, error) {
+	const fn = "grpc.gRPCClient.ImportLinks"
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.ImportLinks(ctx)
+	if err != nil {
+		return transfer.Result{}, e.WrapError(fn, err)
+	}
+
+	mode := gRPCUtils.ToProtoMode(opts.Mode)
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			// cancel обрывает поток, сервер не применит неполный файл как законченный
+			return transfer.Result{}, e.WrapError(fn, fmt.Errorf("record %d: %w", n, err))
+		}
+
+		err = stream.Send(&proto.ImportLinkRequest{
+			Link:   gRPCUtils.ToProtoRecord(record),
+			Mode:   mode,
+			DryRun: opts.DryRun,
+		})
+		if errors.Is(err, io.EOF) {
+			// сервер закрыл поток, настоящая ошибка придет из CloseAndRecv
+			break
+		} else if err != nil {
+			return transfer.Result{}, e.WrapError(fn, err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return transfer.Result{}, e.WrapError(fn, err)
+	}
+	return transfer.Result{
+		Imported:  resp.Imported,
+		Updated:   resp.Updated,
+		Unchanged: resp.Unchanged,
+		Skipped:   resp.Skipped,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"urlShortener/internal/gRPC/gRPCUtils"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/transfer"
+)
+
+type Service interface {
+	GetLink(ctx context.Context, code string) (storage.Link, error)
+	FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error)
+	DeleteLink(ctx context.Context, code string) error
+	SetDisabled(ctx context.Context, code string, disabled bool) (storage.Link, error)
+	ReassignDomain(ctx context.Context, from string, to string) (uint64, error)
+	CounterStatus() service.CounterStatus
+	Stats(ctx context.Context) (service.Stats, error)
+	VariantStats(ctx context.Context, code string) ([]service.VariantStats, error)
+	TargetStats(ctx context.Context, code string) ([]service.TargetStats, error)
+	ExportLinks(ctx context.Context, w transfer.Writer) error
+	ImportLinks(ctx context.Context, r transfer.Reader, opts transfer.Options) (transfer.Result, error)
+}
+
+type HandleAdmin struct {
+	service Service
+
+	proto.UnimplementedAdminServer
+}
+
+func New(service Service) *HandleAdmin {
+	return &HandleAdmin{service: service}
+}
+
+func (h *HandleAdmin) GetLink(ctx context.Context, req *proto.LinkCode) (*proto.Link, error) {
+	link, err := h.service.GetLink(ctx, req.Code)
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	return toProtoLink(link), nil
+}
+
+func (h *HandleAdmin) FindLink(ctx context.Context, req *proto.FindLinkRequest) (*proto.Link, error) {
+	link, err := h.service.FindByFullURL(ctx, req.FullUrl)
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	return toProtoLink(link), nil
+}
+
+func (h *HandleAdmin) DeleteLink(ctx context.Context, req *proto.LinkCode) (*proto.DeleteLinkResponse, error) {
+	if err := h.service.DeleteLink(ctx, req.Code); err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	return &proto.DeleteLinkResponse{}, nil
+}
+
+func (h *HandleAdmin) SetDisabled(ctx context.Context, req *proto.SetDisabledRequest) (*proto.Link, error) {
+	link, err := h.service.SetDisabled(ctx, req.Code, req.Disabled)
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	return toProtoLink(link), nil
+}
+
+func (h *HandleAdmin) ReassignDomain(ctx context.Context, req *proto.ReassignDomainRequest) (*proto.ReassignDomainResponse, error) {
+	changed, err := h.service.ReassignDomain(ctx, req.From, req.To)
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	return &proto.ReassignDomainResponse{Changed: changed}, nil
+}
+
+func (h *HandleAdmin) GetCounterStatus(ctx context.Context, req *proto.CounterStatusRequest) (*proto.CounterStatus, error) {
+	return toProtoCounter(h.service.CounterStatus()), nil
+}
+
+func (h *HandleAdmin) GetStats(ctx context.Context, req *proto.StatsRequest) (*proto.Stats, error) {
+	stats, err := h.service.Stats(ctx)
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	result := &proto.Stats{
+		Links:         stats.Links,
+		DisabledLinks: stats.DisabledLinks,
+		Counter:       toProtoCounter(stats.Counter),
+	}
+	if req.GetCode() == "" {
+		return result, nil
+	}
+
+	variants, err := h.service.VariantStats(ctx, req.GetCode())
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	for _, variant := range variants {
+		result.Variants = append(result.Variants, &proto.VariantStats{
+			Variant: &proto.Variant{Name: variant.Name, URL: variant.URL, Weight: variant.Weight},
+			Clicks:  variant.Clicks,
+		})
+	}
+
+	targets, err := h.service.TargetStats(ctx, req.GetCode())
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+	for _, target := range targets {
+		result.Targets = append(result.Targets, &proto.TargetStats{Target: target.Target, Clicks: target.Clicks})
+	}
+	return result, nil
+}
+
+func toProtoLink(link storage.Link) *proto.Link {
+	return &proto.Link{
+		Id:              link.ID,
+		Code:            link.Code,
+		FullUrl:         link.FullURL,
+		CreatedAt:       timestamppb.New(link.CreatedAt),
+		Disabled:        link.Disabled,
+		Redirect:        gRPCUtils.ToProtoRedirect(link.RedirectStatus),
+		Query:           gRPCUtils.ToProtoQuery(link.Query),
+		PathPassthrough: link.PathPassthrough,
+		PasswordHash:    link.PasswordHash,
+		MaxClicks:       link.MaxClicks,
+		ClicksLeft:      link.ClicksLeft,
+		NotBefore:       gRPCUtils.ToProtoTime(link.NotBefore),
+		NotAfter:        gRPCUtils.ToProtoTime(link.NotAfter),
+		FallbackUrl:     link.FallbackURL,
+		Targets:         gRPCUtils.ToProtoTargets(link.Targets),
+		Countries:       link.Countries,
+		Variants:        gRPCUtils.ToProtoVariants(link.Variants),
+		Sticky:          gRPCUtils.ToProtoSticky(link.Sticky),
+	}
+}
+
+func toProtoCounter(status service.CounterStatus) *proto.CounterStatus {
+	return &proto.CounterStatus{
+		CurrentId: status.CurrentID,
+		MaxId:     status.MaxID,
+		Headroom:  status.Headroom,
+	}
+}
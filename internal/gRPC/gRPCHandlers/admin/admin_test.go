@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+func newTestHandler(t *testing.T) *HandleAdmin {
+	st := inMemmory.New()
+	assert.NoError(t, st.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	return New(service.NewAdmin(st, hashByID.New(1)))
+}
+
+func TestFindAndDisable(t *testing.T) {
+	handler := newTestHandler(t)
+	ctx := context.Background()
+
+	link, err := handler.FindLink(ctx, &proto.FindLinkRequest{FullUrl: "https://ozon.ru"})
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", link.Code)
+	assert.False(t, link.Disabled)
+	assert.NotZero(t, link.CreatedAt.AsTime())
+
+	link, err = handler.SetDisabled(ctx, &proto.SetDisabledRequest{Code: "aaaaaaaaaa", Disabled: true})
+	assert.NoError(t, err)
+	assert.True(t, link.Disabled)
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	handler := newTestHandler(t)
+
+	_, err := handler.DeleteLink(context.Background(), &proto.LinkCode{Code: "bbbbbbbbbb"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetStats(t *testing.T) {
+	handler := newTestHandler(t)
+
+	stats, err := handler.GetStats(context.Background(), &proto.StatsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.Links)
+	assert.Equal(t, uint64(1), stats.Counter.CurrentId)
+	assert.Equal(t, stats.Counter.MaxId-1, stats.Counter.Headroom)
+}
+
+func TestGetStatsVariants(t *testing.T) {
+	st := inMemmory.New()
+	ctx := context.Background()
+	variants := storage.NewVariants([]storage.Variant{{URL: "https://ozon.ru/a", Weight: 3}, {URL: "https://ozon.ru/b", Weight: 1}})
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{Variants: variants, Sticky: storage.StickyCookie}))
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaaa", "B"))
+	handler := New(service.NewAdmin(st, hashByID.New(1)))
+
+	stats, err := handler.GetStats(ctx, &proto.StatsRequest{Code: "aaaaaaaaaa"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.Links)
+	assert.Len(t, stats.Variants, 2)
+	assert.Equal(t, "A", stats.Variants[0].Variant.Name)
+	assert.Equal(t, uint32(3), stats.Variants[0].Variant.Weight)
+	assert.Equal(t, uint64(0), stats.Variants[0].Clicks)
+	assert.Equal(t, "https://ozon.ru/b", stats.Variants[1].Variant.URL)
+	assert.Equal(t, uint64(1), stats.Variants[1].Clicks)
+
+	link, err := handler.GetLink(ctx, &proto.LinkCode{Code: "aaaaaaaaaa"})
+	assert.NoError(t, err)
+	assert.Len(t, link.Variants, 2)
+	assert.Equal(t, proto.Sticky_STICKY_COOKIE, link.Sticky)
+
+	_, err = handler.GetStats(ctx, &proto.StatsRequest{Code: "bbbbbbbbbb"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetStatsTargets(t *testing.T) {
+	st := inMemmory.New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{Targets: storage.Targets{IOS: "https://apps.apple.com/app"}}))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaaa", "ios"))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaaa", "default"))
+	handler := New(service.NewAdmin(st, hashByID.New(1)))
+
+	stats, err := handler.GetStats(ctx, &proto.StatsRequest{Code: "aaaaaaaaaa"})
+	assert.NoError(t, err)
+	assert.Len(t, stats.Targets, 2)
+	assert.Equal(t, "default", stats.Targets[0].Target)
+	assert.Equal(t, "ios", stats.Targets[1].Target)
+	assert.Equal(t, uint64(1), stats.Targets[1].Clicks)
+}
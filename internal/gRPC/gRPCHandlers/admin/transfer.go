@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"urlShortener/internal/gRPC/gRPCUtils"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/transfer"
+)
+
+func (h *HandleAdmin) ExportLinks(req *proto.ExportLinksRequest, stream proto.Admin_ExportLinksServer) error {
+	if err := h.service.ExportLinks(stream.Context(), streamWriter{stream}); err != nil {
+		return gRPCUtils.FromError(err)
+	}
+	return nil
+}
+
+func (h *HandleAdmin) ImportLinks(stream proto.Admin_ImportLinksServer) error {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return stream.SendAndClose(&proto.ImportLinksResponse{})
+	} else if err != nil {
+		return err
+	}
+
+	opts := transfer.Options{
+		Mode:   gRPCUtils.FromProtoMode(first.Mode),
+		DryRun: first.DryRun,
+	}
+	result, err := h.service.ImportLinks(stream.Context(), &streamReader{stream: stream, first: first}, opts)
+	if err != nil {
+		return gRPCUtils.FromError(err)
+	}
+
+	return stream.SendAndClose(&proto.ImportLinksResponse{
+		Imported:  result.Imported,
+		Updated:   result.Updated,
+		Unchanged: result.Unchanged,
+		Skipped:   result.Skipped,
+	})
+}
+
+// streamWriter sends every record as soon as it's written, gRPC does the buffering.
+type streamWriter struct {
+	stream proto.Admin_ExportLinksServer
+}
+
+func (w streamWriter) Write(record transfer.Record) error {
+	return w.stream.Send(gRPCUtils.ToProtoRecord(record))
+}
+
+func (w streamWriter) Flush() error {
+	return nil
+}
+
+type streamReader struct {
+	stream proto.Admin_ImportLinksServer
+	first  *proto.ImportLinkRequest
+}
+
+func (r *streamReader) Read() (transfer.Record, error) {
+	req := r.first
+	if req != nil {
+		r.first = nil
+	} else {
+		var err error
+		if req, err = r.stream.Recv(); err != nil {
+			return transfer.Record{}, err
+		}
+	}
+	return gRPCUtils.FromProtoRecord(req.Link), nil
+}
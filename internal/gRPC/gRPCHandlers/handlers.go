@@ -2,27 +2,39 @@ package gRPCHandlers
 
 import (
 	"context"
+	"urlShortener/internal/gRPC/gRPCHandlers/inspect"
+	"urlShortener/internal/gRPC/gRPCHandlers/qr"
 	"urlShortener/internal/gRPC/gRPCHandlers/redirect"
 	"urlShortener/internal/gRPC/gRPCHandlers/save"
 	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
 )
 
 type Handlers struct {
 	*redirect.HandleRedirect
 	*save.HandleSave
+	*inspect.HandleInspect
+	*qr.HandleQRCode
 
 	proto.UnimplementedURLShortenerServer
 }
 
 type Service interface {
-	GetShortenURL(fullURL string) (string, error)
-	GetFullURL(shortenURL string) (string, error)
+	GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error)
+	Unlock(ctx context.Context, shortenURL, password, client string) (storage.Link, error)
+	Inspect(ctx context.Context, shortenURL string) (service.Preview, error)
+	QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error)
 }
 
-func New(service Service) *Handlers {
+// New builds the handlers, publicURL is the base of short URLs in QR codes.
+func New(service Service, publicURL string) *Handlers {
 	return &Handlers{
 		HandleRedirect: redirect.New(service),
 		HandleSave:     save.New(service),
+		HandleInspect:  inspect.New(service),
+		HandleQRCode:   qr.New(service, publicURL),
 	}
 }
 
@@ -33,3 +45,11 @@ func (h Handlers) Save(ctx context.Context, req *proto.FullURL) (*proto.ShortURL
 func (h Handlers) Redirect(ctx context.Context, req *proto.ShortURL) (*proto.FullURL, error) {
 	return h.HandleRedirect.Redirect(ctx, req)
 }
+
+func (h Handlers) Inspect(ctx context.Context, req *proto.ShortURL) (*proto.LinkPreview, error) {
+	return h.HandleInspect.Inspect(ctx, req)
+}
+
+func (h Handlers) QRCode(ctx context.Context, req *proto.QRCodeRequest) (*proto.QRCodeImage, error) {
+	return h.HandleQRCode.QRCode(ctx, req)
+}
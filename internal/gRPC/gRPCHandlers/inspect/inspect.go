@@ -0,0 +1,47 @@
+package inspect
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"urlShortener/internal/gRPC/gRPCUtils"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/service"
+)
+
+type HandleInspect struct {
+	inspector
+}
+
+type inspector interface {
+	Inspect(ctx context.Context, shortURL string) (service.Preview, error)
+}
+
+func New(inspector inspector) *HandleInspect {
+	return &HandleInspect{inspector}
+}
+
+var safeties = map[service.Safety]proto.Safety{
+	service.SafetyOK:       proto.Safety_SAFETY_OK,
+	service.SafetyInsecure: proto.Safety_SAFETY_INSECURE,
+	service.SafetyDisabled: proto.Safety_SAFETY_DISABLED,
+}
+
+func (g *HandleInspect) Inspect(ctx context.Context, reqShortenURL *proto.ShortURL) (*proto.LinkPreview, error) {
+	preview, err := g.inspector.Inspect(ctx, reqShortenURL.GetURL())
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+
+	resp := &proto.LinkPreview{
+		Code:      preview.Code,
+		URL:       preview.FullURL,
+		Safety:    safeties[preview.Safety],
+		Protected: preview.Protected,
+		NotBefore: gRPCUtils.ToProtoTime(preview.NotBefore),
+		NotAfter:  gRPCUtils.ToProtoTime(preview.NotAfter),
+	}
+	if !preview.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(preview.CreatedAt)
+	}
+	return resp, nil
+}
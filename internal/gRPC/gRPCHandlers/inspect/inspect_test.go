@@ -0,0 +1,68 @@
+package inspect
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+)
+
+type mockInspector struct {
+	mock.Mock
+}
+
+func (m *mockInspector) Inspect(ctx context.Context, shortURL string) (service.Preview, error) {
+	args := m.Called(shortURL)
+	return args.Get(0).(service.Preview), args.Error(1)
+}
+
+func TestInspectSuccess(t *testing.T) {
+	inspector := &mockInspector{}
+	handler := New(inspector)
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	preview := service.Preview{Code: "aaaaaaaaaa", FullURL: "https://ozon.ru", CreatedAt: createdAt, Safety: service.SafetyOK}
+	inspector.On("Inspect", "aaaaaaaaaa").Return(preview, nil)
+
+	result, err := handler.Inspect(context.Background(), &proto.ShortURL{URL: "aaaaaaaaaa"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", result.GetURL())
+	assert.Equal(t, proto.Safety_SAFETY_OK, result.GetSafety())
+	assert.True(t, createdAt.Equal(result.GetCreatedAt().AsTime()))
+
+	assert.True(t, inspector.AssertExpectations(t))
+}
+
+func TestInspectDisabled(t *testing.T) {
+	inspector := &mockInspector{}
+	handler := New(inspector)
+
+	inspector.On("Inspect", "aaaaaaaaaa").Return(service.Preview{Code: "aaaaaaaaaa", Safety: service.SafetyDisabled}, nil)
+
+	result, err := handler.Inspect(context.Background(), &proto.ShortURL{URL: "aaaaaaaaaa"})
+	assert.NoError(t, err)
+	assert.Empty(t, result.GetURL())
+	assert.Nil(t, result.GetCreatedAt())
+	assert.Equal(t, proto.Safety_SAFETY_DISABLED, result.GetSafety())
+
+	assert.True(t, inspector.AssertExpectations(t))
+}
+
+func TestInspectNotFound(t *testing.T) {
+	inspector := &mockInspector{}
+	handler := New(inspector)
+
+	inspector.On("Inspect", "aaaaaaaaaa").Return(service.Preview{}, domainError.URLNotFound(storage.ErrURLNotFound))
+
+	_, err := handler.Inspect(context.Background(), &proto.ShortURL{URL: "aaaaaaaaaa"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.True(t, inspector.AssertExpectations(t))
+}
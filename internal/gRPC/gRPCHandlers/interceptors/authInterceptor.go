@@ -0,0 +1,54 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/subtle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	AuthorizationKey = "authorization"
+	BearerPrefix     = "Bearer "
+)
+
+// AuthInterceptor rejects calls without "authorization: Bearer <token>" metadata.
+func AuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	expected := []byte(BearerPrefix + token)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, token, expected); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor checks the same token before the stream handler starts.
+func AuthStreamInterceptor(token string) grpc.StreamServerInterceptor {
+	expected := []byte(BearerPrefix + token)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), token, expected); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, token string, expected []byte) error {
+	var got string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(AuthorizationKey); len(values) > 0 {
+			got = values[0]
+		}
+	}
+
+	// сравнение за постоянное время, чтобы токен нельзя было подобрать по времени ответа
+	if token == "" || subtle.ConstantTimeCompare([]byte(got), expected) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.Admin/GetStats"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		md    metadata.MD
+		code  codes.Code
+	}{
+		{"valid", "secret", metadata.Pairs(AuthorizationKey, "Bearer secret"), codes.OK},
+		{"wrong token", "secret", metadata.Pairs(AuthorizationKey, "Bearer other"), codes.Unauthenticated},
+		{"no metadata", "secret", nil, codes.Unauthenticated},
+		{"empty server token", "", metadata.Pairs(AuthorizationKey, "Bearer "), codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			_, err := AuthInterceptor(tt.token)(ctx, nil, info, handler)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -4,22 +4,31 @@ import (
 	"context"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
 )
 
+// LoggerInterceptor writes one log line per call.
 func LoggerInterceptor(logger *logrus.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		logger := logger.WithFields(logrus.Fields{
-			"method": info.FullMethod,
-		})
-
-		logger.Info("gRPC method called")
+		start := time.Now()
 
 		resp, err := handler(ctx, req)
 
-		if err != nil {
-			logger.Errorf("gRPC method failed: %v", err)
-		} else {
-			logger.Info("gRPC method completed successfully")
+		code := status.Code(err)
+		entry := logger.WithContext(ctx).WithFields(logrus.Fields{
+			"method":      info.FullMethod,
+			"code":        code.String(),
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+		switch code {
+		case codes.OK:
+			entry.Info("gRPC call completed")
+		case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+			entry.WithError(err).Error("gRPC call failed")
+		default:
+			entry.WithError(err).Warn("gRPC call failed")
 		}
 
 		return resp, err
@@ -0,0 +1,24 @@
+package interceptors
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+type grpcObserver interface {
+	ObserveGRPC(method, code string, duration time.Duration)
+}
+
+func MetricsInterceptor(observer grpcObserver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		observer.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+
+		return resp, err
+	}
+}
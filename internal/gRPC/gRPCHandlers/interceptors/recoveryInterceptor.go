@@ -0,0 +1,56 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime/debug"
+)
+
+const grpcTransport = "grpc"
+
+type PanicObserver interface {
+	ObservePanic(transport string)
+}
+
+// RecoveryInterceptor turns a panic in the handler into codes.Internal.
+func RecoveryInterceptor(logger *logrus.Logger, observer PanicObserver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = handlePanic(ctx, logger, observer, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor turns a panic in the stream handler into codes.Internal.
+func RecoveryStreamInterceptor(logger *logrus.Logger, observer PanicObserver) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = handlePanic(ss.Context(), logger, observer, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func handlePanic(ctx context.Context, logger *logrus.Logger, observer PanicObserver, method string, recovered interface{}) error {
+	logger.WithContext(ctx).WithFields(logrus.Fields{
+		"method": method,
+		"panic":  fmt.Sprint(recovered),
+		"stack":  string(debug.Stack()),
+	}).Error("panic recovered in gRPC handler")
+	if observer != nil {
+		observer.ObservePanic(grpcTransport)
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
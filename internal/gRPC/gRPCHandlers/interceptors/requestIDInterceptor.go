@@ -0,0 +1,26 @@
+package interceptors
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"urlShortener/pkg/requestID"
+)
+
+// RequestIDInterceptor propagates the incoming x-request-id metadata or generates a new one,
+// puts it into the context and returns it in the response header.
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var incoming string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestID.MetadataKey); len(values) > 0 {
+				incoming = values[0]
+			}
+		}
+		id := requestID.FromIncoming(incoming)
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestID.MetadataKey, id))
+
+		return handler(requestID.NewContext(ctx, id), req)
+	}
+}
@@ -0,0 +1,36 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"testing"
+	"urlShortener/pkg/requestID"
+)
+
+func TestRequestIDInterceptorPropagates(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestID.MetadataKey, "req-42"))
+
+	var handlerID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerID = requestID.FromContext(ctx)
+		return nil, nil
+	}
+
+	_, err := RequestIDInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/service.URLShortener/Save"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "req-42", handlerID)
+}
+
+func TestRequestIDInterceptorGenerates(t *testing.T) {
+	var handlerID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerID = requestID.FromContext(ctx)
+		return nil, nil
+	}
+
+	_, err := RequestIDInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/service.URLShortener/Save"}, handler)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, handlerID)
+}
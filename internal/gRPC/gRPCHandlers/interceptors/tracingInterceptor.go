@@ -0,0 +1,72 @@
+package interceptors
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"urlShortener/internal/tracing"
+)
+
+// metadataCarrier adapts incoming gRPC metadata to the OpenTelemetry propagator.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// TracingInterceptor continues the trace from the W3C traceparent metadata or starts a new one.
+func TracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		service, method := splitFullMethod(info.FullMethod)
+		ctx, span := tracing.Tracer().Start(ctx, info.FullMethod,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(method)),
+		)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		if code == codes.Internal || code == codes.Unavailable || code == codes.Unknown {
+			span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+		}
+
+		return resp, err
+	}
+}
+
+// splitFullMethod splits "/package.Service/Method".
+func splitFullMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "", fullMethod
+	}
+	return service, method
+}
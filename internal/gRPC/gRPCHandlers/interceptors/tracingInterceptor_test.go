@@ -0,0 +1,40 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func TestTracingInterceptorContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01"))
+
+	var handlerTraceID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerTraceID = trace.SpanContextFromContext(ctx).TraceID().String()
+		return nil, nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.URLShortener/Save"}
+	_, err := TracingInterceptor()(ctx, nil, info, handler)
+	assert.NoError(t, err)
+
+	assert.Equal(t, traceID, handlerTraceID)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "/service.URLShortener/Save", spans[0].Name())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
@@ -0,0 +1,73 @@
+package qr
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"urlShortener/internal/gRPC/gRPCUtils"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/qrCode"
+)
+
+type HandleQRCode struct {
+	renderer
+	publicURL string
+}
+
+type renderer interface {
+	QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error)
+}
+
+func New(renderer renderer, publicURL string) *HandleQRCode {
+	return &HandleQRCode{renderer: renderer, publicURL: publicURL}
+}
+
+var formats = map[proto.QRFormat]qrCode.Format{
+	proto.QRFormat_QR_FORMAT_PNG: qrCode.PNG,
+	proto.QRFormat_QR_FORMAT_SVG: qrCode.SVG,
+}
+
+var levels = map[proto.QRLevel]qrCode.Level{
+	proto.QRLevel_QR_LEVEL_L: "L",
+	proto.QRLevel_QR_LEVEL_M: "M",
+	proto.QRLevel_QR_LEVEL_Q: "Q",
+	proto.QRLevel_QR_LEVEL_H: "H",
+}
+
+func (g *HandleQRCode) QRCode(ctx context.Context, req *proto.QRCodeRequest) (*proto.QRCodeImage, error) {
+	// без publicURL неизвестно, на какой адрес вести: хост gRPC не подходит
+	if g.publicURL == "" {
+		return nil, status.Error(codes.FailedPrecondition, "public URL is not configured")
+	}
+
+	image, err := g.renderer.QRCode(ctx, g.publicURL, req.GetCode(), fromProtoOptions(req))
+	if err != nil {
+		return nil, gRPCUtils.FromError(err)
+	}
+
+	return &proto.QRCodeImage{Image: image, ContentType: formats[req.GetFormat()].ContentType()}, nil
+}
+
+// fromProtoOptions keeps unknown enum values as their names, so the service rejects them.
+func fromProtoOptions(req *proto.QRCodeRequest) qrCode.Options {
+	opts := qrCode.DefaultOptions()
+	if format, ok := formats[req.GetFormat()]; ok {
+		opts.Format = format
+	} else {
+		opts.Format = qrCode.Format(req.GetFormat().String())
+	}
+	if req.GetLevel() != proto.QRLevel_QR_LEVEL_DEFAULT {
+		if level, ok := levels[req.GetLevel()]; ok {
+			opts.Level = level
+		} else {
+			opts.Level = qrCode.Level(req.GetLevel().String())
+		}
+	}
+	if req.GetSize() != 0 {
+		opts.Size = int(req.GetSize())
+	}
+	if req.Margin != nil {
+		opts.Margin = int(req.GetMargin())
+	}
+	return opts
+}
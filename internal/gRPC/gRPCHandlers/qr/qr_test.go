@@ -0,0 +1,81 @@
+package qr
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/storage"
+)
+
+const qrCodeMethod = "QRCode"
+
+type mockRenderer struct {
+	mock.Mock
+}
+
+func (m *mockRenderer) QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error) {
+	args := m.Called(base, shortenURL, opts)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestQRCodeDefaults(t *testing.T) {
+	renderer := &mockRenderer{}
+	handler := New(renderer, "https://sho.rt")
+
+	renderer.On(qrCodeMethod, "https://sho.rt", "aaaaaaaaaa", qrCode.DefaultOptions()).Return([]byte("png"), nil)
+
+	result, err := handler.QRCode(context.Background(), &proto.QRCodeRequest{Code: "aaaaaaaaaa"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("png"), result.GetImage())
+	assert.Equal(t, "image/png", result.GetContentType())
+
+	assert.True(t, renderer.AssertExpectations(t))
+}
+
+func TestQRCodeOptions(t *testing.T) {
+	renderer := &mockRenderer{}
+	handler := New(renderer, "https://sho.rt")
+
+	opts := qrCode.Options{Format: qrCode.SVG, Size: 512, Level: "H", Margin: 0}
+	renderer.On(qrCodeMethod, "https://sho.rt", "aaaaaaaaaa", opts).Return([]byte("<svg/>"), nil)
+
+	margin := uint32(0)
+	req := &proto.QRCodeRequest{
+		Code:   "aaaaaaaaaa",
+		Format: proto.QRFormat_QR_FORMAT_SVG,
+		Size:   512,
+		Level:  proto.QRLevel_QR_LEVEL_H,
+		Margin: &margin,
+	}
+	result, err := handler.QRCode(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", result.GetContentType())
+
+	assert.True(t, renderer.AssertExpectations(t))
+}
+
+func TestQRCodeNotFound(t *testing.T) {
+	renderer := &mockRenderer{}
+	handler := New(renderer, "https://sho.rt")
+
+	renderer.On(qrCodeMethod, "https://sho.rt", "aaaaaaaaaa", qrCode.DefaultOptions()).
+		Return([]byte(nil), domainError.URLNotFound(storage.ErrURLNotFound))
+
+	_, err := handler.QRCode(context.Background(), &proto.QRCodeRequest{Code: "aaaaaaaaaa"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.True(t, renderer.AssertExpectations(t))
+}
+
+func TestQRCodeWithoutPublicURL(t *testing.T) {
+	handler := New(&mockRenderer{}, "")
+
+	_, err := handler.QRCode(context.Background(), &proto.QRCodeRequest{Code: "aaaaaaaaaa"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
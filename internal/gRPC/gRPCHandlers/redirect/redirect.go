@@ -2,30 +2,44 @@ package redirect
 
 import (
 	"context"
+	"urlShortener/internal/gRPC/gRPCUtils"
 	"urlShortener/internal/gRPC/proto"
-	"urlShortener/utils/e"
+	"urlShortener/internal/storage"
 )
 
 type HandleRedirect struct {
-	fullURLGetter
+	resolver
 }
 
-type fullURLGetter interface {
-	GetFullURL(shortURL string) (string, error)
+type resolver interface {
+	Unlock(ctx context.Context, shortURL, password, client string) (storage.Link, error)
 }
 
-func New(getter fullURLGetter) *HandleRedirect {
-	return &HandleRedirect{getter}
+func New(resolver resolver) *HandleRedirect {
+	return &HandleRedirect{resolver}
 }
 
+// Redirect returns where the link goes, a protected link needs its password in the request. Outside
+// of its window the link returns its fallback or FAILED_PRECONDITION. The targets by device are
+// returned as is, the client knows its platform better than the server.
 func (g *HandleRedirect) Redirect(ctx context.Context, reqShortenURL *proto.ShortURL) (*proto.FullURL, error) {
-	const fn = "gRPC.gRPCHandlers.httpRedirect.Redirect"
-	shortenURL := reqShortenURL.URL
-
-	fullURL, err := g.GetFullURL(shortenURL)
+	link, err := g.Unlock(ctx, reqShortenURL.GetURL(), reqShortenURL.GetPassword(), gRPCUtils.PeerAddr(ctx))
 	if err != nil {
-		return nil, e.WrapError(fn, err)
+		return nil, gRPCUtils.FromError(err)
 	}
 
-	return &proto.FullURL{URL: fullURL}, nil
+	return &proto.FullURL{
+		URL:             link.FullURL,
+		Redirect:        gRPCUtils.ToProtoRedirect(link.RedirectStatus),
+		Query:           gRPCUtils.ToProtoQuery(link.Query),
+		PathPassthrough: link.PathPassthrough,
+		MaxClicks:       link.MaxClicks,
+		NotBefore:       gRPCUtils.ToProtoTime(link.NotBefore),
+		NotAfter:        gRPCUtils.ToProtoTime(link.NotAfter),
+		FallbackUrl:     link.FallbackURL,
+		Targets:         gRPCUtils.ToProtoTargets(link.Targets),
+		Countries:       link.Countries,
+		Variants:        gRPCUtils.ToProtoVariants(link.Variants),
+		Sticky:          gRPCUtils.ToProtoSticky(link.Sticky),
+	}, nil
 }
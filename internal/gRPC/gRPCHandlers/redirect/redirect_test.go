@@ -5,28 +5,32 @@ import (
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"testing"
+	"urlShortener/internal/domainError"
 	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/storage"
 )
 
-const getFullURL = "GetFullURL"
+const unlock = "Unlock"
 
-type mockFullUrlGetter struct {
+type mockResolver struct {
 	mock.Mock
 }
 
-func (m *mockFullUrlGetter) GetFullURL(shortURL string) (string, error) {
-	args := m.Called(shortURL)
-	return args.String(0), args.Error(1)
+func (m *mockResolver) Unlock(ctx context.Context, shortURL, password, client string) (storage.Link, error) {
+	args := m.Called(shortURL, password)
+	return args.Get(0).(storage.Link), args.Error(1)
 }
 
 func TestRedirectSuccess(t *testing.T) {
-	getter := &mockFullUrlGetter{}
+	getter := &mockResolver{}
 	handler := New(getter)
 
 	expectedFullURL := proto.FullURL{URL: "ozon.ru"}
 	shortenURL := proto.ShortURL{URL: "aaaadaaaa"}
-	getter.On(getFullURL, shortenURL.URL).Return(expectedFullURL.URL, nil)
+	getter.On(unlock, shortenURL.URL, "").Return(storage.Link{FullURL: expectedFullURL.URL}, nil)
 
 	resultFullURL, err := handler.Redirect(context.Background(), &shortenURL)
 	assert.NoError(t, err)
@@ -36,14 +40,70 @@ func TestRedirectSuccess(t *testing.T) {
 }
 
 func TestRedirectErr(t *testing.T) {
-	getter := &mockFullUrlGetter{}
+	getter := &mockResolver{}
 	handler := New(getter)
 
 	shortenURL := proto.ShortURL{URL: "aaaadaaaa"}
-	getter.On(getFullURL, shortenURL.URL).Return("", errors.New("unknown"))
+	getter.On(unlock, shortenURL.URL, "").Return(storage.Link{}, errors.New("unknown"))
 
 	_, err := handler.Redirect(context.Background(), &shortenURL)
-	assert.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	assert.True(t, getter.AssertExpectations(t))
+}
+
+func TestRedirectNotFound(t *testing.T) {
+	getter := &mockResolver{}
+	handler := New(getter)
+
+	shortenURL := proto.ShortURL{URL: "aaaadaaaa"}
+	getter.On(unlock, shortenURL.URL, "").Return(storage.Link{}, domainError.URLNotFound(storage.ErrURLNotFound))
+
+	_, err := handler.Redirect(context.Background(), &shortenURL)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.True(t, getter.AssertExpectations(t))
+}
+
+func TestRedirectEmptyURL(t *testing.T) {
+	getter := &mockResolver{}
+	handler := New(getter)
+
+	getter.On(unlock, "", "").Return(storage.Link{}, domainError.InvalidArgument("URL", "empty"))
+
+	_, err := handler.Redirect(context.Background(), &proto.ShortURL{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	assert.True(t, getter.AssertExpectations(t))
+}
+
+func TestRedirectStatus(t *testing.T) {
+	getter := &mockResolver{}
+	handler := New(getter)
+
+	link := storage.Link{FullURL: "https://ozon.ru", Options: storage.Options{RedirectStatus: 308}}
+	getter.On(unlock, "aaaadaaaa", "").Return(link, nil)
+
+	result, err := handler.Redirect(context.Background(), &proto.ShortURL{URL: "aaaadaaaa"})
+	assert.NoError(t, err)
+	assert.Equal(t, proto.RedirectType_REDIRECT_TYPE_PERMANENT_REDIRECT, result.GetRedirect())
+
+	assert.True(t, getter.AssertExpectations(t))
+}
+
+func TestRedirectPassword(t *testing.T) {
+	getter := &mockResolver{}
+	handler := New(getter)
+
+	getter.On(unlock, "aaaadaaaa", "").Return(storage.Link{}, domainError.PasswordRequired(nil))
+	getter.On(unlock, "aaaadaaaa", "secret").Return(storage.Link{FullURL: "https://ozon.ru"}, nil)
+
+	_, err := handler.Redirect(context.Background(), &proto.ShortURL{URL: "aaaadaaaa"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	result, err := handler.Redirect(context.Background(), &proto.ShortURL{URL: "aaaadaaaa", Password: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", result.GetURL())
 
 	assert.True(t, getter.AssertExpectations(t))
 }
@@ -2,10 +2,9 @@ package save
 
 import (
 	"context"
-	"fmt"
-	"net/url"
+	"urlShortener/internal/gRPC/gRPCUtils"
 	"urlShortener/internal/gRPC/proto"
-	"urlShortener/utils/e"
+	"urlShortener/internal/storage"
 )
 
 type HandleSave struct {
@@ -13,7 +12,7 @@ type HandleSave struct {
 }
 
 type shortURLGetter interface {
-	GetShortenURL(fullURL string) (string, error)
+	GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error)
 }
 
 func New(getter shortURLGetter) *HandleSave {
@@ -21,17 +20,9 @@ func New(getter shortURLGetter) *HandleSave {
 }
 
 func (g *HandleSave) Save(ctx context.Context, reqFullURL *proto.FullURL) (*proto.ShortURL, error) {
-	const fn = "gRPC.gRPCHAndlers.httpSave.Save"
-	fullURL := reqFullURL.URL
-
-	_, err := url.ParseRequestURI(fullURL)
-	if err != nil {
-		return nil, e.WrapError(fn, err)
-	}
-
-	shortenURL, err := g.GetShortenURL(fullURL)
+	shortenURL, err := g.GetShortenURL(ctx, reqFullURL.GetURL(), gRPCUtils.FromProtoOptions(reqFullURL), reqFullURL.GetPassword())
 	if err != nil {
-		return nil, fmt.Errorf("can't get shorten URL")
+		return nil, gRPCUtils.FromError(err)
 	}
 
 	return &proto.ShortURL{URL: shortenURL}, nil
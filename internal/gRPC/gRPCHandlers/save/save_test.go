@@ -5,8 +5,15 @@ import (
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"testing"
+	"time"
+	"urlShortener/internal/domainError"
 	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/storage"
 )
 
 const getShortenURL = "GetShortenURL"
@@ -15,8 +22,8 @@ type mockShortUrlGetter struct {
 	mock.Mock
 }
 
-func (m *mockShortUrlGetter) GetShortenURL(fullURL string) (string, error) {
-	args := m.Called(fullURL)
+func (m *mockShortUrlGetter) GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error) {
+	args := m.Called(fullURL, opts, password)
 	return args.String(0), args.Error(1)
 }
 
@@ -26,7 +33,7 @@ func TestSaveSuccess(t *testing.T) {
 
 	fullURL := proto.FullURL{URL: "https://ozon.ru"}
 	expectedShortenURL := proto.ShortURL{URL: "iii098iiii"}
-	getter.On(getShortenURL, fullURL.URL).Return(expectedShortenURL.URL, nil)
+	getter.On(getShortenURL, fullURL.URL, storage.Options{}, "").Return(expectedShortenURL.URL, nil)
 
 	resultShortenURL, err := handlerSave.Save(context.Background(), &fullURL)
 	assert.NoError(t, err)
@@ -40,9 +47,10 @@ func TestSaveInvalidURL(t *testing.T) {
 	handlerSave := New(&getter)
 
 	fullURL := proto.FullURL{URL: "ozon.ru"}
+	getter.On(getShortenURL, fullURL.URL, storage.Options{}, "").Return("", domainError.InvalidArgument("URL", "wrong url"))
 
 	_, err := handlerSave.Save(context.Background(), &fullURL)
-	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
 
 	assert.True(t, getter.AssertExpectations(t))
 }
@@ -52,10 +60,53 @@ func TestSaveErrShortening(t *testing.T) {
 	handlerSave := New(&getter)
 
 	fullURL := proto.FullURL{URL: "https://ozon.ru"}
-	getter.On(getShortenURL, fullURL.URL).Return("", errors.New("unknown"))
+	getter.On(getShortenURL, fullURL.URL, storage.Options{}, "").Return("", errors.New("unknown"))
 
 	_, err := handlerSave.Save(context.Background(), &fullURL)
-	assert.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	assert.True(t, getter.AssertExpectations(t))
+}
+
+func TestSaveErrOverflow(t *testing.T) {
+	getter := mockShortUrlGetter{}
+	handlerSave := New(&getter)
+
+	fullURL := proto.FullURL{URL: "https://ozon.ru"}
+	getter.On(getShortenURL, fullURL.URL, storage.Options{}, "").Return("", domainError.IDSpaceExhausted(hashByID.ErrOverFlow))
+
+	_, err := handlerSave.Save(context.Background(), &fullURL)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	assert.True(t, getter.AssertExpectations(t))
+}
+
+func TestSaveOptions(t *testing.T) {
+	getter := mockShortUrlGetter{}
+	handlerSave := New(&getter)
+
+	fullURL := proto.FullURL{
+		URL:             "https://ozon.ru",
+		Redirect:        proto.RedirectType_REDIRECT_TYPE_MOVED_PERMANENTLY,
+		Query:           proto.QueryMerge_QUERY_MERGE_OVERRIDE,
+		PathPassthrough: true,
+		Password:        "secret",
+		MaxClicks:       1,
+		NotBefore:       timestamppb.New(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)),
+		FallbackUrl:     "https://ozon.ru/soon",
+		Targets:         &proto.Targets{Ios: "https://apps.apple.com/app/id1"},
+		Countries:       map[string]string{"kz": "https://ozon.kz"},
+		Variants:        []*proto.Variant{{URL: "https://ozon.ru/a", Weight: 1}, {Name: "new", URL: "https://ozon.ru/b", Weight: 2}},
+		Sticky:          proto.Sticky_STICKY_HASH,
+	}
+	opts := storage.Options{RedirectStatus: 301, Query: storage.QueryOverride, PathPassthrough: true, MaxClicks: 1,
+		NotBefore: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), FallbackURL: "https://ozon.ru/soon",
+		Targets: storage.Targets{IOS: "https://apps.apple.com/app/id1"}, Countries: storage.Countries{"KZ": "https://ozon.kz"},
+		Variants: storage.Variants{{Name: "A", URL: "https://ozon.ru/a", Weight: 1}, {Name: "new", URL: "https://ozon.ru/b", Weight: 2}}, Sticky: storage.StickyHash}
+	getter.On(getShortenURL, fullURL.URL, opts, "secret").Return("iii098iiii", nil)
+
+	_, err := handlerSave.Save(context.Background(), &fullURL)
+	assert.NoError(t, err)
 
 	assert.True(t, getter.AssertExpectations(t))
 }
@@ -0,0 +1,69 @@
+package gRPCServer
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"net"
+	"os"
+	"urlShortener/internal/gRPC/gRPCHandlers/admin"
+	"urlShortener/internal/gRPC/gRPCHandlers/interceptors"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/utils/e"
+)
+
+const unixNetwork = "unix"
+
+// AdminServer serves the Admin service. Every call must carry the admin token.
+type AdminServer struct {
+	*grpc.Server
+	logger *logrus.Logger
+}
+
+func NewAdmin(logger *logrus.Logger, token string, opts ...Option) *AdminServer {
+	return &AdminServer{
+		Server: newServer(logger, collectOptions(opts),
+			[]grpc.UnaryServerInterceptor{interceptors.AuthInterceptor(token)},
+			[]grpc.StreamServerInterceptor{interceptors.AuthStreamInterceptor(token)},
+		),
+		logger: logger,
+	}
+}
+
+// Run listens on a tcp address or on a unix socket path until ctx is done.
+func (a *AdminServer) Run(ctx context.Context, network string, addr string, service admin.Service) error {
+	const fn = "grpc.gRPCServer.AdminServer.Run"
+
+	proto.RegisterAdminServer(a.Server, admin.New(service))
+
+	if network == unixNetwork {
+		// сокет от предыдущего запуска мешает listen
+		if err := os.Remove(addr); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return e.WrapError(fn, err)
+		}
+	}
+
+	lis, err := net.Listen(network, addr)
+	if err != nil {
+		return e.WrapError(fn, err)
+	}
+
+	if network == unixNetwork {
+		if err = os.Chmod(addr, 0o600); err != nil {
+			_ = lis.Close()
+			return e.WrapError(fn, err)
+		}
+	}
+
+	go func() {
+		err := a.Serve(lis)
+		if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			a.logger.Errorf("%s: %v", fn, err)
+		}
+	}()
+
+	<-ctx.Done()
+	a.GracefulStop()
+	return nil
+}
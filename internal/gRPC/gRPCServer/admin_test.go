@@ -0,0 +1,117 @@
+package gRPCServer
+
+import (
+	"bytes"
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"path/filepath"
+	"sync"
+	"testing"
+	"urlShortener/internal/gRPC/gRPCClient"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+	"urlShortener/internal/transfer"
+)
+
+func TestAdminOverUnixSocket(t *testing.T) {
+	st := inMemmory.New()
+	assert.NoError(t, st.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	srv := NewAdmin(logger, "secret")
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+
+	ctx, final := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		err := srv.Run(ctx, "unix", socket, service.NewAdmin(st, hashByID.New(1)))
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+
+	client, conn, err := gRPCClient.DialAdmin("unix", socket, "secret")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	link, err := client.GetLink(context.Background(), &proto.LinkCode{Code: "aaaaaaaaaa"}, grpc.WaitForReady(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullUrl)
+
+	badClient, badConn, err := gRPCClient.DialAdmin("unix", socket, "wrong")
+	assert.NoError(t, err)
+	defer badConn.Close()
+
+	_, err = badClient.GetStats(context.Background(), &proto.StatsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	final()
+	wg.Wait()
+}
+
+func TestAdminExportImportStreams(t *testing.T) {
+	source := inMemmory.New()
+	assert.NoError(t, source.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, source.SaveURL(context.Background(), "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+	target := inMemmory.New()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	sourceSocket := filepath.Join(t.TempDir(), "source.sock")
+	targetSocket := filepath.Join(t.TempDir(), "target.sock")
+	targetCounter := hashByID.New(1)
+
+	ctx, final := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		assert.NoError(t, NewAdmin(logger, "secret").Run(ctx, "unix", sourceSocket, service.NewAdmin(source, hashByID.New(1))))
+		wg.Done()
+	}()
+	go func() {
+		assert.NoError(t, NewAdmin(logger, "secret").Run(ctx, "unix", targetSocket, service.NewAdmin(target, targetCounter)))
+		wg.Done()
+	}()
+
+	sourceClient, sourceConn, err := gRPCClient.DialAdmin("unix", sourceSocket, "secret")
+	assert.NoError(t, err)
+	defer sourceConn.Close()
+	targetClient, targetConn, err := gRPCClient.DialAdmin("unix", targetSocket, "secret")
+	assert.NoError(t, err)
+	defer targetConn.Close()
+
+	_, err = sourceClient.GetStats(context.Background(), &proto.StatsRequest{}, grpc.WaitForReady(true))
+	assert.NoError(t, err)
+	_, err = targetClient.GetStats(context.Background(), &proto.StatsRequest{}, grpc.WaitForReady(true))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, gRPCClient.ExportLinks(context.Background(), sourceClient, transfer.NewJSONLinesWriter(&buf)))
+
+	result, err := gRPCClient.ImportLinks(context.Background(), targetClient, transfer.NewJSONLinesReader(&buf), transfer.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Imported)
+	assert.Equal(t, uint64(3), targetCounter.CurrentID())
+
+	link, err := target.Resolve(context.Background(), "bbbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ya.ru", link.FullURL)
+
+	badClient, badConn, err := gRPCClient.DialAdmin("unix", sourceSocket, "wrong")
+	assert.NoError(t, err)
+	defer badConn.Close()
+
+	err = gRPCClient.ExportLinks(context.Background(), badClient, transfer.NewJSONLinesWriter(&buf))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	final()
+	wg.Wait()
+}
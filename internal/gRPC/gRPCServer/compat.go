@@ -0,0 +1,39 @@
+package gRPCServer
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"urlShortener/internal/gRPC/proto"
+)
+
+// legacySaveMethod is the name Save was published under before the stubs were regenerated.
+// TODO: убрать через релиз, когда клиенты перейдут на новые стабы
+const legacySaveMethod = "httpSave"
+
+// urlShortenerDesc registers Save under both names, so clients built from the old stubs keep working.
+func urlShortenerDesc() *grpc.ServiceDesc {
+	desc := proto.URLShortener_ServiceDesc
+	desc.Methods = append([]grpc.MethodDesc{{
+		MethodName: legacySaveMethod,
+		Handler:    legacySaveHandler,
+	}}, desc.Methods...)
+	return &desc
+}
+
+func legacySaveHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto.FullURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(proto.URLShortenerServer).Save(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + proto.URLShortener_ServiceDesc.ServiceName + "/" + legacySaveMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(proto.URLShortenerServer).Save(ctx, req.(*proto.FullURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
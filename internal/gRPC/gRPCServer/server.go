@@ -5,36 +5,84 @@ import (
 	"errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"log"
 	"net"
 	"urlShortener/internal/gRPC/gRPCHandlers"
 	"urlShortener/internal/gRPC/gRPCHandlers/interceptors"
 	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
 	"urlShortener/utils/e"
 )
 
 type Service interface {
-	GetShortenURL(fullURL string) (string, error)
-	GetFullURL(shortenURL string) (string, error)
+	GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error)
+	Unlock(ctx context.Context, shortenURL, password, client string) (storage.Link, error)
+	Inspect(ctx context.Context, shortenURL string) (service.Preview, error)
+	QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error)
 }
 
 type GRPCServer struct {
 	*grpc.Server
-	logger *logrus.Logger
+	logger    *logrus.Logger
+	health    *health.Server
+	publicURL string
 }
 
-func New(logger *logrus.Logger) *GRPCServer {
+type options struct {
+	unaryInterceptors []grpc.UnaryServerInterceptor
+	panicObserver     interceptors.PanicObserver
+	publicURL         string
+}
+
+type Option func(*options)
+
+// WithUnaryInterceptors adds interceptors that run after request ID and tracing
+// and before the logger interceptor.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(o *options) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithPanicObserver reports panics recovered in handlers.
+func WithPanicObserver(observer interceptors.PanicObserver) Option {
+	return func(o *options) {
+		o.panicObserver = observer
+	}
+}
+
+// WithPublicURL sets the base of short URLs in QR codes, the QRCode RPC fails without it.
+func WithPublicURL(publicURL string) Option {
+	return func(o *options) {
+		o.publicURL = publicURL
+	}
+}
+
+func New(logger *logrus.Logger, opts ...Option) *GRPCServer {
+	o := collectOptions(opts)
+	server := newServer(logger, o, nil, nil)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
 	return &GRPCServer{
-		grpc.NewServer(grpc.UnaryInterceptor(interceptors.LoggerInterceptor(logger))),
-		logger,
+		Server:    server,
+		logger:    logger,
+		health:    healthServer,
+		publicURL: o.publicURL,
 	}
 }
 
 func (g *GRPCServer) Run(ctx context.Context, addr string, service Service) error {
 	const fn = "grpc.gRPCServer.Run"
-	handlers := gRPCHandlers.New(service)
+	handlers := gRPCHandlers.New(service, g.publicURL)
 
-	proto.RegisterURLShortenerServer(g.Server, handlers)
+	g.RegisterService(urlShortenerDesc(), handlers)
 
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -47,7 +95,45 @@ func (g *GRPCServer) Run(ctx context.Context, addr string, service Service) erro
 			log.Fatalf("%s: %v", fn, err)
 		}
 	}()
+	g.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	g.health.SetServingStatus(proto.URLShortener_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
 	<-ctx.Done()
+	g.StartShutdown()
 	g.GracefulStop()
 	return nil
 }
+
+// StartShutdown reports NOT_SERVING to health checks while the server still serves, so load balancers
+// drain it before the connections start closing. Statuses set afterwards are ignored.
+func (g *GRPCServer) StartShutdown() {
+	g.health.Shutdown()
+}
+
+// newServer builds a server with the common interceptor chain. inner interceptors run after the logger,
+// so the calls they reject are still logged. Streams get only the inner interceptors and recovery.
+func collectOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func newServer(logger *logrus.Logger, o *options, inner []grpc.UnaryServerInterceptor, innerStream []grpc.StreamServerInterceptor) *grpc.Server {
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		interceptors.RequestIDInterceptor(),
+		interceptors.TracingInterceptor(),
+	}
+	unaryInterceptors = append(unaryInterceptors, o.unaryInterceptors...)
+	unaryInterceptors = append(unaryInterceptors, interceptors.LoggerInterceptor(logger))
+	unaryInterceptors = append(unaryInterceptors, inner...)
+	unaryInterceptors = append(unaryInterceptors, interceptors.RecoveryInterceptor(logger, o.panicObserver))
+
+	streamInterceptors := append(innerStream, interceptors.RecoveryStreamInterceptor(logger, o.panicObserver))
+
+	return grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
+}
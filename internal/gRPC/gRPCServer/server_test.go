@@ -6,25 +6,44 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 	"net"
 	"net/http"
 	"sync"
 	"testing"
 	"time"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
 )
 
 type mockShortService struct {
 	mock.Mock
 }
 
-func (m *mockShortService) GetShortenURL(fullURL string) (string, error) {
-	args := m.Called(fullURL)
+func (m *mockShortService) GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error) {
+	args := m.Called(fullURL, opts, password)
 	return args.String(0), args.Error(1)
 }
 
-func (m *mockShortService) GetFullURL(shortURL string) (string, error) {
+func (m *mockShortService) Inspect(ctx context.Context, shortURL string) (service.Preview, error) {
 	args := m.Called(shortURL)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(service.Preview), args.Error(1)
+}
+
+func (m *mockShortService) QRCode(ctx context.Context, base string, shortURL string, opts qrCode.Options) ([]byte, error) {
+	args := m.Called(base, shortURL, opts)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *mockShortService) Unlock(ctx context.Context, shortURL, password, client string) (storage.Link, error) {
+	args := m.Called(shortURL, password, client)
+	return args.Get(0).(storage.Link), args.Error(1)
 }
 
 func TestRunSuccess(t *testing.T) {
@@ -76,3 +95,173 @@ func TestRunPortInUse(t *testing.T) {
 	assert.NoError(t, err)
 	wg.Wait()
 }
+
+func TestHealthStatusTransitions(t *testing.T) {
+	service := &mockShortService{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	srv := New(logger)
+	testAddr := "localhost:8091"
+	ctx, final := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		err := srv.Run(ctx, testAddr, service)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+
+	conn, err := grpc.Dial(testAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	stream, err := client.Watch(watchCtx, &healthpb.HealthCheckRequest{Service: ""}, grpc.WaitForReady(true))
+	assert.NoError(t, err)
+
+	for {
+		resp, err := stream.Recv()
+		assert.NoError(t, err)
+		if resp.Status == healthpb.HealthCheckResponse_SERVING {
+			break
+		}
+	}
+
+	final()
+
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	stopWatch()
+	wg.Wait()
+}
+
+func TestHealthStartShutdown(t *testing.T) {
+	service := &mockShortService{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	srv := New(logger)
+	testAddr := "localhost:8092"
+	ctx, final := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		err := srv.Run(ctx, testAddr, service)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+
+	conn, err := grpc.Dial(testAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	stream, err := client.Watch(watchCtx, &healthpb.HealthCheckRequest{Service: ""}, grpc.WaitForReady(true))
+	assert.NoError(t, err)
+	for {
+		resp, err := stream.Recv()
+		assert.NoError(t, err)
+		if resp.Status == healthpb.HealthCheckResponse_SERVING {
+			break
+		}
+	}
+
+	// до отмены ctx сервер еще работает, но балансировщики уже видят NOT_SERVING
+	srv.StartShutdown()
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	check, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ""})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check.Status)
+
+	stopWatch()
+	final()
+	wg.Wait()
+}
+
+type panicCounter struct {
+	mu         sync.Mutex
+	transports []string
+}
+
+func (c *panicCounter) ObservePanic(transport string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transports = append(c.transports, transport)
+}
+
+func TestPanicRecovered(t *testing.T) {
+	service := &mockShortService{}
+	service.On("Unlock", "abcdefghij", "", mock.Anything).Run(func(args mock.Arguments) {
+		panic("boom")
+	}).Return(storage.Link{}, nil)
+	service.On("GetShortenURL", "https://ozon.ru", storage.Options{}, "").Return("abcdefghij", nil)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	counter := &panicCounter{}
+	srv := New(logger, WithPanicObserver(counter))
+	testAddr := "localhost:8092"
+	ctx, final := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		err := srv.Run(ctx, testAddr, service)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+
+	conn, err := grpc.Dial(testAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewURLShortenerClient(conn)
+	_, err = client.Redirect(context.Background(), &proto.ShortURL{URL: "abcdefghij"}, grpc.WaitForReady(true))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []string{"grpc"}, counter.transports)
+
+	resp, err := client.Save(context.Background(), &proto.FullURL{URL: "https://ozon.ru"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdefghij", resp.URL)
+
+	final()
+	wg.Wait()
+}
+
+func TestLegacySaveMethod(t *testing.T) {
+	service := &mockShortService{}
+	service.On("GetShortenURL", "https://ozon.ru", storage.Options{}, "").Return("abcdefghij", nil)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	srv := New(logger)
+	testAddr := "localhost:8093"
+	ctx, final := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		err := srv.Run(ctx, testAddr, service)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+
+	conn, err := grpc.Dial(testAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// клиенты со старыми стабами вызывают Save под именем httpSave
+	for _, method := range []string{"/service.URLShortener/httpSave", "/service.URLShortener/Save"} {
+		resp := &proto.ShortURL{}
+		err = conn.Invoke(context.Background(), method, &proto.FullURL{URL: "https://ozon.ru"}, resp, grpc.WaitForReady(true))
+		assert.NoError(t, err)
+		assert.Equal(t, "abcdefghij", resp.URL)
+	}
+
+	final()
+	wg.Wait()
+}
@@ -0,0 +1,131 @@
+package gRPCUtils
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/storage"
+)
+
+// Values of proto.RedirectType are the HTTP statuses themselves, an unknown value is passed on
+// for the service to reject.
+
+func ToProtoRedirect(status int) proto.RedirectType {
+	return proto.RedirectType(status)
+}
+
+func FromProtoRedirect(redirect proto.RedirectType) int {
+	return int(redirect)
+}
+
+var queryMerges = map[storage.QueryMerge]proto.QueryMerge{
+	storage.QueryDrop:     proto.QueryMerge_QUERY_MERGE_DROP,
+	storage.QueryKeep:     proto.QueryMerge_QUERY_MERGE_KEEP,
+	storage.QueryOverride: proto.QueryMerge_QUERY_MERGE_OVERRIDE,
+	storage.QueryAppend:   proto.QueryMerge_QUERY_MERGE_APPEND,
+}
+
+func ToProtoQuery(merge storage.QueryMerge) proto.QueryMerge {
+	return queryMerges[merge]
+}
+
+// FromProtoQuery keeps unknown values as their number, so the service rejects them.
+func FromProtoQuery(merge proto.QueryMerge) storage.QueryMerge {
+	for m, protoMerge := range queryMerges {
+		if protoMerge == merge {
+			return m
+		}
+	}
+	return storage.QueryMerge(merge.String())
+}
+
+var stickies = map[storage.Stickiness]proto.Sticky{
+	storage.StickyNone:   proto.Sticky_STICKY_NONE,
+	storage.StickyCookie: proto.Sticky_STICKY_COOKIE,
+	storage.StickyHash:   proto.Sticky_STICKY_HASH,
+}
+
+func ToProtoSticky(sticky storage.Stickiness) proto.Sticky {
+	return stickies[sticky]
+}
+
+// FromProtoSticky keeps unknown values as their number, so the service rejects them.
+func FromProtoSticky(sticky proto.Sticky) storage.Stickiness {
+	for s, protoSticky := range stickies {
+		if protoSticky == sticky {
+			return s
+		}
+	}
+	return storage.Stickiness(sticky.String())
+}
+
+func ToProtoVariants(variants []storage.Variant) []*proto.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	result := make([]*proto.Variant, 0, len(variants))
+	for _, variant := range variants {
+		result = append(result, &proto.Variant{Name: variant.Name, URL: variant.URL, Weight: variant.Weight})
+	}
+	return result
+}
+
+func FromProtoVariants(variants []*proto.Variant) []storage.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	result := make([]storage.Variant, 0, len(variants))
+	for _, variant := range variants {
+		result = append(result, storage.Variant{Name: variant.GetName(), URL: variant.GetURL(), Weight: variant.GetWeight()})
+	}
+	return result
+}
+
+func FromProtoOptions(fullURL *proto.FullURL) storage.Options {
+	return storage.Options{
+		RedirectStatus:  FromProtoRedirect(fullURL.GetRedirect()),
+		Query:           FromProtoQuery(fullURL.GetQuery()),
+		PathPassthrough: fullURL.GetPathPassthrough(),
+		MaxClicks:       fullURL.GetMaxClicks(),
+		NotBefore:       FromProtoTime(fullURL.GetNotBefore()),
+		NotAfter:        FromProtoTime(fullURL.GetNotAfter()),
+		FallbackURL:     fullURL.GetFallbackUrl(),
+		Targets:         FromProtoTargets(fullURL.GetTargets()),
+		Countries:       storage.NewCountries(fullURL.GetCountries()),
+		Variants:        storage.NewVariants(FromProtoVariants(fullURL.GetVariants())),
+		Sticky:          FromProtoSticky(fullURL.GetSticky()),
+	}
+}
+
+// ToProtoTargets leaves the message unset for a link without targets.
+func ToProtoTargets(targets storage.Targets) *proto.Targets {
+	if targets == (storage.Targets{}) {
+		return nil
+	}
+	return &proto.Targets{Ios: targets.IOS, Android: targets.Android, Desktop: targets.Desktop, Bot: targets.Bot}
+}
+
+func FromProtoTargets(targets *proto.Targets) storage.Targets {
+	return storage.Targets{
+		IOS:     targets.GetIos(),
+		Android: targets.GetAndroid(),
+		Desktop: targets.GetDesktop(),
+		Bot:     targets.GetBot(),
+	}
+}
+
+// ToProtoTime leaves an open side of the window unset.
+func ToProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// FromProtoTime returns the zero time for an unset timestamp.
+func FromProtoTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,20 @@
+package gRPCUtils
+
+import (
+	"context"
+	"google.golang.org/grpc/peer"
+	"net"
+)
+
+// PeerAddr returns the host of the client, the address as is if it has no port.
+func PeerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
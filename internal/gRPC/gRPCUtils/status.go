@@ -0,0 +1,76 @@
+package gRPCUtils
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"time"
+	"urlShortener/internal/domainError"
+)
+
+const errorDomain = "urlShortener"
+
+var grpcCodes = map[domainError.Code]codes.Code{
+	domainError.CodeInvalidArgument:  codes.InvalidArgument,
+	domainError.CodeURLNotFound:      codes.NotFound,
+	domainError.CodeURLConflict:      codes.Aborted,
+	domainError.CodeLinkExists:       codes.AlreadyExists,
+	domainError.CodeURLDisabled:      codes.FailedPrecondition,
+	domainError.CodePasswordRequired: codes.Unauthenticated,
+	domainError.CodeTooManyAttempts:  codes.ResourceExhausted,
+	domainError.CodeClicksExhausted:  codes.FailedPrecondition,
+	domainError.CodeLinkNotActive:    codes.FailedPrecondition,
+	domainError.CodeLinkExpired:      codes.FailedPrecondition,
+	domainError.CodeIDSpaceExhausted: codes.ResourceExhausted,
+	domainError.CodeUnavailable:      codes.Unavailable,
+	domainError.CodeNotSupported:     codes.Unimplemented,
+	domainError.CodeInternal:         codes.Internal,
+}
+
+// FromError translates a domain error to gRPC status. Errors that are not domain errors
+// become codes.Internal, the internal cause is never sent to the client.
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	domainErr := domainError.From(err)
+
+	code, ok := grpcCodes[domainErr.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	st := status.New(code, domainErr.Message)
+
+	metadata := map[string]string(nil)
+	if domainErr.Retryable {
+		metadata = map[string]string{"retryable": "true"}
+	}
+	if !domainErr.RetryAt.IsZero() {
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata["retryAt"] = domainErr.RetryAt.UTC().Format(time.RFC3339)
+	}
+	details := []protoiface.MessageV1{
+		&errdetails.ErrorInfo{Reason: string(domainErr.Code), Domain: errorDomain, Metadata: metadata},
+	}
+
+	if len(domainErr.Details) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range domainErr.Fields() {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: domainErr.Details[field],
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,88 @@
+package gRPCUtils
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+)
+
+func errorInfo(t *testing.T, st *status.Status) *errdetails.ErrorInfo {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			assert.Equal(t, errorDomain, info.Domain)
+			return info
+		}
+	}
+	t.Fatal("ErrorInfo detail not found")
+	return nil
+}
+
+func TestFromErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"not found", domainError.URLNotFound(storage.ErrURLNotFound), codes.NotFound},
+		{"conflict", domainError.URLConflict(storage.ErrURLExists), codes.Aborted},
+		{"disabled", domainError.URLDisabled(storage.ErrURLDisabled), codes.FailedPrecondition},
+		{"password required", domainError.PasswordRequired(nil), codes.Unauthenticated},
+		{"too many attempts", domainError.TooManyAttempts(nil), codes.ResourceExhausted},
+		{"clicks exhausted", domainError.ClicksExhausted(storage.ErrClicksExhausted), codes.FailedPrecondition},
+		{"not active", domainError.LinkNotActive(time.Now(), nil), codes.FailedPrecondition},
+		{"expired", domainError.LinkExpired(nil), codes.FailedPrecondition},
+		{"not supported", domainError.NotSupported(storage.ErrNotSupported), codes.Unimplemented},
+		{"overflow", domainError.IDSpaceExhausted(errors.New("overflow")), codes.ResourceExhausted},
+		{"unavailable", domainError.Unavailable(errors.New("pq: connection refused")), codes.Unavailable},
+		{"internal", domainError.Internal(errors.New("pq: connection refused")), codes.Internal},
+		{"not domain", errors.New("pq: connection refused"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(FromError(tt.err))
+			assert.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, string(domainError.From(tt.err).Code), errorInfo(t, st).Reason)
+			assert.NotContains(t, st.Message(), "pq:")
+		})
+	}
+}
+
+func TestFromErrorRetryable(t *testing.T) {
+	st, _ := status.FromError(FromError(domainError.URLConflict(storage.ErrURLExists)))
+	assert.Equal(t, "true", errorInfo(t, st).Metadata["retryable"])
+}
+
+func TestFromErrorRetryAt(t *testing.T) {
+	notBefore := time.Date(2024, 6, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	st, _ := status.FromError(FromError(domainError.LinkNotActive(notBefore, nil)))
+	assert.Equal(t, "2024-06-01T09:00:00Z", errorInfo(t, st).Metadata["retryAt"])
+}
+
+func TestFromErrorNil(t *testing.T) {
+	assert.NoError(t, FromError(nil))
+}
+
+func TestFromErrorInvalidArgument(t *testing.T) {
+	st, ok := status.FromError(FromError(domainError.InvalidArgument("URL", "wrong url")))
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+
+	var badRequest *errdetails.BadRequest
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			badRequest = br
+		}
+	}
+	assert.NotNil(t, badRequest)
+	assert.Len(t, badRequest.FieldViolations, 1)
+	assert.Equal(t, "URL", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "wrong url", badRequest.FieldViolations[0].Description)
+}
@@ -0,0 +1,96 @@
+package gRPCUtils
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"urlShortener/internal/gRPC/proto"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/transfer"
+)
+
+var importModes = map[transfer.Mode]proto.ImportMode{
+	transfer.ModeSkip:   proto.ImportMode_IMPORT_MODE_SKIP,
+	transfer.ModeUpdate: proto.ImportMode_IMPORT_MODE_UPDATE,
+	transfer.ModeStrict: proto.ImportMode_IMPORT_MODE_STRICT,
+}
+
+// ToProtoRecord converts an exported record to the link message of the export and import streams.
+func ToProtoRecord(record transfer.Record) *proto.Link {
+	link := &proto.Link{
+		Id:              record.ID,
+		Code:            record.Code,
+		FullUrl:         record.URL,
+		Disabled:        record.Disabled,
+		Redirect:        ToProtoRedirect(record.Redirect),
+		Query:           ToProtoQuery(storage.QueryMerge(record.Query)),
+		PathPassthrough: record.PathPassthrough,
+		PasswordHash:    record.PasswordHash,
+		MaxClicks:       record.MaxClicks,
+		ClicksLeft:      record.ClicksLeft,
+		FallbackUrl:     record.FallbackURL,
+		Countries:       record.Countries,
+		Variants:        ToProtoVariants(record.Variants),
+		Sticky:          ToProtoSticky(storage.Stickiness(record.Sticky)),
+	}
+	if record.Targets != nil {
+		link.Targets = ToProtoTargets(*record.Targets)
+	}
+	if !record.CreatedAt.IsZero() {
+		link.CreatedAt = timestamppb.New(record.CreatedAt)
+	}
+	if record.NotBefore != nil {
+		link.NotBefore = timestamppb.New(*record.NotBefore)
+	}
+	if record.NotAfter != nil {
+		link.NotAfter = timestamppb.New(*record.NotAfter)
+	}
+	return link
+}
+
+func FromProtoRecord(link *proto.Link) transfer.Record {
+	record := transfer.Record{
+		ID:              link.GetId(),
+		Code:            link.GetCode(),
+		URL:             link.GetFullUrl(),
+		Disabled:        link.GetDisabled(),
+		Redirect:        FromProtoRedirect(link.GetRedirect()),
+		Query:           string(FromProtoQuery(link.GetQuery())),
+		PathPassthrough: link.GetPathPassthrough(),
+		PasswordHash:    link.GetPasswordHash(),
+		MaxClicks:       link.GetMaxClicks(),
+		ClicksLeft:      link.GetClicksLeft(),
+		FallbackURL:     link.GetFallbackUrl(),
+		Countries:       link.GetCountries(),
+		Variants:        FromProtoVariants(link.GetVariants()),
+		Sticky:          string(FromProtoSticky(link.GetSticky())),
+	}
+	if link.GetTargets() != nil {
+		targets := FromProtoTargets(link.GetTargets())
+		record.Targets = &targets
+	}
+	if link.GetCreatedAt() != nil {
+		record.CreatedAt = link.GetCreatedAt().AsTime()
+	}
+	if link.GetNotBefore() != nil {
+		notBefore := link.GetNotBefore().AsTime()
+		record.NotBefore = &notBefore
+	}
+	if link.GetNotAfter() != nil {
+		notAfter := link.GetNotAfter().AsTime()
+		record.NotAfter = &notAfter
+	}
+	return record
+}
+
+func ToProtoMode(mode transfer.Mode) proto.ImportMode {
+	return importModes[mode]
+}
+
+// FromProtoMode keeps unknown values as their number, so the service rejects them as an unknown mode.
+func FromProtoMode(mode proto.ImportMode) transfer.Mode {
+	for m, protoMode := range importModes {
+		if protoMode == mode {
+			return m
+		}
+	}
+	return transfer.Mode(mode.String())
+}
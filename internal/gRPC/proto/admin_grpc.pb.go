@@ -0,0 +1,451 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	GetLink(ctx context.Context, in *LinkCode, opts ...grpc.CallOption) (*Link, error)
+	FindLink(ctx context.Context, in *FindLinkRequest, opts ...grpc.CallOption) (*Link, error)
+	DeleteLink(ctx context.Context, in *LinkCode, opts ...grpc.CallOption) (*DeleteLinkResponse, error)
+	SetDisabled(ctx context.Context, in *SetDisabledRequest, opts ...grpc.CallOption) (*Link, error)
+	ReassignDomain(ctx context.Context, in *ReassignDomainRequest, opts ...grpc.CallOption) (*ReassignDomainResponse, error)
+	GetCounterStatus(ctx context.Context, in *CounterStatusRequest, opts ...grpc.CallOption) (*CounterStatus, error)
+	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error)
+	ExportLinks(ctx context.Context, in *ExportLinksRequest, opts ...grpc.CallOption) (Admin_ExportLinksClient, error)
+	ImportLinks(ctx context.Context, opts ...grpc.CallOption) (Admin_ImportLinksClient, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) GetLink(ctx context.Context, in *LinkCode, opts ...grpc.CallOption) (*Link, error) {
+	out := new(Link)
+	err := c.cc.Invoke(ctx, "/service.Admin/GetLink", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) FindLink(ctx context.Context, in *FindLinkRequest, opts ...grpc.CallOption) (*Link, error) {
+	out := new(Link)
+	err := c.cc.Invoke(ctx, "/service.Admin/FindLink", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteLink(ctx context.Context, in *LinkCode, opts ...grpc.CallOption) (*DeleteLinkResponse, error) {
+	out := new(DeleteLinkResponse)
+	err := c.cc.Invoke(ctx, "/service.Admin/DeleteLink", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SetDisabled(ctx context.Context, in *SetDisabledRequest, opts ...grpc.CallOption) (*Link, error) {
+	out := new(Link)
+	err := c.cc.Invoke(ctx, "/service.Admin/SetDisabled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReassignDomain(ctx context.Context, in *ReassignDomainRequest, opts ...grpc.CallOption) (*ReassignDomainResponse, error) {
+	out := new(ReassignDomainResponse)
+	err := c.cc.Invoke(ctx, "/service.Admin/ReassignDomain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetCounterStatus(ctx context.Context, in *CounterStatusRequest, opts ...grpc.CallOption) (*CounterStatus, error) {
+	out := new(CounterStatus)
+	err := c.cc.Invoke(ctx, "/service.Admin/GetCounterStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error) {
+	out := new(Stats)
+	err := c.cc.Invoke(ctx, "/service.Admin/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ExportLinks(ctx context.Context, in *ExportLinksRequest, opts ...grpc.CallOption) (Admin_ExportLinksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], "/service.Admin/ExportLinks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminExportLinksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_ExportLinksClient interface {
+	Recv() (*Link, error)
+	grpc.ClientStream
+}
+
+type adminExportLinksClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminExportLinksClient) Recv() (*Link, error) {
+	m := new(Link)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) ImportLinks(ctx context.Context, opts ...grpc.CallOption) (Admin_ImportLinksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[1], "/service.Admin/ImportLinks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminImportLinksClient{stream}
+	return x, nil
+}
+
+type Admin_ImportLinksClient interface {
+	Send(*ImportLinkRequest) error
+	CloseAndRecv() (*ImportLinksResponse, error)
+	grpc.ClientStream
+}
+
+type adminImportLinksClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminImportLinksClient) Send(m *ImportLinkRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adminImportLinksClient) CloseAndRecv() (*ImportLinksResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportLinksResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	GetLink(context.Context, *LinkCode) (*Link, error)
+	FindLink(context.Context, *FindLinkRequest) (*Link, error)
+	DeleteLink(context.Context, *LinkCode) (*DeleteLinkResponse, error)
+	SetDisabled(context.Context, *SetDisabledRequest) (*Link, error)
+	ReassignDomain(context.Context, *ReassignDomainRequest) (*ReassignDomainResponse, error)
+	GetCounterStatus(context.Context, *CounterStatusRequest) (*CounterStatus, error)
+	GetStats(context.Context, *StatsRequest) (*Stats, error)
+	ExportLinks(*ExportLinksRequest, Admin_ExportLinksServer) error
+	ImportLinks(Admin_ImportLinksServer) error
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) GetLink(context.Context, *LinkCode) (*Link, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLink not implemented")
+}
+func (UnimplementedAdminServer) FindLink(context.Context, *FindLinkRequest) (*Link, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindLink not implemented")
+}
+func (UnimplementedAdminServer) DeleteLink(context.Context, *LinkCode) (*DeleteLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLink not implemented")
+}
+func (UnimplementedAdminServer) SetDisabled(context.Context, *SetDisabledRequest) (*Link, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDisabled not implemented")
+}
+func (UnimplementedAdminServer) ReassignDomain(context.Context, *ReassignDomainRequest) (*ReassignDomainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReassignDomain not implemented")
+}
+func (UnimplementedAdminServer) GetCounterStatus(context.Context, *CounterStatusRequest) (*CounterStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCounterStatus not implemented")
+}
+func (UnimplementedAdminServer) GetStats(context.Context, *StatsRequest) (*Stats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedAdminServer) ExportLinks(*ExportLinksRequest, Admin_ExportLinksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportLinks not implemented")
+}
+func (UnimplementedAdminServer) ImportLinks(Admin_ImportLinksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportLinks not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_GetLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/GetLink",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetLink(ctx, req.(*LinkCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_FindLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).FindLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/FindLink",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).FindLink(ctx, req.(*FindLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/DeleteLink",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteLink(ctx, req.(*LinkCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/SetDisabled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetDisabled(ctx, req.(*SetDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReassignDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReassignDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReassignDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/ReassignDomain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReassignDomain(ctx, req.(*ReassignDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetCounterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetCounterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/GetCounterStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetCounterStatus(ctx, req.(*CounterStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Admin/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetStats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ExportLinks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportLinksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).ExportLinks(m, &adminExportLinksServer{stream})
+}
+
+type Admin_ExportLinksServer interface {
+	Send(*Link) error
+	grpc.ServerStream
+}
+
+type adminExportLinksServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminExportLinksServer) Send(m *Link) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Admin_ImportLinks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServer).ImportLinks(&adminImportLinksServer{stream})
+}
+
+type Admin_ImportLinksServer interface {
+	SendAndClose(*ImportLinksResponse) error
+	Recv() (*ImportLinkRequest, error)
+	grpc.ServerStream
+}
+
+type adminImportLinksServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminImportLinksServer) SendAndClose(m *ImportLinksResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adminImportLinksServer) Recv() (*ImportLinkRequest, error) {
+	m := new(ImportLinkRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "service.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLink",
+			Handler:    _Admin_GetLink_Handler,
+		},
+		{
+			MethodName: "FindLink",
+			Handler:    _Admin_FindLink_Handler,
+		},
+		{
+			MethodName: "DeleteLink",
+			Handler:    _Admin_DeleteLink_Handler,
+		},
+		{
+			MethodName: "SetDisabled",
+			Handler:    _Admin_SetDisabled_Handler,
+		},
+		{
+			MethodName: "ReassignDomain",
+			Handler:    _Admin_ReassignDomain_Handler,
+		},
+		{
+			MethodName: "GetCounterStatus",
+			Handler:    _Admin_GetCounterStatus_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Admin_GetStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportLinks",
+			Handler:       _Admin_ExportLinks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportLinks",
+			Handler:       _Admin_ImportLinks_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "admin.proto",
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.26.0
+// 	protoc-gen-go v1.31.0
 // 	protoc        v3.6.1
 // source: service.proto
 
@@ -9,6 +9,7 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -20,12 +21,353 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// RedirectType is the HTTP status of the redirect, DEFAULT leaves the choice to the server.
+type RedirectType int32
+
+const (
+	RedirectType_REDIRECT_TYPE_DEFAULT            RedirectType = 0
+	RedirectType_REDIRECT_TYPE_MOVED_PERMANENTLY  RedirectType = 301
+	RedirectType_REDIRECT_TYPE_FOUND              RedirectType = 302
+	RedirectType_REDIRECT_TYPE_TEMPORARY_REDIRECT RedirectType = 307
+	RedirectType_REDIRECT_TYPE_PERMANENT_REDIRECT RedirectType = 308
+)
+
+// Enum value maps for RedirectType.
+var (
+	RedirectType_name = map[int32]string{
+		0:   "REDIRECT_TYPE_DEFAULT",
+		301: "REDIRECT_TYPE_MOVED_PERMANENTLY",
+		302: "REDIRECT_TYPE_FOUND",
+		307: "REDIRECT_TYPE_TEMPORARY_REDIRECT",
+		308: "REDIRECT_TYPE_PERMANENT_REDIRECT",
+	}
+	RedirectType_value = map[string]int32{
+		"REDIRECT_TYPE_DEFAULT":            0,
+		"REDIRECT_TYPE_MOVED_PERMANENTLY":  301,
+		"REDIRECT_TYPE_FOUND":              302,
+		"REDIRECT_TYPE_TEMPORARY_REDIRECT": 307,
+		"REDIRECT_TYPE_PERMANENT_REDIRECT": 308,
+	}
+)
+
+func (x RedirectType) Enum() *RedirectType {
+	p := new(RedirectType)
+	*p = x
+	return p
+}
+
+func (x RedirectType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RedirectType) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[0].Descriptor()
+}
+
+func (RedirectType) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[0]
+}
+
+func (x RedirectType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RedirectType.Descriptor instead.
+func (RedirectType) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{0}
+}
+
+// QueryMerge is how the query of a visit is merged into the URL, DROP ignores it.
+type QueryMerge int32
+
+const (
+	QueryMerge_QUERY_MERGE_DROP QueryMerge = 0
+	// the URL keeps its own values of the same parameters
+	QueryMerge_QUERY_MERGE_KEEP     QueryMerge = 1
+	QueryMerge_QUERY_MERGE_OVERRIDE QueryMerge = 2
+	QueryMerge_QUERY_MERGE_APPEND   QueryMerge = 3
+)
+
+// Enum value maps for QueryMerge.
+var (
+	QueryMerge_name = map[int32]string{
+		0: "QUERY_MERGE_DROP",
+		1: "QUERY_MERGE_KEEP",
+		2: "QUERY_MERGE_OVERRIDE",
+		3: "QUERY_MERGE_APPEND",
+	}
+	QueryMerge_value = map[string]int32{
+		"QUERY_MERGE_DROP":     0,
+		"QUERY_MERGE_KEEP":     1,
+		"QUERY_MERGE_OVERRIDE": 2,
+		"QUERY_MERGE_APPEND":   3,
+	}
+)
+
+func (x QueryMerge) Enum() *QueryMerge {
+	p := new(QueryMerge)
+	*p = x
+	return p
+}
+
+func (x QueryMerge) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueryMerge) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[1].Descriptor()
+}
+
+func (QueryMerge) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[1]
+}
+
+func (x QueryMerge) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueryMerge.Descriptor instead.
+func (QueryMerge) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{1}
+}
+
+// Sticky is how a visitor keeps seeing the same variant, NONE chooses on every visit.
+type Sticky int32
+
+const (
+	Sticky_STICKY_NONE Sticky = 0
+	// the variant is remembered in a cookie
+	Sticky_STICKY_COOKIE Sticky = 1
+	// the variant is derived from the client IP and the User-Agent
+	Sticky_STICKY_HASH Sticky = 2
+)
+
+// Enum value maps for Sticky.
+var (
+	Sticky_name = map[int32]string{
+		0: "STICKY_NONE",
+		1: "STICKY_COOKIE",
+		2: "STICKY_HASH",
+	}
+	Sticky_value = map[string]int32{
+		"STICKY_NONE":   0,
+		"STICKY_COOKIE": 1,
+		"STICKY_HASH":   2,
+	}
+)
+
+func (x Sticky) Enum() *Sticky {
+	p := new(Sticky)
+	*p = x
+	return p
+}
+
+func (x Sticky) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Sticky) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[2].Descriptor()
+}
+
+func (Sticky) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[2]
+}
+
+func (x Sticky) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Sticky.Descriptor instead.
+func (Sticky) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{2}
+}
+
+type Safety int32
+
+const (
+	Safety_SAFETY_UNSPECIFIED Safety = 0
+	Safety_SAFETY_OK          Safety = 1
+	// the URL is not https
+	Safety_SAFETY_INSECURE Safety = 2
+	// the link is disabled, its URL is not shown
+	Safety_SAFETY_DISABLED Safety = 3
+)
+
+// Enum value maps for Safety.
+var (
+	Safety_name = map[int32]string{
+		0: "SAFETY_UNSPECIFIED",
+		1: "SAFETY_OK",
+		2: "SAFETY_INSECURE",
+		3: "SAFETY_DISABLED",
+	}
+	Safety_value = map[string]int32{
+		"SAFETY_UNSPECIFIED": 0,
+		"SAFETY_OK":          1,
+		"SAFETY_INSECURE":    2,
+		"SAFETY_DISABLED":    3,
+	}
+)
+
+func (x Safety) Enum() *Safety {
+	p := new(Safety)
+	*p = x
+	return p
+}
+
+func (x Safety) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Safety) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[3].Descriptor()
+}
+
+func (Safety) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[3]
+}
+
+func (x Safety) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Safety.Descriptor instead.
+func (Safety) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{3}
+}
+
+type QRFormat int32
+
+const (
+	QRFormat_QR_FORMAT_PNG QRFormat = 0
+	QRFormat_QR_FORMAT_SVG QRFormat = 1
+)
+
+// Enum value maps for QRFormat.
+var (
+	QRFormat_name = map[int32]string{
+		0: "QR_FORMAT_PNG",
+		1: "QR_FORMAT_SVG",
+	}
+	QRFormat_value = map[string]int32{
+		"QR_FORMAT_PNG": 0,
+		"QR_FORMAT_SVG": 1,
+	}
+)
+
+func (x QRFormat) Enum() *QRFormat {
+	p := new(QRFormat)
+	*p = x
+	return p
+}
+
+func (x QRFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QRFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[4].Descriptor()
+}
+
+func (QRFormat) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[4]
+}
+
+func (x QRFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QRFormat.Descriptor instead.
+func (QRFormat) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{4}
+}
+
+// QRLevel is the error correction level, DEFAULT is M.
+type QRLevel int32
+
+const (
+	QRLevel_QR_LEVEL_DEFAULT QRLevel = 0
+	QRLevel_QR_LEVEL_L       QRLevel = 1
+	QRLevel_QR_LEVEL_M       QRLevel = 2
+	QRLevel_QR_LEVEL_Q       QRLevel = 3
+	QRLevel_QR_LEVEL_H       QRLevel = 4
+)
+
+// Enum value maps for QRLevel.
+var (
+	QRLevel_name = map[int32]string{
+		0: "QR_LEVEL_DEFAULT",
+		1: "QR_LEVEL_L",
+		2: "QR_LEVEL_M",
+		3: "QR_LEVEL_Q",
+		4: "QR_LEVEL_H",
+	}
+	QRLevel_value = map[string]int32{
+		"QR_LEVEL_DEFAULT": 0,
+		"QR_LEVEL_L":       1,
+		"QR_LEVEL_M":       2,
+		"QR_LEVEL_Q":       3,
+		"QR_LEVEL_H":       4,
+	}
+)
+
+func (x QRLevel) Enum() *QRLevel {
+	p := new(QRLevel)
+	*p = x
+	return p
+}
+
+func (x QRLevel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QRLevel) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[5].Descriptor()
+}
+
+func (QRLevel) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[5]
+}
+
+func (x QRLevel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QRLevel.Descriptor instead.
+func (QRLevel) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{5}
+}
+
 type FullURL struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	URL string `protobuf:"bytes,1,opt,name=URL,proto3" json:"URL,omitempty"`
+	URL      string       `protobuf:"bytes,1,opt,name=URL,proto3" json:"URL,omitempty"`
+	Redirect RedirectType `protobuf:"varint,2,opt,name=redirect,proto3,enum=service.RedirectType" json:"redirect,omitempty"`
+	Query    QueryMerge   `protobuf:"varint,3,opt,name=query,proto3,enum=service.QueryMerge" json:"query,omitempty"`
+	// path_passthrough appends the path after the code to the URL.
+	PathPassthrough bool `protobuf:"varint,4,opt,name=path_passthrough,json=pathPassthrough,proto3" json:"path_passthrough,omitempty"`
+	// password protects the link on Save, it's never returned.
+	Password string `protobuf:"bytes,5,opt,name=password,proto3" json:"password,omitempty"`
+	// max_clicks limits the redirects of the link, zero means no limit.
+	MaxClicks uint64 `protobuf:"varint,6,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// not_before and not_after are the window when the link redirects, an unset one leaves that side open.
+	NotBefore *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter  *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	// fallback_url is where the link redirects outside of its window.
+	FallbackUrl string `protobuf:"bytes,9,opt,name=fallback_url,json=fallbackUrl,proto3" json:"fallback_url,omitempty"`
+	// targets replace the URL for some devices.
+	Targets *Targets `protobuf:"bytes,10,opt,name=targets,proto3" json:"targets,omitempty"`
+	// countries replace the URL for visitors from some countries, keys are ISO 3166-1 alpha-2 codes.
+	// A device target wins over the country.
+	Countries map[string]string `protobuf:"bytes,11,rep,name=countries,proto3" json:"countries,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// variants split the visitors that would get the URL between several URLs by weight, unnamed ones
+	// are called A, B, C by position. Redirect returns them for the client to choose, only HTTP redirects
+	// are counted in the stats of the variants.
+	Variants []*Variant `protobuf:"bytes,12,rep,name=variants,proto3" json:"variants,omitempty"`
+	Sticky   Sticky     `protobuf:"varint,13,opt,name=sticky,proto3,enum=service.Sticky" json:"sticky,omitempty"`
 }
 
 func (x *FullURL) Reset() {
@@ -67,18 +409,240 @@ func (x *FullURL) GetURL() string {
 	return ""
 }
 
+func (x *FullURL) GetRedirect() RedirectType {
+	if x != nil {
+		return x.Redirect
+	}
+	return RedirectType_REDIRECT_TYPE_DEFAULT
+}
+
+func (x *FullURL) GetQuery() QueryMerge {
+	if x != nil {
+		return x.Query
+	}
+	return QueryMerge_QUERY_MERGE_DROP
+}
+
+func (x *FullURL) GetPathPassthrough() bool {
+	if x != nil {
+		return x.PathPassthrough
+	}
+	return false
+}
+
+func (x *FullURL) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *FullURL) GetMaxClicks() uint64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *FullURL) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *FullURL) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+func (x *FullURL) GetFallbackUrl() string {
+	if x != nil {
+		return x.FallbackUrl
+	}
+	return ""
+}
+
+func (x *FullURL) GetTargets() *Targets {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *FullURL) GetCountries() map[string]string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *FullURL) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *FullURL) GetSticky() Sticky {
+	if x != nil {
+		return x.Sticky
+	}
+	return Sticky_STICKY_NONE
+}
+
+// Variant is one of the URLs an A/B link splits its visitors between.
+type Variant struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	URL    string `protobuf:"bytes,2,opt,name=URL,proto3" json:"URL,omitempty"`
+	Weight uint32 `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Variant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Variant) GetURL() string {
+	if x != nil {
+		return x.URL
+	}
+	return ""
+}
+
+func (x *Variant) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// Targets are the URLs of the link by the device of the visitor, unset ones use the URL of the link.
+type Targets struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ios     string `protobuf:"bytes,1,opt,name=ios,proto3" json:"ios,omitempty"`
+	Android string `protobuf:"bytes,2,opt,name=android,proto3" json:"android,omitempty"`
+	Desktop string `protobuf:"bytes,3,opt,name=desktop,proto3" json:"desktop,omitempty"`
+	Bot     string `protobuf:"bytes,4,opt,name=bot,proto3" json:"bot,omitempty"`
+}
+
+func (x *Targets) Reset() {
+	*x = Targets{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_service_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Targets) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Targets) ProtoMessage() {}
+
+func (x *Targets) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Targets.ProtoReflect.Descriptor instead.
+func (*Targets) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Targets) GetIos() string {
+	if x != nil {
+		return x.Ios
+	}
+	return ""
+}
+
+func (x *Targets) GetAndroid() string {
+	if x != nil {
+		return x.Android
+	}
+	return ""
+}
+
+func (x *Targets) GetDesktop() string {
+	if x != nil {
+		return x.Desktop
+	}
+	return ""
+}
+
+func (x *Targets) GetBot() string {
+	if x != nil {
+		return x.Bot
+	}
+	return ""
+}
+
 type ShortURL struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	URL string `protobuf:"bytes,1,opt,name=URL,proto3" json:"URL,omitempty"`
+	// password opens a protected link on Redirect.
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 }
 
 func (x *ShortURL) Reset() {
 	*x = ShortURL{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_service_proto_msgTypes[1]
+		mi := &file_service_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -91,7 +655,7 @@ func (x *ShortURL) String() string {
 func (*ShortURL) ProtoMessage() {}
 
 func (x *ShortURL) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[1]
+	mi := &file_service_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -104,7 +668,7 @@ func (x *ShortURL) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShortURL.ProtoReflect.Descriptor instead.
 func (*ShortURL) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{1}
+	return file_service_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ShortURL) GetURL() string {
@@ -114,23 +678,394 @@ func (x *ShortURL) GetURL() string {
 	return ""
 }
 
+func (x *ShortURL) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type LinkPreview struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code      string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	URL       string                 `protobuf:"bytes,2,opt,name=URL,proto3" json:"URL,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Safety    Safety                 `protobuf:"varint,4,opt,name=safety,proto3,enum=service.Safety" json:"safety,omitempty"`
+	// protected links need a password, their URL is not shown
+	Protected bool `protobuf:"varint,5,opt,name=protected,proto3" json:"protected,omitempty"`
+	// the URL is not shown outside of the window
+	NotBefore *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter  *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+}
+
+func (x *LinkPreview) Reset() {
+	*x = LinkPreview{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_service_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LinkPreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkPreview) ProtoMessage() {}
+
+func (x *LinkPreview) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkPreview.ProtoReflect.Descriptor instead.
+func (*LinkPreview) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LinkPreview) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetURL() string {
+	if x != nil {
+		return x.URL
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *LinkPreview) GetSafety() Safety {
+	if x != nil {
+		return x.Safety
+	}
+	return Safety_SAFETY_UNSPECIFIED
+}
+
+func (x *LinkPreview) GetProtected() bool {
+	if x != nil {
+		return x.Protected
+	}
+	return false
+}
+
+func (x *LinkPreview) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *LinkPreview) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+type QRCodeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code   string   `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Format QRFormat `protobuf:"varint,2,opt,name=format,proto3,enum=service.QRFormat" json:"format,omitempty"`
+	// size is the side in pixels, 256 if zero.
+	Size  uint32  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Level QRLevel `protobuf:"varint,4,opt,name=level,proto3,enum=service.QRLevel" json:"level,omitempty"`
+	// margin is the quiet zone in modules, 4 if not set.
+	Margin *uint32 `protobuf:"varint,5,opt,name=margin,proto3,oneof" json:"margin,omitempty"`
+}
+
+func (x *QRCodeRequest) Reset() {
+	*x = QRCodeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_service_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QRCodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QRCodeRequest) ProtoMessage() {}
+
+func (x *QRCodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QRCodeRequest.ProtoReflect.Descriptor instead.
+func (*QRCodeRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *QRCodeRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *QRCodeRequest) GetFormat() QRFormat {
+	if x != nil {
+		return x.Format
+	}
+	return QRFormat_QR_FORMAT_PNG
+}
+
+func (x *QRCodeRequest) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *QRCodeRequest) GetLevel() QRLevel {
+	if x != nil {
+		return x.Level
+	}
+	return QRLevel_QR_LEVEL_DEFAULT
+}
+
+func (x *QRCodeRequest) GetMargin() uint32 {
+	if x != nil && x.Margin != nil {
+		return *x.Margin
+	}
+	return 0
+}
+
+type QRCodeImage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Image       []byte `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	ContentType string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+}
+
+func (x *QRCodeImage) Reset() {
+	*x = QRCodeImage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_service_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QRCodeImage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QRCodeImage) ProtoMessage() {}
+
+func (x *QRCodeImage) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QRCodeImage.ProtoReflect.Descriptor instead.
+func (*QRCodeImage) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *QRCodeImage) GetImage() []byte {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *QRCodeImage) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
 var File_service_proto protoreflect.FileDescriptor
 
 var file_service_proto_rawDesc = []byte{
 	0x0a, 0x0d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x22, 0x1b, 0x0a, 0x07, 0x46, 0x75, 0x6c, 0x6c,
-	0x55, 0x52, 0x4c, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x55, 0x52, 0x4c, 0x22, 0x1c, 0x0a, 0x08, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x55, 0x52,
-	0x4c, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x55, 0x52, 0x4c, 0x32, 0x70, 0x0a, 0x0c, 0x55, 0x52, 0x4c, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x65,
-	0x6e, 0x65, 0x72, 0x12, 0x2d, 0x0a, 0x04, 0x53, 0x61, 0x76, 0x65, 0x12, 0x10, 0x2e, 0x73, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x46, 0x75, 0x6c, 0x6c, 0x55, 0x52, 0x4c, 0x1a, 0x11, 0x2e,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x55, 0x52, 0x4c,
-	0x22, 0x00, 0x12, 0x31, 0x0a, 0x08, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x12, 0x11,
-	0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x55, 0x52,
-	0x4c, 0x1a, 0x10, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x46, 0x75, 0x6c, 0x6c,
-	0x55, 0x52, 0x4c, 0x22, 0x00, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf6, 0x04, 0x0a, 0x07, 0x46, 0x75,
+	0x6c, 0x6c, 0x55, 0x52, 0x4c, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x55, 0x52, 0x4c, 0x12, 0x31, 0x0a, 0x08, 0x72, 0x65, 0x64, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x08, 0x72, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x12, 0x29, 0x0a, 0x05, 0x71, 0x75,
+	0x65, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x52, 0x05,
+	0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x61, 0x74, 0x68, 0x5f, 0x70, 0x61,
+	0x73, 0x73, 0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0f, 0x70, 0x61, 0x74, 0x68, 0x50, 0x61, 0x73, 0x73, 0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68,
+	0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x6d, 0x61, 0x78, 0x5f, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x09, 0x6d, 0x61, 0x78, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x73, 0x12, 0x39, 0x0a, 0x0a, 0x6e,
+	0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x6e, 0x6f, 0x74,
+	0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f, 0x61, 0x66,
+	0x74, 0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12,
+	0x21, 0x0a, 0x0c, 0x66, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x75, 0x72, 0x6c, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x66, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x55,
+	0x72, 0x6c, 0x12, 0x2a, 0x0a, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x54, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x73, 0x52, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x12, 0x3d,
+	0x0a, 0x09, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1f, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x46, 0x75, 0x6c, 0x6c,
+	0x55, 0x52, 0x4c, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x09, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x2c, 0x0a,
+	0x08, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x56, 0x61, 0x72, 0x69, 0x61, 0x6e,
+	0x74, 0x52, 0x08, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x06, 0x73,
+	0x74, 0x69, 0x63, 0x6b, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x74, 0x69, 0x63, 0x6b, 0x79, 0x52, 0x06, 0x73, 0x74,
+	0x69, 0x63, 0x6b, 0x79, 0x1a, 0x3c, 0x0a, 0x0e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x69, 0x65,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0x47, 0x0a, 0x07, 0x56, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x52, 0x4c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x55, 0x52, 0x4c, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x61, 0x0a, 0x07, 0x54,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x6f, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x69, 0x6f, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6e, 0x64, 0x72,
+	0x6f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x6e, 0x64, 0x72, 0x6f,
+	0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x12, 0x10, 0x0a, 0x03,
+	0x62, 0x6f, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x62, 0x6f, 0x74, 0x22, 0x38,
+	0x0a, 0x08, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x55, 0x52, 0x4c, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x52,
+	0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x55, 0x52, 0x4c, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0xa9, 0x02, 0x0a, 0x0b, 0x4c, 0x69, 0x6e,
+	0x6b, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x10, 0x0a, 0x03,
+	0x55, 0x52, 0x4c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x55, 0x52, 0x4c, 0x12, 0x39,
+	0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x27, 0x0a, 0x06, 0x73, 0x61, 0x66,
+	0x65, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x53, 0x61, 0x66, 0x65, 0x74, 0x79, 0x52, 0x06, 0x73, 0x61, 0x66, 0x65,
+	0x74, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x12, 0x39, 0x0a, 0x0a, 0x6e, 0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x09, 0x6e,
+	0x6f, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41,
+	0x66, 0x74, 0x65, 0x72, 0x22, 0xb2, 0x01, 0x0a, 0x0d, 0x51, 0x52, 0x43, 0x6f, 0x64, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x73, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x2e, 0x51, 0x52, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x66,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x51, 0x52, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x1b, 0x0a, 0x06, 0x6d, 0x61, 0x72, 0x67, 0x69, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0d, 0x48, 0x00, 0x52, 0x06, 0x6d, 0x61, 0x72, 0x67, 0x69, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x09,
+	0x0a, 0x07, 0x5f, 0x6d, 0x61, 0x72, 0x67, 0x69, 0x6e, 0x22, 0x46, 0x0a, 0x0b, 0x51, 0x52, 0x43,
+	0x6f, 0x64, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x21,
+	0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70,
+	0x65, 0x2a, 0xb7, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x19, 0x0a, 0x15, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x00, 0x12, 0x24, 0x0a,
+	0x1f, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4d,
+	0x4f, 0x56, 0x45, 0x44, 0x5f, 0x50, 0x45, 0x52, 0x4d, 0x41, 0x4e, 0x45, 0x4e, 0x54, 0x4c, 0x59,
+	0x10, 0xad, 0x02, 0x12, 0x18, 0x0a, 0x13, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0xae, 0x02, 0x12, 0x25, 0x0a,
+	0x20, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54,
+	0x45, 0x4d, 0x50, 0x4f, 0x52, 0x41, 0x52, 0x59, 0x5f, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43,
+	0x54, 0x10, 0xb3, 0x02, 0x12, 0x25, 0x0a, 0x20, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x45, 0x52, 0x4d, 0x41, 0x4e, 0x45, 0x4e, 0x54, 0x5f,
+	0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x10, 0xb4, 0x02, 0x2a, 0x6a, 0x0a, 0x0a, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x51, 0x55, 0x45,
+	0x52, 0x59, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f, 0x44, 0x52, 0x4f, 0x50, 0x10, 0x00, 0x12,
+	0x14, 0x0a, 0x10, 0x51, 0x55, 0x45, 0x52, 0x59, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f, 0x4b,
+	0x45, 0x45, 0x50, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x51, 0x55, 0x45, 0x52, 0x59, 0x5f, 0x4d,
+	0x45, 0x52, 0x47, 0x45, 0x5f, 0x4f, 0x56, 0x45, 0x52, 0x52, 0x49, 0x44, 0x45, 0x10, 0x02, 0x12,
+	0x16, 0x0a, 0x12, 0x51, 0x55, 0x45, 0x52, 0x59, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f, 0x41,
+	0x50, 0x50, 0x45, 0x4e, 0x44, 0x10, 0x03, 0x2a, 0x3d, 0x0a, 0x06, 0x53, 0x74, 0x69, 0x63, 0x6b,
+	0x79, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x54, 0x49, 0x43, 0x4b, 0x59, 0x5f, 0x4e, 0x4f, 0x4e, 0x45,
+	0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x54, 0x49, 0x43, 0x4b, 0x59, 0x5f, 0x43, 0x4f, 0x4f,
+	0x4b, 0x49, 0x45, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x54, 0x49, 0x43, 0x4b, 0x59, 0x5f,
+	0x48, 0x41, 0x53, 0x48, 0x10, 0x02, 0x2a, 0x59, 0x0a, 0x06, 0x53, 0x61, 0x66, 0x65, 0x74, 0x79,
+	0x12, 0x16, 0x0a, 0x12, 0x53, 0x41, 0x46, 0x45, 0x54, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x41, 0x46, 0x45,
+	0x54, 0x59, 0x5f, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x53, 0x41, 0x46, 0x45, 0x54,
+	0x59, 0x5f, 0x49, 0x4e, 0x53, 0x45, 0x43, 0x55, 0x52, 0x45, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f,
+	0x53, 0x41, 0x46, 0x45, 0x54, 0x59, 0x5f, 0x44, 0x49, 0x53, 0x41, 0x42, 0x4c, 0x45, 0x44, 0x10,
+	0x03, 0x2a, 0x30, 0x0a, 0x08, 0x51, 0x52, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x11, 0x0a,
+	0x0d, 0x51, 0x52, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x50, 0x4e, 0x47, 0x10, 0x00,
+	0x12, 0x11, 0x0a, 0x0d, 0x51, 0x52, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x53, 0x56,
+	0x47, 0x10, 0x01, 0x2a, 0x5f, 0x0a, 0x07, 0x51, 0x52, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x14,
+	0x0a, 0x10, 0x51, 0x52, 0x5f, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x44, 0x45, 0x46, 0x41, 0x55,
+	0x4c, 0x54, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x51, 0x52, 0x5f, 0x4c, 0x45, 0x56, 0x45, 0x4c,
+	0x5f, 0x4c, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x51, 0x52, 0x5f, 0x4c, 0x45, 0x56, 0x45, 0x4c,
+	0x5f, 0x4d, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x51, 0x52, 0x5f, 0x4c, 0x45, 0x56, 0x45, 0x4c,
+	0x5f, 0x51, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x51, 0x52, 0x5f, 0x4c, 0x45, 0x56, 0x45, 0x4c,
+	0x5f, 0x48, 0x10, 0x04, 0x32, 0xe0, 0x01, 0x0a, 0x0c, 0x55, 0x52, 0x4c, 0x53, 0x68, 0x6f, 0x72,
+	0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x2d, 0x0a, 0x04, 0x53, 0x61, 0x76, 0x65, 0x12, 0x10, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x46, 0x75, 0x6c, 0x6c, 0x55, 0x52, 0x4c, 0x1a,
+	0x11, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x55,
+	0x52, 0x4c, 0x22, 0x00, 0x12, 0x31, 0x0a, 0x08, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x12, 0x11, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74,
+	0x55, 0x52, 0x4c, 0x1a, 0x10, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x46, 0x75,
+	0x6c, 0x6c, 0x55, 0x52, 0x4c, 0x22, 0x00, 0x12, 0x34, 0x0a, 0x07, 0x49, 0x6e, 0x73, 0x70, 0x65,
+	0x63, 0x74, 0x12, 0x11, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x68, 0x6f,
+	0x72, 0x74, 0x55, 0x52, 0x4c, 0x1a, 0x14, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x4c, 0x69, 0x6e, 0x6b, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x22, 0x00, 0x12, 0x38, 0x0a,
+	0x06, 0x51, 0x52, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2e, 0x51, 0x52, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x14, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x51, 0x52, 0x43, 0x6f, 0x64, 0x65,
+	0x49, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x00, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -145,21 +1080,53 @@ func file_service_proto_rawDescGZIP() []byte {
 	return file_service_proto_rawDescData
 }
 
-var file_service_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_service_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_service_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_service_proto_goTypes = []interface{}{
-	(*FullURL)(nil),  // 0: service.FullURL
-	(*ShortURL)(nil), // 1: service.ShortURL
+	(RedirectType)(0),             // 0: service.RedirectType
+	(QueryMerge)(0),               // 1: service.QueryMerge
+	(Sticky)(0),                   // 2: service.Sticky
+	(Safety)(0),                   // 3: service.Safety
+	(QRFormat)(0),                 // 4: service.QRFormat
+	(QRLevel)(0),                  // 5: service.QRLevel
+	(*FullURL)(nil),               // 6: service.FullURL
+	(*Variant)(nil),               // 7: service.Variant
+	(*Targets)(nil),               // 8: service.Targets
+	(*ShortURL)(nil),              // 9: service.ShortURL
+	(*LinkPreview)(nil),           // 10: service.LinkPreview
+	(*QRCodeRequest)(nil),         // 11: service.QRCodeRequest
+	(*QRCodeImage)(nil),           // 12: service.QRCodeImage
+	nil,                           // 13: service.FullURL.CountriesEntry
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
 }
 var file_service_proto_depIdxs = []int32{
-	0, // 0: service.URLShortener.httpSave:input_type -> service.FullURL
-	1, // 1: service.URLShortener.Redirect:input_type -> service.ShortURL
-	1, // 2: service.URLShortener.httpSave:output_type -> service.ShortURL
-	0, // 3: service.URLShortener.Redirect:output_type -> service.FullURL
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: service.FullURL.redirect:type_name -> service.RedirectType
+	1,  // 1: service.FullURL.query:type_name -> service.QueryMerge
+	14, // 2: service.FullURL.not_before:type_name -> google.protobuf.Timestamp
+	14, // 3: service.FullURL.not_after:type_name -> google.protobuf.Timestamp
+	8,  // 4: service.FullURL.targets:type_name -> service.Targets
+	13, // 5: service.FullURL.countries:type_name -> service.FullURL.CountriesEntry
+	7,  // 6: service.FullURL.variants:type_name -> service.Variant
+	2,  // 7: service.FullURL.sticky:type_name -> service.Sticky
+	14, // 8: service.LinkPreview.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 9: service.LinkPreview.safety:type_name -> service.Safety
+	14, // 10: service.LinkPreview.not_before:type_name -> google.protobuf.Timestamp
+	14, // 11: service.LinkPreview.not_after:type_name -> google.protobuf.Timestamp
+	4,  // 12: service.QRCodeRequest.format:type_name -> service.QRFormat
+	5,  // 13: service.QRCodeRequest.level:type_name -> service.QRLevel
+	6,  // 14: service.URLShortener.Save:input_type -> service.FullURL
+	9,  // 15: service.URLShortener.Redirect:input_type -> service.ShortURL
+	9,  // 16: service.URLShortener.Inspect:input_type -> service.ShortURL
+	11, // 17: service.URLShortener.QRCode:input_type -> service.QRCodeRequest
+	9,  // 18: service.URLShortener.Save:output_type -> service.ShortURL
+	6,  // 19: service.URLShortener.Redirect:output_type -> service.FullURL
+	10, // 20: service.URLShortener.Inspect:output_type -> service.LinkPreview
+	12, // 21: service.URLShortener.QRCode:output_type -> service.QRCodeImage
+	18, // [18:22] is the sub-list for method output_type
+	14, // [14:18] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_service_proto_init() }
@@ -181,6 +1148,30 @@ func file_service_proto_init() {
 			}
 		}
 		file_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Variant); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Targets); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ShortURL); i {
 			case 0:
 				return &v.state
@@ -192,19 +1183,57 @@ func file_service_proto_init() {
 				return nil
 			}
 		}
+		file_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LinkPreview); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QRCodeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QRCodeImage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
+	file_service_proto_msgTypes[5].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_service_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_service_proto_goTypes,
 		DependencyIndexes: file_service_proto_depIdxs,
+		EnumInfos:         file_service_proto_enumTypes,
 		MessageInfos:      file_service_proto_msgTypes,
 	}.Build()
 	File_service_proto = out.File
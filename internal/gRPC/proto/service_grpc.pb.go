@@ -20,6 +20,10 @@ const _ = grpc.SupportPackageIsVersion7
 type URLShortenerClient interface {
 	Save(ctx context.Context, in *FullURL, opts ...grpc.CallOption) (*ShortURL, error)
 	Redirect(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*FullURL, error)
+	// Inspect describes the link without following it.
+	Inspect(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*LinkPreview, error)
+	// QRCode renders the short URL, the server needs publicURL in its config for it.
+	QRCode(ctx context.Context, in *QRCodeRequest, opts ...grpc.CallOption) (*QRCodeImage, error)
 }
 
 type uRLShortenerClient struct {
@@ -32,7 +36,7 @@ func NewURLShortenerClient(cc grpc.ClientConnInterface) URLShortenerClient {
 
 func (c *uRLShortenerClient) Save(ctx context.Context, in *FullURL, opts ...grpc.CallOption) (*ShortURL, error) {
 	out := new(ShortURL)
-	err := c.cc.Invoke(ctx, "/service.URLShortener/httpSave", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/service.URLShortener/Save", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,12 +52,34 @@ func (c *uRLShortenerClient) Redirect(ctx context.Context, in *ShortURL, opts ..
 	return out, nil
 }
 
-// URLShortenerServer is the gRPCServer API for URLShortener service.
+func (c *uRLShortenerClient) Inspect(ctx context.Context, in *ShortURL, opts ...grpc.CallOption) (*LinkPreview, error) {
+	out := new(LinkPreview)
+	err := c.cc.Invoke(ctx, "/service.URLShortener/Inspect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) QRCode(ctx context.Context, in *QRCodeRequest, opts ...grpc.CallOption) (*QRCodeImage, error) {
+	out := new(QRCodeImage)
+	err := c.cc.Invoke(ctx, "/service.URLShortener/QRCode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// URLShortenerServer is the server API for URLShortener service.
 // All implementations must embed UnimplementedURLShortenerServer
 // for forward compatibility
 type URLShortenerServer interface {
 	Save(context.Context, *FullURL) (*ShortURL, error)
 	Redirect(context.Context, *ShortURL) (*FullURL, error)
+	// Inspect describes the link without following it.
+	Inspect(context.Context, *ShortURL) (*LinkPreview, error)
+	// QRCode renders the short URL, the server needs publicURL in its config for it.
+	QRCode(context.Context, *QRCodeRequest) (*QRCodeImage, error)
 	mustEmbedUnimplementedURLShortenerServer()
 }
 
@@ -62,11 +88,17 @@ type UnimplementedURLShortenerServer struct {
 }
 
 func (UnimplementedURLShortenerServer) Save(context.Context, *FullURL) (*ShortURL, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method httpSave not implemented")
+	return nil, status.Errorf(codes.Unimplemented, "method Save not implemented")
 }
 func (UnimplementedURLShortenerServer) Redirect(context.Context, *ShortURL) (*FullURL, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Redirect not implemented")
 }
+func (UnimplementedURLShortenerServer) Inspect(context.Context, *ShortURL) (*LinkPreview, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Inspect not implemented")
+}
+func (UnimplementedURLShortenerServer) QRCode(context.Context, *QRCodeRequest) (*QRCodeImage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QRCode not implemented")
+}
 func (UnimplementedURLShortenerServer) mustEmbedUnimplementedURLShortenerServer() {}
 
 // UnsafeURLShortenerServer may be embedded to opt out of forward compatibility for this service.
@@ -90,7 +122,7 @@ func _URLShortener_Save_Handler(srv interface{}, ctx context.Context, dec func(i
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/service.URLShortener/httpSave",
+		FullMethod: "/service.URLShortener/Save",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(URLShortenerServer).Save(ctx, req.(*FullURL))
@@ -116,6 +148,42 @@ func _URLShortener_Redirect_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortener_Inspect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).Inspect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.URLShortener/Inspect",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).Inspect(ctx, req.(*ShortURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_QRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QRCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).QRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.URLShortener/QRCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).QRCode(ctx, req.(*QRCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // URLShortener_ServiceDesc is the grpc.ServiceDesc for URLShortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -124,13 +192,21 @@ var URLShortener_ServiceDesc = grpc.ServiceDesc{
 	HandlerType: (*URLShortenerServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "httpSave",
+			MethodName: "Save",
 			Handler:    _URLShortener_Save_Handler,
 		},
 		{
 			MethodName: "Redirect",
 			Handler:    _URLShortener_Redirect_Handler,
 		},
+		{
+			MethodName: "Inspect",
+			Handler:    _URLShortener_Inspect_Handler,
+		},
+		{
+			MethodName: "QRCode",
+			Handler:    _URLShortener_QRCode_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
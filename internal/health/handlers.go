@@ -0,0 +1,49 @@
+package health
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"urlShortener/internal/http/httpUtils"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// LivenessHandler answers while the process is able to serve HTTP at all.
+func (c *Checker) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = httpUtils.RenderJSON(w, Response{Status: statusOK}, http.StatusOK)
+	}
+}
+
+// ReadinessHandler answers 503 if any readiness check fails. The body only tells which checks failed,
+// their errors may name hosts of the storage and are logged instead.
+func (c *Checker) ReadinessHandler(logger *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := Response{Status: statusOK, Checks: make(map[string]string)}
+		statusCode := http.StatusOK
+
+		for name, err := range c.Ready(r.Context()) {
+			if err != nil {
+				if !errors.Is(err, ErrShuttingDown) {
+					logger.WithContext(r.Context()).WithError(err).WithField("check", name).Warn("readiness check failed")
+				}
+				resp.Checks[name] = statusFail
+				resp.Status = statusFail
+				statusCode = http.StatusServiceUnavailable
+			} else {
+				resp.Checks[name] = statusOK
+			}
+		}
+
+		_ = httpUtils.RenderJSON(w, resp, statusCode)
+	}
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"urlShortener/internal/config"
+	"urlShortener/internal/storage"
+)
+
+const (
+	checkStorage  = "storage"
+	checkHasher   = "hasher"
+	checkShutdown = "shutdown"
+)
+
+var (
+	ErrShuttingDown     = errors.New("shutdown has started")
+	ErrLowHashHeadroom  = errors.New("hasher is running out of IDs")
+	ErrStorageUnhealthy = errors.New("storage is unreachable")
+)
+
+// HashCounter is implemented by hashers that generate short URLs from a growing ID.
+type HashCounter interface {
+	CurrentID() uint64
+	MaxID() uint64
+}
+
+// Checker decides whether the instance may receive traffic.
+type Checker struct {
+	pinger       storage.Pinger
+	hashCounter  HashCounter
+	cfg          atomic.Pointer[config.HealthConfig]
+	shuttingDown atomic.Bool
+
+	mu         sync.Mutex
+	onShutdown []func()
+}
+
+// New creates a checker. pinger and hashCounter may be nil, then the check is skipped.
+func New(pinger storage.Pinger, hashCounter HashCounter, cfg config.HealthConfig) *Checker {
+	checker := &Checker{
+		pinger:      pinger,
+		hashCounter: hashCounter,
+	}
+	checker.SetConfig(cfg)
+	return checker
+}
+
+// SetConfig replaces the thresholds of a running checker.
+func (c *Checker) SetConfig(cfg config.HealthConfig) {
+	c.cfg.Store(&cfg)
+}
+
+// OnShutdown registers fn to run when the shutdown starts, like the gRPC health service that must stop
+// serving together with the readiness probe.
+func (c *Checker) OnShutdown(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onShutdown = append(c.onShutdown, fn)
+}
+
+// StartShutdown makes the instance not ready, so load balancers stop sending new requests.
+func (c *Checker) StartShutdown() {
+	if c.shuttingDown.Swap(true) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fn := range c.onShutdown {
+		fn()
+	}
+}
+
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Ready runs all readiness checks and returns the error of every failed check by its name.
+func (c *Checker) Ready(ctx context.Context) map[string]error {
+	results := map[string]error{
+		checkShutdown: nil,
+		checkStorage:  nil,
+		checkHasher:   nil,
+	}
+
+	if c.ShuttingDown() {
+		results[checkShutdown] = ErrShuttingDown
+	}
+
+	cfg := c.cfg.Load()
+	if c.pinger != nil {
+		if cfg.CheckTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.CheckTimeout)
+			defer cancel()
+		}
+		if err := c.pinger.Ping(ctx); err != nil {
+			results[checkStorage] = fmt.Errorf("%w: %w", ErrStorageUnhealthy, err)
+		}
+	}
+
+	if c.hashCounter != nil {
+		current, max := c.hashCounter.CurrentID(), c.hashCounter.MaxID()
+		if current > max || max-current < cfg.MinIDHeadroom {
+			results[checkHasher] = ErrLowHashHeadroom
+		}
+	}
+
+	return results
+}
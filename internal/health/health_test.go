@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"urlShortener/internal/config"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (f fakePinger) Ping(ctx context.Context) error { return f.err }
+
+type fakeHashCounter struct {
+	current, max uint64
+}
+
+func (f fakeHashCounter) CurrentID() uint64 { return f.current }
+func (f fakeHashCounter) MaxID() uint64     { return f.max }
+
+var testCfg = config.HealthConfig{MinIDHeadroom: 10}
+
+func failed(results map[string]error) []string {
+	names := make([]string, 0)
+	for name, err := range results {
+		if err != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestReadyAllOK(t *testing.T) {
+	checker := New(fakePinger{}, fakeHashCounter{current: 0, max: 100}, testCfg)
+	assert.Empty(t, failed(checker.Ready(context.Background())))
+}
+
+func TestReadyWithoutOptionalChecks(t *testing.T) {
+	checker := New(nil, nil, testCfg)
+	assert.Empty(t, failed(checker.Ready(context.Background())))
+}
+
+func TestReadyStorageDown(t *testing.T) {
+	checker := New(fakePinger{err: errors.New("connection refused")}, fakeHashCounter{max: 100}, testCfg)
+
+	results := checker.Ready(context.Background())
+	assert.Equal(t, []string{checkStorage}, failed(results))
+	assert.True(t, errors.Is(results[checkStorage], ErrStorageUnhealthy))
+}
+
+func TestReadyLowHeadroom(t *testing.T) {
+	checker := New(fakePinger{}, fakeHashCounter{current: 95, max: 100}, testCfg)
+
+	results := checker.Ready(context.Background())
+	assert.True(t, errors.Is(results[checkHasher], ErrLowHashHeadroom))
+}
+
+func TestReadyAfterSetConfig(t *testing.T) {
+	checker := New(fakePinger{}, fakeHashCounter{current: 95, max: 100}, testCfg)
+	checker.SetConfig(config.HealthConfig{MinIDHeadroom: 5})
+	assert.Empty(t, failed(checker.Ready(context.Background())))
+}
+
+func TestReadyShuttingDown(t *testing.T) {
+	checker := New(fakePinger{}, fakeHashCounter{max: 100}, testCfg)
+	checker.StartShutdown()
+
+	results := checker.Ready(context.Background())
+	assert.True(t, errors.Is(results[checkShutdown], ErrShuttingDown))
+}
+
+func TestReadinessHandler(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	checker := New(fakePinger{}, fakeHashCounter{max: 100}, testCfg)
+
+	w := httptest.NewRecorder()
+	checker.ReadinessHandler(logger)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	checker.StartShutdown()
+	w = httptest.NewRecorder()
+	checker.ReadinessHandler(logger)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var resp Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, statusFail, resp.Status)
+	assert.Equal(t, statusOK, resp.Checks[checkStorage])
+	assert.Equal(t, statusFail, resp.Checks[checkShutdown])
+}
+
+func TestReadinessHandlerHidesErrors(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	checker := New(fakePinger{err: errors.New("dial tcp 10.1.2.3:5432: connection refused")}, nil, testCfg)
+
+	w := httptest.NewRecorder()
+	checker.ReadinessHandler(logger)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "10.1.2.3")
+
+	var resp Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, statusFail, resp.Checks[checkStorage])
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Contains(t, hook.LastEntry().Data[logrus.ErrorKey].(error).Error(), "10.1.2.3")
+	}
+}
+
+func TestStartShutdownHooks(t *testing.T) {
+	checker := New(nil, nil, testCfg)
+	calls := 0
+	checker.OnShutdown(func() { calls++ })
+
+	checker.StartShutdown()
+	checker.StartShutdown()
+	assert.Equal(t, 1, calls)
+}
+
+func TestLivenessHandlerDuringShutdown(t *testing.T) {
+	checker := New(fakePinger{err: errors.New("down")}, nil, testCfg)
+	checker.StartShutdown()
+
+	w := httptest.NewRecorder()
+	checker.LivenessHandler()(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -7,34 +7,60 @@ import (
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 	"urlShortener/internal/config"
 )
 
 type Server struct {
-	srv    *http.Server
-	ctx    context.Context
-	logger *logrus.Logger
+	srv     *http.Server
+	ctx     context.Context
+	logger  *logrus.Logger
+	timeout atomic.Int64
 }
 
 type ServerOption func(*Server)
 
 func New(ctx context.Context, cfg config.HTTPServerConfig, router *mux.Router, logger *logrus.Logger) *Server {
 	server := &Server{
-		srv: &http.Server{
-			Addr:         cfg.Address,
-			Handler:      router,
-			ReadTimeout:  cfg.Timeout,
-			WriteTimeout: cfg.Timeout,
-			IdleTimeout:  cfg.IdleTimeout,
-		},
 		ctx:    ctx,
 		logger: logger,
 	}
+	server.SetTimeout(cfg.Timeout)
+
+	// таймауты сервера действуют до вызова обработчика, дальше их заменяют дедлайны из withDeadlines
+	server.srv = &http.Server{
+		Addr:         cfg.Address,
+		Handler:      server.withDeadlines(router),
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
 
 	return server
 }
 
+// SetTimeout changes the read and write timeout of the requests that start after the call.
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.timeout.Store(int64(timeout))
+}
+
+func (s *Server) withDeadlines(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if timeout := time.Duration(s.timeout.Load()); timeout > 0 {
+			deadline := time.Now().Add(timeout)
+			rc := http.NewResponseController(w)
+			if err := rc.SetReadDeadline(deadline); err != nil {
+				s.logger.WithError(err).Debug("can't set read deadline")
+			}
+			if err := rc.SetWriteDeadline(deadline); err != nil {
+				s.logger.WithError(err).Debug("can't set write deadline")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) Run() {
 
 	wg := sync.WaitGroup{}
@@ -58,7 +84,7 @@ func (s *Server) Run() {
 
 	err := s.srv.Shutdown(ctx)
 	if err != nil {
-		s.logger.Error("can't stop http Server: ", err.Error())
+		s.logger.WithError(err).Error("can't stop http Server")
 	}
 
 	wg.Wait()
@@ -0,0 +1,86 @@
+package httpUtils
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ForwardedForHeader lists the addresses a request was forwarded from, every proxy appends its peer.
+const ForwardedForHeader = "X-Forwarded-For"
+
+var ErrInvalidProxy = errors.New("trusted proxy must be an IP address or a network in CIDR notation")
+
+// Proxies are the reverse proxies trusted to tell the address of the client in X-Forwarded-For.
+type Proxies []netip.Prefix
+
+// ParseProxies reads the trusted proxies, a single address stands for the network of itself.
+func ParseProxies(proxies []string) (Proxies, error) {
+	result := make(Proxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, errors.Join(ErrInvalidProxy, err)
+			}
+			result = append(result, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidProxy, err)
+		}
+		result = append(result, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return result, nil
+}
+
+// ClientIP returns the address of the client. X-Forwarded-For is read from the right while the hops are
+// trusted: the first untrusted address is the client, so a client can't forge it by sending the header
+// itself. Without trusted proxies it is the peer, the zero Addr means the address is not known.
+func (p Proxies) ClientIP(r *http.Request) netip.Addr {
+	client := parseHop(r.RemoteAddr)
+	if !p.trusted(client) {
+		return client
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values(ForwardedForHeader), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseHop(hops[i])
+		if !hop.IsValid() {
+			// испорченный заголовок: дальше доверенного прокси не идем
+			return client
+		}
+		client = hop
+		if !p.trusted(client) {
+			break
+		}
+	}
+	return client
+}
+
+func (p Proxies) trusted(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHop reads an address with or without a port, IPv4 mapped to IPv6 becomes IPv4.
+func parseHop(hop string) netip.Addr {
+	hop = strings.TrimSpace(hop)
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	addr, err := netip.ParseAddr(hop)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
@@ -0,0 +1,47 @@
+package httpUtils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseProxies([]string{"10.0.0.0/8", "2001:db8::1"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		proxies   Proxies
+		remote    string
+		forwarded []string
+		client    string
+	}{
+		{"no proxies", nil, "192.0.2.1:1234", []string{"198.51.100.1"}, "192.0.2.1"},
+		{"untrusted peer", proxies, "192.0.2.1:1234", []string{"198.51.100.1"}, "192.0.2.1"},
+		{"trusted peer", proxies, "10.0.0.2:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"chain of proxies", proxies, "[2001:db8::1]:443", []string{"203.0.113.9, 198.51.100.1, 10.1.1.1"}, "198.51.100.1"},
+		{"several headers", proxies, "10.0.0.2:1234", []string{"203.0.113.9", "198.51.100.1:5555"}, "198.51.100.1"},
+		{"only proxies", proxies, "10.0.0.2:1234", []string{"10.0.0.3"}, "10.0.0.3"},
+		{"no header", proxies, "10.0.0.2:1234", nil, "10.0.0.2"},
+		{"invalid hop", proxies, "10.0.0.2:1234", []string{"198.51.100.1, unknown"}, "10.0.0.2"},
+		{"mapped IPv4", proxies, "[::ffff:10.0.0.2]:1234", []string{"::ffff:198.51.100.1"}, "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				r.Header.Add(ForwardedForHeader, value)
+			}
+			assert.Equal(t, netip.MustParseAddr(tt.client), tt.proxies.ClientIP(r))
+		})
+	}
+}
+
+func TestParseProxiesInvalid(t *testing.T) {
+	_, err := ParseProxies([]string{"10.0.0.0/8", "proxy.local"})
+	assert.ErrorIs(t, err, ErrInvalidProxy)
+}
@@ -0,0 +1,53 @@
+package httpUtils
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PrefersHTML reports whether the Accept header ranks text/html above application/json.
+// Clients that accept both equally, like curl with */*, get JSON.
+func PrefersHTML(r *http.Request) bool {
+	return acceptQuality(r, "text/html") > acceptQuality(r, "application/json")
+}
+
+// acceptQuality returns the q of the most specific Accept range matching mediaType.
+func acceptQuality(r *http.Request, mediaType string) float64 {
+	quality, specificity := 0.0, -1
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			s := matchSpecificity(accepted, mediaType)
+			if s <= specificity {
+				continue
+			}
+			q := 1.0
+			if value, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(value, 64); err != nil {
+					continue
+				}
+			}
+			quality, specificity = q, s
+		}
+	}
+	return quality
+}
+
+// matchSpecificity is 2 for an exact match, 1 for type/* and 0 for */*, -1 if the range doesn't match.
+func matchSpecificity(accepted string, mediaType string) int {
+	switch {
+	case accepted == mediaType:
+		return 2
+	case accepted == "*/*":
+		return 0
+	case strings.HasSuffix(accepted, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(accepted, "*")):
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,33 @@
+package httpUtils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrefersHTML(t *testing.T) {
+	tests := []struct {
+		accept string
+		html   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", true},
+		{"text/html;q=0.5, application/json", false},
+		{"text/*, application/json;q=0.1", true},
+		{"application/json, text/html;q=bad", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			assert.Equal(t, tt.html, PrefersHTML(r))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package httpUtils
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/utils/e"
+)
+
+const problemTypePrefix = "urn:urlShortener:problem:"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Code          string         `json:"code"`
+	Retryable     bool           `json:"retryable"`
+	RetryAt       *time.Time     `json:"retryAt,omitempty"`
+	InvalidParams []InvalidParam `json:"invalidParams,omitempty"`
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+var httpStatuses = map[domainError.Code]int{
+	domainError.CodeInvalidArgument:  http.StatusBadRequest,
+	domainError.CodeURLNotFound:      http.StatusNotFound,
+	domainError.CodeURLConflict:      http.StatusConflict,
+	domainError.CodeLinkExists:       http.StatusConflict,
+	domainError.CodeURLDisabled:      http.StatusGone,
+	domainError.CodePasswordRequired: http.StatusUnauthorized,
+	domainError.CodeTooManyAttempts:  http.StatusTooManyRequests,
+	domainError.CodeClicksExhausted:  http.StatusGone,
+	domainError.CodeLinkNotActive:    http.StatusForbidden,
+	domainError.CodeLinkExpired:      http.StatusGone,
+	domainError.CodeIDSpaceExhausted: http.StatusInsufficientStorage,
+	domainError.CodeUnavailable:      http.StatusServiceUnavailable,
+	domainError.CodeNotSupported:     http.StatusNotImplemented,
+	domainError.CodeInternal:         http.StatusInternalServerError,
+}
+
+// NewProblem translates a domain error to problem details. Errors that are not domain errors
+// become 500, the internal cause is never sent to the client.
+func NewProblem(err error) Problem {
+	domainErr := domainError.From(err)
+
+	statusCode, ok := httpStatuses[domainErr.Code]
+	if !ok {
+		statusCode = http.StatusInternalServerError
+	}
+
+	problem := Problem{
+		Type:      problemTypePrefix + string(domainErr.Code),
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    domainErr.Message,
+		Code:      string(domainErr.Code),
+		Retryable: domainErr.Retryable,
+	}
+	if !domainErr.RetryAt.IsZero() {
+		retryAt := domainErr.RetryAt.UTC()
+		problem.RetryAt = &retryAt
+	}
+	for _, field := range domainErr.Fields() {
+		problem.InvalidParams = append(problem.InvalidParams, InvalidParam{
+			Name:   field,
+			Reason: domainErr.Details[field],
+		})
+	}
+
+	return problem
+}
+
+func RenderProblem(w http.ResponseWriter, err error) error {
+	const fn = "http.httpUtils.RenderProblem"
+
+	problem := NewProblem(err)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	if problem.RetryAt != nil {
+		w.Header().Set("Retry-After", problem.RetryAt.Format(http.TimeFormat))
+	} else if problem.Retryable {
+		w.Header().Set("Retry-After", "1")
+	}
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
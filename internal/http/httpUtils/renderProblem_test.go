@@ -0,0 +1,76 @@
+package httpUtils
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+)
+
+func TestRenderProblemInvalidArgument(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := RenderProblem(w, domainError.InvalidArgument("URL", "URL must be an absolute URL"))
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, string(domainError.CodeInvalidArgument), problem.Code)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, []InvalidParam{{Name: "URL", Reason: "URL must be an absolute URL"}}, problem.InvalidParams)
+}
+
+func TestRenderProblemHidesInternalCause(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := RenderProblem(w, errors.New("pq: password authentication failed"))
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, string(domainError.CodeInternal), problem.Code)
+	assert.NotContains(t, problem.Detail, "pq:")
+}
+
+func TestRenderProblemRetryable(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := RenderProblem(w, domainError.URLConflict(errors.New("exists")))
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.True(t, problem.Retryable)
+}
+
+func TestRenderProblemRetryAt(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	notBefore := time.Date(2024, 6, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	err := RenderProblem(w, domainError.LinkNotActive(notBefore, errors.New("not yet")))
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "Sat, 01 Jun 2024 09:00:00 GMT", resp.Header.Get("Retry-After"))
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, string(domainError.CodeLinkNotActive), problem.Code)
+	assert.True(t, notBefore.Equal(*problem.RetryAt))
+}
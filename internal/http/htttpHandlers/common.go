@@ -1,3 +1,6 @@
 package htttpHandlers
 
 const ShortenURLQuery = "shortenURL"
+
+// PathSuffixQuery is the route variable with the path after the code.
+const PathSuffixQuery = "suffix"
@@ -0,0 +1,114 @@
+package httpPreview
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"html/template"
+	"net/http"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
+	"urlShortener/internal/http/htttpHandlers"
+	"urlShortener/internal/service"
+)
+
+var errNoShortenURL = errors.New("shorten URL route variable is missing")
+
+// Response is the preview for API clients.
+type Response struct {
+	Code      string     `json:"code"`
+	FullURL   string     `json:"URL,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Safety    string     `json:"safety"`
+	Protected bool       `json:"protected,omitempty"`
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+}
+
+type Inspector interface {
+	Inspect(ctx context.Context, shortenURL string) (service.Preview, error)
+}
+
+var page = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Preview of {{.Code}}</title>
+</head>
+<body>
+{{if .FullURL}}<p>This link goes to</p>
+<p><a href="{{.FullURL}}" rel="nofollow noopener noreferrer">{{.FullURL}}</a></p>
+{{if eq .Safety "insecure"}}<p>The connection to this site is not encrypted.</p>
+{{end}}<p>Created {{.CreatedAt.UTC.Format "2006-01-02 15:04 MST"}}</p>
+{{else if eq .Safety "disabled"}}<p>This link has been disabled.</p>
+{{else if .Protected}}<p>This link is protected with a password.</p>
+<p>Created {{.CreatedAt.UTC.Format "2006-01-02 15:04 MST"}}</p>
+{{else}}<p>This link is not available now.</p>
+{{end}}{{if not .NotBefore.IsZero}}<p>Opens {{.NotBefore.UTC.Format "2006-01-02 15:04 MST"}}</p>
+{{end}}{{if not .NotAfter.IsZero}}<p>Closes {{.NotAfter.UTC.Format "2006-01-02 15:04 MST"}}</p>
+{{end}}</body>
+</html>
+`))
+
+// New shows where the link goes instead of redirecting: an HTML page for browsers and JSON for the rest.
+func New(logger *logrus.Logger, inspector Inspector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "httpHandlers.httpPreview.New"
+
+		logger := logger.WithContext(r.Context()).WithField("handler", fn)
+
+		shortenURL, ok := mux.Vars(r)[htttpHandlers.ShortenURLQuery]
+		if !ok {
+			logger.Error(errNoShortenURL)
+			err := httpUtils.RenderProblem(w, domainError.Internal(errNoShortenURL))
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
+			}
+			return
+		}
+
+		preview, err := inspector.Inspect(r.Context(), shortenURL)
+		if err != nil {
+			logger.WithError(err).Info("can't inspect link")
+			err = httpUtils.RenderProblem(w, err)
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
+			}
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Add("Vary", "Accept")
+		if httpUtils.PrefersHTML(r) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			err = page.Execute(w, preview)
+		} else {
+			err = httpUtils.RenderJSON(w, toResponse(preview), http.StatusOK)
+		}
+		if err != nil {
+			logger.WithError(err).Error("rendering error")
+		}
+	}
+}
+
+func toResponse(preview service.Preview) Response {
+	resp := Response{
+		Code:      preview.Code,
+		FullURL:   preview.FullURL,
+		Safety:    string(preview.Safety),
+		Protected: preview.Protected,
+	}
+	if !preview.CreatedAt.IsZero() {
+		resp.CreatedAt = &preview.CreatedAt
+	}
+	if !preview.NotBefore.IsZero() {
+		resp.NotBefore = &preview.NotBefore
+	}
+	if !preview.NotAfter.IsZero() {
+		resp.NotAfter = &preview.NotAfter
+	}
+	return resp
+}
@@ -0,0 +1,93 @@
+package httpPreview
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/htttpHandlers"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+)
+
+type mockInspector struct {
+	mock.Mock
+}
+
+func (m *mockInspector) Inspect(ctx context.Context, shortenURL string) (service.Preview, error) {
+	args := m.Called(shortenURL)
+	return args.Get(0).(service.Preview), args.Error(1)
+}
+
+func newRequest(code string, accept string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/"+code+"+", nil)
+	req.Header.Set("Accept", accept)
+	return mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: code})
+}
+
+func TestNewDisabled(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	inspector := &mockInspector{}
+	handler := New(logger, inspector)
+
+	inspector.On("Inspect", "disabled").Return(service.Preview{Code: "disabled", Safety: service.SafetyDisabled}, nil)
+
+	w := httptest.NewRecorder()
+	handler(w, newRequest("disabled", "application/json"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":"disabled","safety":"disabled"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler(w, newRequest("disabled", "text/html"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "This link has been disabled.")
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	inspector.AssertExpectations(t)
+}
+
+func TestNewNotFound(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	inspector := &mockInspector{}
+	handler := New(logger, inspector)
+
+	inspector.On("Inspect", "unknown").Return(service.Preview{}, domainError.URLNotFound(storage.ErrURLNotFound))
+
+	w := httptest.NewRecorder()
+	handler(w, newRequest("unknown", "text/html"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var problem map[string]any
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, string(domainError.CodeURLNotFound), problem["code"])
+
+	inspector.AssertExpectations(t)
+}
+
+func TestNewNotActive(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	inspector := &mockInspector{}
+	handler := New(logger, inspector)
+
+	launch := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	inspector.On("Inspect", "soon").Return(service.Preview{Code: "soon", Safety: service.SafetyOK, NotBefore: launch}, nil)
+
+	w := httptest.NewRecorder()
+	handler(w, newRequest("soon", "application/json"))
+	assert.JSONEq(t, `{"code":"soon","safety":"ok","notBefore":"2024-06-01T09:00:00Z"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler(w, newRequest("soon", "text/html"))
+	assert.Contains(t, w.Body.String(), "This link is not available now.")
+	assert.Contains(t, w.Body.String(), "Opens 2024-06-01 09:00 UTC")
+}
@@ -0,0 +1,134 @@
+package httpQR
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
+	"urlShortener/internal/http/htttpHandlers"
+	"urlShortener/internal/lib/qrCode"
+)
+
+// Query parameters of the image.
+const (
+	formatParam = "format"
+	sizeParam   = "size"
+	levelParam  = "level"
+	marginParam = "margin"
+)
+
+// Cache-Control картинки. Ссылку могут удалить или отключить, поэтому кэш живет час, а не вечно.
+// Адрес из Host присылает клиент: такую картинку общим кэшам не отдаем, иначе один запрос с чужим
+// Host отравит кэш для всех.
+const (
+	publicCacheControl  = "public, max-age=3600"
+	requestCacheControl = "private, no-cache"
+)
+
+var errNoShortenURL = errors.New("shorten URL route variable is missing")
+
+type Renderer interface {
+	QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error)
+}
+
+// New renders the QR code of the short URL. publicURL is the base of the short URL, the host of the
+// request is used if it's empty and then shared caches don't keep the image.
+func New(logger *logrus.Logger, renderer Renderer, publicURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "httpHandlers.httpQR.New"
+
+		logger := logger.WithContext(r.Context()).WithField("handler", fn)
+
+		shortenURL, ok := mux.Vars(r)[htttpHandlers.ShortenURLQuery]
+		if !ok {
+			logger.Error(errNoShortenURL)
+			err := httpUtils.RenderProblem(w, domainError.Internal(errNoShortenURL))
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
+			}
+			return
+		}
+
+		opts, err := parseOptions(r.URL.Query())
+		if err != nil {
+			logger.WithError(err).Info("wrong QR code parameters")
+			err = httpUtils.RenderProblem(w, err)
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
+			}
+			return
+		}
+
+		base, cacheControl := publicURL, publicCacheControl
+		if base == "" {
+			base, cacheControl = requestBase(r), requestCacheControl
+		}
+		image, err := renderer.QRCode(r.Context(), base, shortenURL, opts)
+		if err != nil {
+			logger.WithError(err).Info("can't render QR code")
+			err = httpUtils.RenderProblem(w, err)
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
+			}
+			return
+		}
+
+		sum := sha256.Sum256(image)
+		w.Header().Set("Content-Type", opts.Format.ContentType())
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		// ServeContent отвечает 304 на If-None-Match
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(image))
+	}
+}
+
+func parseOptions(query url.Values) (qrCode.Options, error) {
+	opts := qrCode.DefaultOptions()
+	if format := query.Get(formatParam); format != "" {
+		opts.Format = qrCode.Format(strings.ToLower(format))
+	}
+	if level := query.Get(levelParam); level != "" {
+		opts.Level = qrCode.Level(strings.ToUpper(level))
+	}
+	// порядок фиксирован, чтобы при двух ошибках ответ всегда называл size
+	if err := parseInt(query, sizeParam, &opts.Size); err != nil {
+		return qrCode.Options{}, err
+	}
+	if err := parseInt(query, marginParam, &opts.Margin); err != nil {
+		return qrCode.Options{}, err
+	}
+	return opts, nil
+}
+
+// parseInt sets value from the query parameter if it's there.
+func parseInt(query url.Values, param string, value *int) error {
+	raw := query.Get(param)
+	if raw == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return domainError.InvalidArgument(param, param+" must be a number")
+	}
+	*value = n
+	return nil
+}
+
+// requestBase - адрес, по которому пришел запрос; за прокси с TLS нужен publicURL.
+func requestBase(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
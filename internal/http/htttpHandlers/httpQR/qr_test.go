@@ -0,0 +1,92 @@
+package httpQR
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"urlShortener/internal/http/htttpHandlers"
+	"urlShortener/internal/lib/qrCode"
+)
+
+type mockRenderer struct {
+	mock.Mock
+}
+
+func (m *mockRenderer) QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error) {
+	args := m.Called(base, shortenURL, opts)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func newRequest(target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	return mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "aaaaaaaaaa"})
+}
+
+func TestNewBase(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	tests := []struct {
+		name         string
+		publicURL    string
+		base         string
+		cacheControl string
+	}{
+		// Host присылает клиент, общий кэш такую картинку хранить не должен
+		{"request host", "", "http://example.com", "private, no-cache"},
+		{"public URL", "https://sho.rt", "https://sho.rt", "public, max-age=3600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := &mockRenderer{}
+			handler := New(logger, renderer, tt.publicURL)
+
+			renderer.On("QRCode", tt.base, "aaaaaaaaaa", qrCode.DefaultOptions()).Return([]byte("png"), nil)
+
+			w := httptest.NewRecorder()
+			handler(w, newRequest("http://example.com/aaaaaaaaaa/qr"))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+			assert.Equal(t, "png", w.Body.String())
+			assert.Equal(t, tt.cacheControl, w.Header().Get("Cache-Control"))
+
+			renderer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	renderer := &mockRenderer{}
+	handler := New(logger, renderer, "https://sho.rt")
+
+	opts := qrCode.Options{Format: qrCode.SVG, Size: 128, Level: "Q", Margin: 0}
+	renderer.On("QRCode", "https://sho.rt", "aaaaaaaaaa", opts).Return([]byte("<svg/>"), nil)
+
+	w := httptest.NewRecorder()
+	handler(w, newRequest("/aaaaaaaaaa/qr?format=SVG&size=128&level=q&margin=0"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	handler(w, newRequest("/aaaaaaaaaa/qr?margin=-"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// при двух ошибках ответ каждый раз называет одно и то же поле
+	for i := 0; i < 10; i++ {
+		w = httptest.NewRecorder()
+		handler(w, newRequest("/aaaaaaaaaa/qr?size=big&margin=-"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"size"`)
+		assert.NotContains(t, w.Body.String(), `"margin"`)
+	}
+
+	renderer.AssertExpectations(t)
+}
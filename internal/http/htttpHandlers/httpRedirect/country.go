@@ -0,0 +1,40 @@
+package httpRedirect
+
+import (
+	"net/http"
+	"net/netip"
+	"urlShortener/internal/http/httpUtils"
+)
+
+// Locator finds the country of an address, an empty code means it's not known.
+type Locator interface {
+	Country(addr netip.Addr) string
+}
+
+// Visitors tell who requests come from. The zero value trusts no proxy and knows no countries.
+type Visitors struct {
+	// Proxies are trusted to pass the address of the client in X-Forwarded-For.
+	Proxies httpUtils.Proxies
+	// Countries locates clients for the country targets of links, nil leaves them unused.
+	Countries Locator
+}
+
+// clientAddr identifies the client whose wrong passwords are counted.
+func (v Visitors) clientAddr(r *http.Request) string {
+	if addr := v.Proxies.ClientIP(r); addr.IsValid() {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
+
+// country locates the client, only links with country targets need it.
+func (v Visitors) country(r *http.Request, countries bool) string {
+	if !countries || v.Countries == nil {
+		return ""
+	}
+	addr := v.Proxies.ClientIP(r)
+	if !addr.IsValid() {
+		return ""
+	}
+	return v.Countries.Country(addr)
+}
@@ -0,0 +1,59 @@
+package httpRedirect
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	"urlShortener/internal/storage"
+)
+
+var errNoPassthrough = errors.New("link doesn't pass the path through")
+
+// Destination returns the URL to redirect a request for the link to. The suffix is the path after
+// the code, query is the query of the request.
+func Destination(link storage.Link, suffix string, query url.Values) (string, error) {
+	if suffix != "" && !link.PathPassthrough {
+		return "", errNoPassthrough
+	}
+	if suffix == "" && (link.Query == storage.QueryDrop || len(query) == 0) {
+		return link.FullURL, nil
+	}
+
+	destination, err := url.Parse(link.FullURL)
+	if err != nil {
+		return "", err
+	}
+	if suffix != "" {
+		destination = destination.JoinPath(cleanSuffix(suffix))
+	}
+	if link.Query != storage.QueryDrop && len(query) > 0 {
+		destination.RawQuery = mergeQuery(destination.Query(), query, link.Query).Encode()
+	}
+	return destination.String(), nil
+}
+
+// cleanSuffix removes ../ from the suffix, so it can't leave the path of the link.
+func cleanSuffix(suffix string) string {
+	cleaned := strings.TrimPrefix(path.Clean("/"+suffix), "/")
+	if cleaned != "" && strings.HasSuffix(suffix, "/") {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+func mergeQuery(link url.Values, request url.Values, merge storage.QueryMerge) url.Values {
+	for name, values := range request {
+		switch merge {
+		case storage.QueryKeep:
+			if !link.Has(name) {
+				link[name] = values
+			}
+		case storage.QueryOverride:
+			link[name] = values
+		case storage.QueryAppend:
+			link[name] = append(link[name], values...)
+		}
+	}
+	return link
+}
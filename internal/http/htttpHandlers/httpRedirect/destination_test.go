@@ -0,0 +1,39 @@
+package httpRedirect
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"urlShortener/internal/storage"
+)
+
+func TestDestination(t *testing.T) {
+	query := url.Values{"utm_source": {"mail"}, "lang": {"ru"}}
+	tests := []struct {
+		name   string
+		url    string
+		opts   storage.Options
+		suffix string
+		query  url.Values
+		want   string
+		err    error
+	}{
+		{"no options", "https://ozon.ru/a?utm_source=site", storage.Options{}, "", query, "https://ozon.ru/a?utm_source=site", nil},
+		{"keep", "https://ozon.ru/a?utm_source=site", storage.Options{Query: storage.QueryKeep}, "", query, "https://ozon.ru/a?lang=ru&utm_source=site", nil},
+		{"override", "https://ozon.ru/a?utm_source=site", storage.Options{Query: storage.QueryOverride}, "", query, "https://ozon.ru/a?lang=ru&utm_source=mail", nil},
+		{"append", "https://ozon.ru/a?utm_source=site", storage.Options{Query: storage.QueryAppend}, "", query, "https://ozon.ru/a?lang=ru&utm_source=site&utm_source=mail", nil},
+		{"empty query", "https://ozon.ru/a?b=c%20d", storage.Options{Query: storage.QueryAppend}, "", nil, "https://ozon.ru/a?b=c%20d", nil},
+		{"suffix", "https://ozon.ru/docs/", storage.Options{PathPassthrough: true}, "guide/page/", nil, "https://ozon.ru/docs/guide/page/", nil},
+		{"suffix outside link path", "https://ozon.ru/docs", storage.Options{PathPassthrough: true}, "../../admin", nil, "https://ozon.ru/docs/admin", nil},
+		{"suffix without passthrough", "https://ozon.ru/docs", storage.Options{}, "guide", nil, "", errNoPassthrough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := storage.Link{FullURL: tt.url, Options: tt.opts}
+			got, err := Destination(link, tt.suffix, tt.query)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
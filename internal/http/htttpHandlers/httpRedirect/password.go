@@ -0,0 +1,78 @@
+package httpRedirect
+
+import (
+	"html/template"
+	"net/http"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
+)
+
+const (
+	// PasswordHeader carries the password of a protected link for API clients.
+	PasswordHeader = "X-Link-Password"
+	passwordField  = "password"
+	// maxFormSize - форма пароля маленькая, большое тело не читаем.
+	maxFormSize = 4 << 10
+)
+
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Protected link</title>
+</head>
+<body>
+<p>This link is protected with a password.</p>
+{{if .}}<p>{{.}}</p>
+{{end}}<form method="post">
+<input type="password" name="password" autofocus required>
+<button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
+// requestPassword takes the password from the header, basic auth or the submitted form, in this order.
+func requestPassword(w http.ResponseWriter, r *http.Request) string {
+	if password := r.Header.Get(PasswordHeader); password != "" {
+		return password
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+		return r.PostFormValue(passwordField)
+	}
+	return ""
+}
+
+// isPasswordError tells whether the link needs another attempt at the password.
+func isPasswordError(err error) bool {
+	code := domainError.From(err).Code
+	return code == domainError.CodePasswordRequired || code == domainError.CodeTooManyAttempts
+}
+
+// askPassword shows browsers the password form and challenges the rest with basic auth. The message of
+// the error is shown in the form only after an attempt, so the first visit doesn't start with an error.
+func askPassword(w http.ResponseWriter, r *http.Request, err error) error {
+	w.Header().Set("Cache-Control", noStore)
+	w.Header().Add("Vary", "Accept")
+
+	problem := httpUtils.NewProblem(err)
+	if !httpUtils.PrefersHTML(r) {
+		if problem.Code == string(domainError.CodePasswordRequired) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="short link", charset="UTF-8"`)
+		}
+		return httpUtils.RenderProblem(w, err)
+	}
+
+	message := problem.Detail
+	if r.Method != http.MethodPost && problem.Code == string(domainError.CodePasswordRequired) {
+		message = ""
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	return passwordPage.Execute(w, message)
+}
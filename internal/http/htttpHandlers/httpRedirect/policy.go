@@ -0,0 +1,57 @@
+package httpRedirect
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"urlShortener/internal/config"
+	"urlShortener/internal/storage"
+)
+
+const (
+	noCache = "no-cache"
+	noStore = "no-store"
+)
+
+// Policy chooses the status and the caching of a redirect. The config can be replaced while serving.
+type Policy struct {
+	cfg atomic.Pointer[config.RedirectConfig]
+}
+
+func NewPolicy(cfg config.RedirectConfig) *Policy {
+	p := &Policy{}
+	p.SetConfig(cfg)
+	return p
+}
+
+func (p *Policy) SetConfig(cfg config.RedirectConfig) {
+	p.cfg.Store(&cfg)
+}
+
+// Status returns the status chosen for the link or the server default.
+func (p *Policy) Status(link storage.Link) int {
+	if link.RedirectStatus != 0 {
+		return link.RedirectStatus
+	}
+	return p.cfg.Load().Status
+}
+
+// CacheControl lets clients keep permanent redirects for the configured time. Temporary ones are
+// checked with the server on every use, so the destination can change. Redirects of protected links,
+// of links with a click limit, a window, variants, device targets or country rules are never stored:
+// the next visit has to reach the server, and a cached redirect may be wrong for the next device or address.
+func (p *Policy) CacheControl(link storage.Link, status int) string {
+	if link.PasswordHash != "" || link.MaxClicks > 0 || !link.NotBefore.IsZero() || !link.NotAfter.IsZero() ||
+		len(link.Variants) > 0 || link.Targets != (storage.Targets{}) || len(link.Countries) > 0 {
+		return noStore
+	}
+	maxAge := p.cfg.Load().PermanentMaxAge
+	if !permanent(status) || maxAge <= 0 {
+		return noCache
+	}
+	return fmt.Sprintf("public, max-age=%d", int64(maxAge.Seconds()))
+}
+
+func permanent(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
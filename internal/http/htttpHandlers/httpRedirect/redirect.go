@@ -1,55 +1,133 @@
 package httpRedirect
 
 import (
+	"context"
 	"errors"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"urlShortener/internal/domainError"
 	"urlShortener/internal/http/httpUtils"
 	"urlShortener/internal/http/htttpHandlers"
+	"urlShortener/internal/lib/userAgent"
 	"urlShortener/internal/storage"
 )
 
-type FullURLGetter interface {
-	GetFullURL(shortenURL string) (string, error)
-}
+var errNoShortenURL = errors.New("shorten URL route variable is missing")
 
-type Response struct {
-	Error   string `json:"error,omitempty"`
-	FullURL string `json:"fullURL,omitempty"`
+type Resolver interface {
+	// UnlockPath refuses a suffix the link doesn't pass through before the click of the link is used.
+	UnlockPath(ctx context.Context, shortenURL, suffix, password, client string) (storage.Link, error)
+	// CountVariant records the redirect to the variant of an A/B link.
+	CountVariant(ctx context.Context, shortenURL string, variant string) error
+	// CountTarget records the target chosen for a link with device or country targets.
+	CountTarget(ctx context.Context, shortenURL string, target string) error
 }
 
-func New(logger *logrus.Logger, getter FullURLGetter) http.HandlerFunc {
+// New redirects to the link. A protected link asks for the password first: it is accepted in the
+// X-Link-Password header, as the basic auth password or from the form posted back to the same URL.
+// Before its window a link without a fallback shows when it opens. A link with targets sends the
+// visitor to the one of their device or country and counts the choice for the link, observer gets
+// the choices of all links and may be nil. Variants of an A/B link split the visitors that would get
+// the URL of the link, the chosen one is counted.
+func New(logger *logrus.Logger, resolver Resolver, policy *Policy, visitors Visitors, observer TargetObserver) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "httpHandlers.httpRedirect.New"
 
-		logger := logger.WithField(
-			"function", fn,
-		)
+		logger := logger.WithContext(r.Context()).WithField("handler", fn)
 
 		shortenURL, ok := mux.Vars(r)[htttpHandlers.ShortenURLQuery]
 		if !ok {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			logger.Error(errNoShortenURL)
+			err := httpUtils.RenderProblem(w, domainError.Internal(errNoShortenURL))
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
+			}
 			return
 		}
 
-		fullURL, err := getter.GetFullURL(shortenURL)
-		if errors.Is(err, storage.ErrURLNotFound) {
-			logger.Info("url not found")
-			err = httpUtils.RenderJSON(w, Response{Error: "can't find url"}, http.StatusNotFound)
+		suffix := mux.Vars(r)[htttpHandlers.PathSuffixQuery]
+		link, err := resolver.UnlockPath(r.Context(), shortenURL, suffix, requestPassword(w, r), visitors.clientAddr(r))
+		if isPasswordError(err) {
+			logger.WithError(err).Info("link is locked")
+			if err = askPassword(w, r, err); err != nil {
+				logger.WithError(err).Error("can't ask for password")
+			}
+			return
+		} else if isNotActive(err) {
+			logger.WithError(err).Info("link is not active yet")
+			if err = notActive(w, r, err); err != nil {
+				logger.WithError(err).Error("can't render not active link")
+			}
+			return
+		} else if err != nil {
+			if domainError.ServerFault(err) {
+				logger.WithError(err).Error("can't get full URL")
+			} else {
+				logger.WithError(err).Info("can't get full URL")
+			}
+			err = httpUtils.RenderProblem(w, err)
 			if err != nil {
-				logger.Error("can't render JSON", "error", err.Error())
+				logger.WithError(err).Error("can't render problem")
+			}
+			return
+		}
+
+		device := userAgent.Classify(r.UserAgent())
+		country := visitors.country(r, len(link.Countries) > 0)
+		fullURL, chosen := target(link, device, country)
+		variant, split := storage.Variant{}, false
+		if chosen == DefaultTarget {
+			variant, split = visitors.variant(w, r, shortenURL, link)
+			if split {
+				fullURL = variant.URL
+			}
+		}
+		link.FullURL = fullURL
+
+		destination, err := Destination(link, suffix, r.URL.Query())
+		if errors.Is(err, errNoPassthrough) {
+			logger.WithError(err).Info("path after the code")
+			err = httpUtils.RenderProblem(w, domainError.URLNotFound(err))
+			if err != nil {
+				logger.WithError(err).Error("can't render problem")
 			}
 			return
 		} else if err != nil {
-			logger.Error("error while getting full URL")
-			err = httpUtils.RenderJSON(w, Response{Error: "can't get url sorry"}, http.StatusInternalServerError)
+			logger.WithError(err).Error("can't build destination")
+			err = httpUtils.RenderProblem(w, domainError.Internal(err))
 			if err != nil {
-				logger.Error("error while rendering JSON")
+				logger.WithError(err).Error("can't render problem")
 			}
 			return
 		}
 
-		http.Redirect(w, r, fullURL, http.StatusFound)
+		status := policy.Status(link)
+		if r.Method == http.MethodPost {
+			// после формы пароля браузер должен перейти по ссылке GET-запросом
+			status = http.StatusSeeOther
+		}
+		w.Header().Set("Cache-Control", policy.CacheControl(link, status))
+		if link.Targets != (storage.Targets{}) {
+			// кеш не должен отдать ссылку для Android айфону
+			w.Header().Add("Vary", "User-Agent")
+		}
+		http.Redirect(w, r, destination, status)
+
+		logger.WithFields(logrus.Fields{"device": device, "country": country, "target": chosen, "variant": variant.Name}).Debug("redirect target")
+		if split {
+			// переход уже отдан: без записи клика посетитель все равно уходит по ссылке
+			if err = resolver.CountVariant(r.Context(), shortenURL, variant.Name); err != nil {
+				logger.WithError(err).Warn("can't count variant click")
+			}
+		}
+		if link.Targets != (storage.Targets{}) || len(link.Countries) > 0 {
+			if err = resolver.CountTarget(r.Context(), shortenURL, chosen); err != nil {
+				logger.WithError(err).Warn("can't count redirect target")
+			}
+		}
+		if observer != nil {
+			observer.ObserveRedirectTarget(string(device), chosen)
+		}
 	}
 }
@@ -1,13 +1,23 @@
 package httpRedirect
 
 import (
+	"context"
+	"errors"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"urlShortener/internal/config"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
 	"urlShortener/internal/http/htttpHandlers"
 	"urlShortener/internal/storage"
 )
@@ -16,18 +26,30 @@ type mockURLGetter struct {
 	mock.Mock
 }
 
-func (m *mockURLGetter) GetFullURL(shortenURL string) (string, error) {
-	args := m.Called(shortenURL)
-	return args.String(0), args.Error(1)
+func (m *mockURLGetter) UnlockPath(ctx context.Context, shortenURL, suffix, password, client string) (storage.Link, error) {
+	args := m.Called(shortenURL, password)
+	return args.Get(0).(storage.Link), args.Error(1)
 }
 
+func (m *mockURLGetter) CountVariant(ctx context.Context, shortenURL string, variant string) error {
+	args := m.Called(shortenURL, variant)
+	return args.Error(0)
+}
+
+func (m *mockURLGetter) CountTarget(ctx context.Context, shortenURL string, target string) error {
+	args := m.Called(shortenURL, target)
+	return args.Error(0)
+}
+
+var testPolicy = config.RedirectConfig{Status: http.StatusFound, PermanentMaxAge: time.Hour}
+
 func TestNewSuccess(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.PanicLevel)
 	getter := &mockURLGetter{}
-	handler := New(logger, getter)
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
 
-	getter.On("GetFullURL", "known").Return("https://911.com", nil)
+	getter.On("UnlockPath", "known", "").Return(storage.Link{FullURL: "https://911.com"}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/known", nil)
 	req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "known"})
@@ -38,6 +60,7 @@ func TestNewSuccess(t *testing.T) {
 	resp := w.Result()
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "https://911.com", resp.Header.Get("Location"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
 
 	getter.AssertExpectations(t)
 }
@@ -46,9 +69,9 @@ func TestNewURLNotFound(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.PanicLevel)
 	getter := &mockURLGetter{}
-	handler := New(logger, getter)
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
 
-	getter.On("GetFullURL", "bbbbb").Return("", storage.ErrURLNotFound)
+	getter.On("UnlockPath", "bbbbb", "").Return(storage.Link{}, domainError.URLNotFound(storage.ErrURLNotFound))
 
 	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
 	req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "bbbbb"})
@@ -62,11 +85,40 @@ func TestNewURLNotFound(t *testing.T) {
 	getter.AssertExpectations(t)
 }
 
+func TestNewLogLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level logrus.Level
+	}{
+		{"not found", domainError.URLNotFound(storage.ErrURLNotFound), logrus.InfoLevel},
+		{"disabled", domainError.URLDisabled(storage.ErrURLDisabled), logrus.InfoLevel},
+		{"unavailable", domainError.Unavailable(errors.New("breaker is open")), logrus.ErrorLevel},
+		{"internal", errors.New("connection reset"), logrus.ErrorLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := test.NewNullLogger()
+			getter := &mockURLGetter{}
+			handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+			getter.On("UnlockPath", "known", "").Return(storage.Link{}, tt.err)
+
+			req := httptest.NewRequest(http.MethodGet, "/known", nil)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "known"})
+			handler(httptest.NewRecorder(), req)
+
+			if assert.NotNil(t, hook.LastEntry()) {
+				assert.Equal(t, tt.level, hook.LastEntry().Level)
+			}
+		})
+	}
+}
+
 func TestNewQueryError(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.PanicLevel)
 	getter := &mockURLGetter{}
-	handler := New(logger, getter)
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/notok", nil)
 
@@ -78,3 +130,439 @@ func TestNewQueryError(t *testing.T) {
 
 	getter.AssertExpectations(t)
 }
+
+func TestNewLinkStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantStatus   int
+		cacheControl string
+	}{
+		{"server default", 0, http.StatusFound, "no-cache"},
+		{"moved permanently", http.StatusMovedPermanently, http.StatusMovedPermanently, "public, max-age=3600"},
+		{"permanent redirect", http.StatusPermanentRedirect, http.StatusPermanentRedirect, "public, max-age=3600"},
+		{"temporary redirect", http.StatusTemporaryRedirect, http.StatusTemporaryRedirect, "no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.PanicLevel)
+			getter := &mockURLGetter{}
+			handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+
+			link := storage.Link{FullURL: "https://911.com", Options: storage.Options{RedirectStatus: tt.status}}
+			getter.On("UnlockPath", "known", "").Return(link, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/known", nil)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "known"})
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			resp := w.Result()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.cacheControl, resp.Header.Get("Cache-Control"))
+			assert.Equal(t, "https://911.com", resp.Header.Get("Location"))
+		})
+	}
+}
+
+func TestPolicySetConfig(t *testing.T) {
+	policy := NewPolicy(testPolicy)
+	policy.SetConfig(config.RedirectConfig{Status: http.StatusMovedPermanently})
+
+	status := policy.Status(storage.Link{})
+	assert.Equal(t, http.StatusMovedPermanently, status)
+	assert.Equal(t, "no-cache", policy.CacheControl(storage.Link{}, status))
+}
+
+func TestPolicyCacheControl(t *testing.T) {
+	policy := NewPolicy(testPolicy)
+
+	tests := []struct {
+		name         string
+		opts         storage.Options
+		cacheControl string
+	}{
+		{"open", storage.Options{}, "public, max-age=3600"},
+		{"variants", storage.Options{Variants: storage.NewVariants([]storage.Variant{{URL: "https://911.com/a", Weight: 1}})}, "no-store"},
+		// закэшированный редирект для iPhone достался бы и desktop
+		{"targets", storage.Options{Targets: storage.Targets{IOS: "https://apps.apple.com/app/id1"}}, "no-store"},
+		{"countries", storage.Options{Countries: storage.NewCountries(map[string]string{"DE": "https://911.de"})}, "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := storage.Link{FullURL: "https://911.com", Options: tt.opts}
+			assert.Equal(t, tt.cacheControl, policy.CacheControl(link, http.StatusMovedPermanently))
+		})
+	}
+}
+
+func TestNewProtectedLink(t *testing.T) {
+	protected := storage.Link{FullURL: "https://911.com", Options: storage.Options{RedirectStatus: http.StatusMovedPermanently, PasswordHash: "hash"}}
+	required := domainError.PasswordRequired(nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		prepare    func(r *http.Request)
+		password   string
+		link       storage.Link
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"header", http.MethodGet, "", func(r *http.Request) { r.Header.Set(PasswordHeader, "secret") }, "secret", protected, nil, http.StatusMovedPermanently, ""},
+		{"basic auth", http.MethodGet, "", func(r *http.Request) { r.SetBasicAuth("", "secret") }, "secret", protected, nil, http.StatusMovedPermanently, ""},
+		{"form", http.MethodPost, "password=secret", func(r *http.Request) {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}, "secret", protected, nil, http.StatusSeeOther, ""},
+		{"form for browsers", http.MethodGet, "", func(r *http.Request) { r.Header.Set("Accept", "text/html") }, "", storage.Link{}, required, http.StatusUnauthorized, `<form method="post">`},
+		{"wrong password in form", http.MethodPost, "password=guess", func(r *http.Request) {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Header.Set("Accept", "text/html")
+		}, "guess", storage.Link{}, domainError.WrongPassword(nil), http.StatusUnauthorized, "wrong password"},
+		{"problem for API clients", http.MethodGet, "", func(r *http.Request) {}, "", storage.Link{}, required, http.StatusUnauthorized, "PASSWORD_REQUIRED"},
+		{"too many attempts", http.MethodGet, "", func(r *http.Request) { r.Header.Set(PasswordHeader, "guess") }, "guess", storage.Link{}, domainError.TooManyAttempts(nil), http.StatusTooManyRequests, "TOO_MANY_ATTEMPTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.PanicLevel)
+			getter := &mockURLGetter{}
+			handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+
+			getter.On("UnlockPath", "known", tt.password).Return(tt.link, tt.err)
+
+			req := httptest.NewRequest(tt.method, "/known", strings.NewReader(tt.body))
+			tt.prepare(req)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "known"})
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			resp := w.Result()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.err == nil {
+				assert.Equal(t, "https://911.com", resp.Header.Get("Location"))
+			}
+			getter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewPasswordChallenge(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	getter := &mockURLGetter{}
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+
+	getter.On("UnlockPath", "known", "").Return(storage.Link{}, domainError.PasswordRequired(nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/known", nil)
+	req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "known"})
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, `Basic realm="short link", charset="UTF-8"`, w.Result().Header.Get("WWW-Authenticate"))
+}
+
+func TestNewMaxClicks(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	getter := &mockURLGetter{}
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+
+	link := storage.Link{FullURL: "https://911.com", Options: storage.Options{RedirectStatus: http.StatusMovedPermanently, MaxClicks: 1}}
+	getter.On("UnlockPath", "known", "").Return(link, nil).Once()
+	getter.On("UnlockPath", "known", "").Return(storage.Link{}, domainError.ClicksExhausted(storage.ErrClicksExhausted)).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/known", nil)
+	req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "known"})
+	w := httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	getter.AssertExpectations(t)
+}
+
+func TestNewNotActive(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	getter := &mockURLGetter{}
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+
+	launch := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	getter.On("UnlockPath", "soon", "").Return(storage.Link{}, domainError.LinkNotActive(launch, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/soon", nil)
+	req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "soon"})
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "Sat, 01 Jun 2024 09:00:00 GMT", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `datetime="2024-06-01T09:00:00Z"`)
+
+	req.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestNewFallback(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	getter := &mockURLGetter{}
+	handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+
+	launch := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	link := storage.Link{FullURL: "https://911.com/soon", Options: storage.Options{RedirectStatus: http.StatusFound, NotBefore: launch}}
+	getter.On("UnlockPath", "soon", "").Return(link, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/soon", nil)
+	req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "soon"})
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://911.com/soon", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+type mockTargetObserver struct {
+	mock.Mock
+}
+
+func (m *mockTargetObserver) ObserveRedirectTarget(device, target string) {
+	m.Called(device, target)
+}
+
+func TestNewTargets(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	link := storage.Link{FullURL: "https://911.com", Options: storage.Options{
+		Query: storage.QueryKeep,
+		Targets: storage.Targets{
+			IOS:     "https://apps.apple.com/app/id1",
+			Android: "https://play.google.com/store/apps/details?id=com.nine",
+		},
+	}}
+	tests := []struct {
+		name      string
+		userAgent string
+		location  string
+		device    string
+		target    string
+	}{
+		{"iOS", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15", "https://apps.apple.com/app/id1?utm=a", "ios", "ios"},
+		{"Android", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36", "https://play.google.com/store/apps/details?id=com.nine&utm=a", "android", "android"},
+		{"desktop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36", "https://911.com?utm=a", "desktop", DefaultTarget},
+		{"bot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "https://911.com?utm=a", "bot", DefaultTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &mockURLGetter{}
+			observer := &mockTargetObserver{}
+			handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, observer)
+			getter.On("UnlockPath", "app", "").Return(link, nil)
+			getter.On("CountTarget", "app", tt.target).Return(nil).Once()
+			observer.On("ObserveRedirectTarget", tt.device, tt.target).Once()
+
+			req := httptest.NewRequest(http.MethodGet, "/app?utm=a", nil)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "app"})
+			req.Header.Set("User-Agent", tt.userAgent)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+			assert.Equal(t, "User-Agent", w.Header().Get("Vary"))
+			getter.AssertExpectations(t)
+			observer.AssertExpectations(t)
+		})
+	}
+}
+
+// testLocator knows the countries of single addresses.
+type testLocator map[string]string
+
+func (l testLocator) Country(addr netip.Addr) string {
+	return l[addr.String()]
+}
+
+func TestNewCountries(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	link := storage.Link{FullURL: "https://911.com", Options: storage.Options{
+		RedirectStatus: http.StatusMovedPermanently,
+		Targets:        storage.Targets{IOS: "https://apps.apple.com/app/id1"},
+		Countries:      storage.NewCountries(map[string]string{"DE": "https://911.de", "FR": "https://911.fr"}),
+	}}
+	proxies, err := httpUtils.ParseProxies([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+	visitors := Visitors{Proxies: proxies, Countries: testLocator{"192.0.2.1": "DE", "198.51.100.1": "FR", "203.0.113.5": "US"}}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		userAgent string
+		location  string
+		target    string
+	}{
+		{"country", "192.0.2.1:1234", "", "", "https://911.de", "DE"},
+		{"behind proxy", "10.0.0.2:1234", "198.51.100.1", "", "https://911.fr", "FR"},
+		// заголовок от клиента напрямую не принимается
+		{"forged header", "203.0.113.5:1234", "192.0.2.1", "", "https://911.com", DefaultTarget},
+		{"unknown address", "10.0.0.2:1234", "", "", "https://911.com", DefaultTarget},
+		{"device wins", "192.0.2.1:1234", "", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X)", "https://apps.apple.com/app/id1", "ios"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &mockURLGetter{}
+			observer := &mockTargetObserver{}
+			handler := New(logger, getter, NewPolicy(testPolicy), visitors, observer)
+			getter.On("UnlockPath", "geo", "").Return(link, nil)
+			getter.On("CountTarget", "geo", tt.target).Return(nil).Once()
+			observer.On("ObserveRedirectTarget", mock.Anything, tt.target).Once()
+
+			req := httptest.NewRequest(http.MethodGet, "/geo", nil)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "geo"})
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set(httpUtils.ForwardedForHeader, tt.forwarded)
+			}
+			req.Header.Set("User-Agent", tt.userAgent)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, http.StatusMovedPermanently, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			getter.AssertExpectations(t)
+			observer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewVariants(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	variants := storage.NewVariants([]storage.Variant{
+		{URL: "https://911.com/a", Weight: 1},
+		{URL: "https://911.com/b", Weight: 1},
+	})
+	locations := map[string]string{"A": "https://911.com/a", "B": "https://911.com/b"}
+
+	t.Run("cookie", func(t *testing.T) {
+		link := storage.Link{FullURL: "https://911.com", Options: storage.Options{Variants: variants, Sticky: storage.StickyCookie}}
+		getter := &mockURLGetter{}
+		handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+		getter.On("UnlockPath", "ab", "").Return(link, nil)
+		getter.On("CountVariant", "ab", mock.Anything).Return(nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/ab", nil)
+		req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "ab"})
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, VariantCookie, cookies[0].Name)
+		assert.Equal(t, "/ab", cookies[0].Path)
+		assert.Equal(t, locations[cookies[0].Value], w.Header().Get("Location"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		getter.AssertCalled(t, "CountVariant", "ab", cookies[0].Value)
+
+		// посетитель с cookie остается на своем варианте
+		for i := 0; i < 10; i++ {
+			req = httptest.NewRequest(http.MethodGet, "/ab", nil)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "ab"})
+			req.AddCookie(&http.Cookie{Name: VariantCookie, Value: "B"})
+			w = httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, "https://911.com/b", w.Header().Get("Location"))
+			assert.Empty(t, w.Result().Cookies())
+		}
+
+		// у ссылки больше нет такого варианта
+		req = httptest.NewRequest(http.MethodGet, "/ab", nil)
+		req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "ab"})
+		req.AddCookie(&http.Cookie{Name: VariantCookie, Value: "C"})
+		w = httptest.NewRecorder()
+		handler(w, req)
+		assert.Len(t, w.Result().Cookies(), 1)
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		link := storage.Link{FullURL: "https://911.com", Options: storage.Options{Variants: variants, Sticky: storage.StickyHash}}
+		getter := &mockURLGetter{}
+		handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+		getter.On("UnlockPath", "ab", "").Return(link, nil)
+		getter.On("CountVariant", "ab", mock.Anything).Return(nil)
+
+		var first string
+		for i := 0; i < 10; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/ab", nil)
+			req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "ab"})
+			// порт у каждого соединения свой, вариант от него не зависит
+			req.RemoteAddr = "192.0.2.1:" + strconv.Itoa(40000+i)
+			req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)")
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if first == "" {
+				first = w.Header().Get("Location")
+			}
+			assert.Equal(t, first, w.Header().Get("Location"))
+			assert.Empty(t, w.Result().Cookies())
+		}
+		assert.Contains(t, []string{"https://911.com/a", "https://911.com/b"}, first)
+	})
+
+	t.Run("device wins", func(t *testing.T) {
+		link := storage.Link{FullURL: "https://911.com", Options: storage.Options{Variants: variants,
+			Targets: storage.Targets{IOS: "https://apps.apple.com/app/id1"}}}
+		getter := &mockURLGetter{}
+		handler := New(logger, getter, NewPolicy(testPolicy), Visitors{}, nil)
+		getter.On("UnlockPath", "ab", "").Return(link, nil)
+		getter.On("CountTarget", "ab", "ios").Return(nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/ab", nil)
+		req = mux.SetURLVars(req, map[string]string{htttpHandlers.ShortenURLQuery: "ab"})
+		req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X)")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, "https://apps.apple.com/app/id1", w.Header().Get("Location"))
+		getter.AssertNotCalled(t, "CountVariant", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPickVariant(t *testing.T) {
+	variants := []storage.Variant{{Name: "A", Weight: 3}, {Name: "B", Weight: 1}}
+
+	picked := map[string]int{}
+	for roll := uint64(0); roll < 8; roll++ {
+		picked[pickVariant(variants, roll).Name]++
+	}
+	assert.Equal(t, map[string]int{"A": 6, "B": 2}, picked)
+}
@@ -0,0 +1,47 @@
+package httpRedirect
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
+)
+
+var notActivePage = template.Must(template.New("notActive").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Not available yet</title>
+</head>
+<body>
+<p>This link is not available yet.</p>
+{{if not .IsZero}}<p>It opens on <time datetime="{{.Format "2006-01-02T15:04:05Z07:00"}}">{{.Format "2 January 2006, 15:04 MST"}}</time>.</p>
+{{end}}</body>
+</html>
+`))
+
+// isNotActive tells whether the link is waiting for its window to open.
+func isNotActive(err error) bool {
+	return domainError.From(err).Code == domainError.CodeLinkNotActive
+}
+
+// notActive shows browsers when the link opens, the rest get the problem with Retry-After. Ответ не
+// кешируется: после открытия окна тот же адрес должен редиректить.
+func notActive(w http.ResponseWriter, r *http.Request, err error) error {
+	w.Header().Set("Cache-Control", noStore)
+	w.Header().Add("Vary", "Accept")
+
+	if !httpUtils.PrefersHTML(r) {
+		return httpUtils.RenderProblem(w, err)
+	}
+
+	domainErr := domainError.From(err)
+	if !domainErr.RetryAt.IsZero() {
+		w.Header().Set("Retry-After", domainErr.RetryAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(httpUtils.NewProblem(err).Status)
+	return notActivePage.Execute(w, domainErr.RetryAt.In(time.UTC))
+}
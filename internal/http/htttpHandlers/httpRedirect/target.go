@@ -0,0 +1,39 @@
+package httpRedirect
+
+import (
+	"urlShortener/internal/lib/userAgent"
+	"urlShortener/internal/storage"
+)
+
+// DefaultTarget is the target of a redirect to the URL of the link itself.
+const DefaultTarget = "default"
+
+// TargetObserver records which destination redirects choose for which devices. The target is the
+// device, the country code or DefaultTarget.
+type TargetObserver interface {
+	ObserveRedirectTarget(device string, target string)
+}
+
+// target returns the URL of the link for the device and the country of the visitor and the name of the
+// chosen target: the device, the country code or DefaultTarget. A device target wins, it's usually an
+// app store, and the country only replaces the default URL.
+func target(link storage.Link, device userAgent.Device, country string) (string, string) {
+	var url string
+	switch device {
+	case userAgent.IOS:
+		url = link.Targets.IOS
+	case userAgent.Android:
+		url = link.Targets.Android
+	case userAgent.Desktop:
+		url = link.Targets.Desktop
+	case userAgent.Bot:
+		url = link.Targets.Bot
+	}
+	if url != "" {
+		return url, string(device)
+	}
+	if url = link.Countries.URL(country); url != "" {
+		return url, country
+	}
+	return link.FullURL, DefaultTarget
+}
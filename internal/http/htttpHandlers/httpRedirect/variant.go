@@ -0,0 +1,83 @@
+package httpRedirect
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"time"
+	"urlShortener/internal/storage"
+)
+
+// VariantCookie keeps the variant of a link with cookie stickiness, the cookie of each link has its own path.
+const VariantCookie = "variant"
+
+// variantCookieAge - сколько посетитель видит один и тот же вариант.
+const variantCookieAge = 30 * 24 * time.Hour
+
+// variant chooses the variant of an A/B link for the visitor, ok is false for a link without variants.
+// A cookie that names a variant the link no longer has is replaced.
+func (v Visitors) variant(w http.ResponseWriter, r *http.Request, code string, link storage.Link) (storage.Variant, bool) {
+	variants := link.Variants
+	if len(variants) == 0 {
+		return storage.Variant{}, false
+	}
+
+	switch link.Sticky {
+	case storage.StickyCookie:
+		if cookie, err := r.Cookie(VariantCookie); err == nil {
+			for _, variant := range variants {
+				if variant.Name == cookie.Value {
+					return variant, true
+				}
+			}
+		}
+		chosen := pickVariant(variants, rand.Uint64())
+		http.SetCookie(w, &http.Cookie{
+			Name:     VariantCookie,
+			Value:    chosen.Name,
+			Path:     "/" + code,
+			MaxAge:   int(variantCookieAge.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return chosen, true
+	case storage.StickyHash:
+		return pickVariant(variants, v.visitorHash(r, code)), true
+	default:
+		return pickVariant(variants, rand.Uint64()), true
+	}
+}
+
+// visitorHash is the same for the requests of one client IP and User-Agent to the link.
+func (v Visitors) visitorHash(r *http.Request, code string) uint64 {
+	h := fnv.New64a()
+	// адрес без порта: у каждого соединения клиента порт свой
+	if addr := v.Proxies.ClientIP(r); addr.IsValid() {
+		h.Write(addr.AsSlice())
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(r.UserAgent()))
+	h.Write([]byte{0})
+	h.Write([]byte(code))
+	return h.Sum64()
+}
+
+// pickVariant maps roll onto the variants in proportion to their weights.
+func pickVariant(variants []storage.Variant, roll uint64) storage.Variant {
+	var total uint64
+	for _, variant := range variants {
+		total += uint64(variant.Weight)
+	}
+	if total == 0 {
+		return variants[0]
+	}
+
+	roll %= total
+	for _, variant := range variants {
+		if roll < uint64(variant.Weight) {
+			return variant
+		}
+		roll -= uint64(variant.Weight)
+	}
+	return variants[len(variants)-1]
+}
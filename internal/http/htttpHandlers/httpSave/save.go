@@ -1,76 +1,111 @@
 package httpSave
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"urlShortener/internal/domainError"
 	"urlShortener/internal/http/httpUtils"
 	"urlShortener/internal/storage"
-	"urlShortener/utils"
 )
 
+const bodyField = "body"
+
 type Request struct {
-	FullURL string `json:"URL" validate:"required,url"`
+	FullURL string `json:"URL"`
+	// Redirect is the HTTP status of the redirect: 301, 302, 307 or 308, the server default if omitted.
+	Redirect int `json:"redirect,omitempty"`
+	// Query is how the query of a visit is merged into the URL: keep, override or append, dropped if omitted.
+	Query string `json:"query,omitempty"`
+	// PathPassthrough appends the path after the code to the URL.
+	PathPassthrough bool `json:"pathPassthrough,omitempty"`
+	// Password protects the link, visitors have to enter it before the redirect.
+	Password string `json:"password,omitempty"`
+	// MaxClicks limits the redirects of the link, after the last one it answers 410 Gone.
+	MaxClicks uint64 `json:"maxClicks,omitempty"`
+	// NotBefore and NotAfter are the window when the link redirects: RFC 3339 times like
+	// 2024-06-01T12:00:00+03:00, or local times like 2024-06-01T12:00 read in Timezone.
+	NotBefore string `json:"notBefore,omitempty"`
+	NotAfter  string `json:"notAfter,omitempty"`
+	// Timezone is the IANA zone of the window times given without an offset, e.g. Europe/Moscow.
+	Timezone string `json:"timezone,omitempty"`
+	// FallbackURL is where the link redirects outside of its window, otherwise it answers that it's not available.
+	FallbackURL string `json:"fallbackURL,omitempty"`
+	// Targets send visitors from iOS, Android, desktops or bots elsewhere, the rest go to URL.
+	Targets storage.Targets `json:"targets,omitempty"`
+	// Countries send visitors from some countries elsewhere by ISO 3166-1 alpha-2 codes, like {"DE": "https://..."}.
+	// A device target wins over the country.
+	Countries map[string]string `json:"countries,omitempty"`
+	// Variants split the visitors that would go to URL between several URLs by weight, like
+	// [{"url": "https://...", "weight": 3}, {"name": "new", "url": "https://...", "weight": 1}].
+	// Unnamed variants are called A, B, C by position.
+	Variants []storage.Variant `json:"variants,omitempty"`
+	// Sticky keeps a visitor on one variant: "cookie" remembers it, "hash" derives it from the IP and User-Agent.
+	Sticky storage.Stickiness `json:"sticky,omitempty"`
 }
 
 type Response struct {
-	Error      string `json:"error,omitempty"`
-	ShortenURL string `json:"shortenURL,omitempty"`
+	ShortenURL string `json:"shortenURL"`
 }
 
 type shortURLGetter interface {
-	GetShortenURL(fullURL string) (string, error)
+	GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error)
 }
 
 func New(logger *logrus.Logger, service shortURLGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "httpHandlers.httpSave.New"
 
-		logger := logger.WithField("handler", fn)
+		logger := logger.WithContext(r.Context()).WithField("handler", fn)
 
 		var req Request
 
 		decoder := json.NewDecoder(r.Body)
 		err := decoder.Decode(&req)
 		if err != nil {
-			logger.Error("can't decode body", "error", err.Error())
-			err = httpUtils.RenderJSON(w, Response{Error: "can't decode JSON"}, http.StatusBadRequest)
+			logger.WithError(err).Info("can't decode body")
+			err = httpUtils.RenderProblem(w, domainError.InvalidArgument(bodyField, "can't decode JSON"))
 			if err != nil {
-				logger.Error("rendering error", "error", err.Error())
+				logger.WithError(err).Error("rendering error")
 			}
 			return
 		}
-		logger.Info("Incoming URL", req.FullURL)
+		logger.WithField("URL", req.FullURL).Debug("Incoming URL")
 
-		if err = validator.New().Struct(&req); err != nil {
-			logger.Error("can't validate request struct", "error", err.Error())
-			resp := utils.ValidateErrors(err.(validator.ValidationErrors))
-			err = httpUtils.RenderJSON(w, Response{Error: resp.Error()}, http.StatusBadRequest)
+		notBefore, notAfter, err := parseWindow(req)
+		if err != nil {
+			logger.WithError(err).Info("can't parse window")
+			err = httpUtils.RenderProblem(w, err)
 			if err != nil {
-				logger.Error("rendering error", "error", err.Error())
+				logger.WithError(err).Error("rendering error")
 			}
 			return
 		}
 
-		shortenURL, err := service.GetShortenURL(req.FullURL)
-		if errors.Is(err, storage.ErrURLExists) {
-			logger.Error("maybe id leaking", "error", err.Error())
-			err = httpUtils.RenderJSON(w, Response{
-				Error: "error happened while getting URL",
-			}, http.StatusInternalServerError)
-			if err != nil {
-				logger.Error("error while rendering JSON", "error", err.Error())
+		opts := storage.Options{
+			RedirectStatus:  req.Redirect,
+			Query:           storage.QueryMerge(req.Query),
+			PathPassthrough: req.PathPassthrough,
+			MaxClicks:       req.MaxClicks,
+			NotBefore:       notBefore,
+			NotAfter:        notAfter,
+			FallbackURL:     req.FallbackURL,
+			Targets:         req.Targets,
+			Countries:       storage.NewCountries(req.Countries),
+			Variants:        storage.NewVariants(req.Variants),
+			Sticky:          req.Sticky,
+		}
+		shortenURL, err := service.GetShortenURL(r.Context(), req.FullURL, opts, req.Password)
+		if err != nil {
+			if domainError.ServerFault(err) {
+				logger.WithError(err).Error("error while getting shortenURL")
+			} else {
+				logger.WithError(err).Info("error while getting shortenURL")
 			}
-			return
-		} else if err != nil {
-			logger.Error("error while getting shortenURL", "error", err.Error())
-			err = httpUtils.RenderJSON(w, Response{
-				Error: "error happened while trying to get short url sorry",
-			}, http.StatusInternalServerError)
+			err = httpUtils.RenderProblem(w, err)
 			if err != nil {
-				logger.Error("error while rendering JSON", "error", err.Error())
+				logger.WithError(err).Error("rendering error")
 			}
 			return
 		}
@@ -79,7 +114,7 @@ func New(logger *logrus.Logger, service shortURLGetter) http.HandlerFunc {
 			ShortenURL: shortenURL,
 		}, http.StatusOK)
 		if err != nil {
-			logger.Error("error rendering", "error", err.Error())
+			logger.WithError(err).Error("error rendering")
 		}
 	}
 }
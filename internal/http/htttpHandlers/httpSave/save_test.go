@@ -2,13 +2,19 @@ package httpSave
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
 	"urlShortener/internal/storage"
 )
 
@@ -16,8 +22,8 @@ type mockShortURLGetter struct {
 	mock.Mock
 }
 
-func (m *mockShortURLGetter) GetShortenURL(fullURL string) (string, error) {
-	args := m.Called(fullURL)
+func (m *mockShortURLGetter) GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error) {
+	args := m.Called(fullURL, opts, password)
 	return args.String(0), args.Error(1)
 }
 
@@ -31,7 +37,7 @@ func TestNewSuccess(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	service.On("GetShortenURL", "https://bmstu.com").Return("abcabcabc", nil)
+	service.On("GetShortenURL", "https://bmstu.com", storage.Options{}, "").Return("abcabcabc", nil)
 
 	w := httptest.NewRecorder()
 	handler(w, req)
@@ -59,6 +65,9 @@ func TestNewValidationError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
+	service.On("GetShortenURL", "123456789", storage.Options{}, "").
+		Return("", domainError.InvalidArgument("URL", "URL must be an absolute URL"))
+
 	w := httptest.NewRecorder()
 	handler(w, req)
 
@@ -66,11 +75,12 @@ func TestNewValidationError(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-	var response Response
-	err := json.NewDecoder(resp.Body).Decode(&response)
+	var problem httpUtils.Problem
+	err := json.NewDecoder(resp.Body).Decode(&problem)
 	assert.NoError(t, err)
 
-	assert.Equal(t, "field FullURL url is wrong", response.Error)
+	assert.Equal(t, string(domainError.CodeInvalidArgument), problem.Code)
+	assert.Equal(t, []httpUtils.InvalidParam{{Name: "URL", Reason: "URL must be an absolute URL"}}, problem.InvalidParams)
 
 	service.AssertExpectations(t)
 }
@@ -85,24 +95,54 @@ func TestNewStorageError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	service.On("GetShortenURL", "https://opposite.com").Return("", storage.ErrURLExists)
+	service.On("GetShortenURL", "https://opposite.com", storage.Options{}, "").Return("", domainError.URLConflict(storage.ErrURLExists))
 
 	w := httptest.NewRecorder()
 	handler(w, req)
 
 	resp := w.Result()
 
-	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
 
-	var response Response
-	err := json.NewDecoder(resp.Body).Decode(&response)
+	var problem httpUtils.Problem
+	err := json.NewDecoder(resp.Body).Decode(&problem)
 	assert.NoError(t, err)
 
-	assert.Equal(t, "error happened while getting URL", response.Error)
+	assert.Equal(t, string(domainError.CodeURLConflict), problem.Code)
+	assert.True(t, problem.Retryable)
 
 	service.AssertExpectations(t)
 }
 
+func TestNewLogLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level logrus.Level
+	}{
+		{"invalid", domainError.InvalidArgument("URL", "URL must be an absolute URL"), logrus.InfoLevel},
+		{"conflict", domainError.OptionsConflict(nil), logrus.InfoLevel},
+		{"unavailable", domainError.Unavailable(errors.New("breaker is open")), logrus.ErrorLevel},
+		{"internal", errors.New("connection reset"), logrus.ErrorLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := test.NewNullLogger()
+			service := mockShortURLGetter{}
+			handler := New(logger, &service)
+			service.On("GetShortenURL", "https://bmstu.com", storage.Options{}, "").Return("", tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://bmstu.com"}`))
+			handler(httptest.NewRecorder(), req)
+
+			if assert.NotNil(t, hook.LastEntry()) {
+				assert.Equal(t, tt.level, hook.LastEntry().Level)
+			}
+		})
+	}
+}
+
 func TestNewDecodeError(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.PanicLevel)
@@ -120,11 +160,95 @@ func TestNewDecodeError(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-	var response Response
-	err := json.NewDecoder(resp.Body).Decode(&response)
+	var problem httpUtils.Problem
+	err := json.NewDecoder(resp.Body).Decode(&problem)
 	assert.NoError(t, err)
 
-	assert.Equal(t, "can't decode JSON", response.Error)
+	assert.Equal(t, string(domainError.CodeInvalidArgument), problem.Code)
+	assert.Equal(t, bodyField, problem.InvalidParams[0].Name)
+
+	service.AssertExpectations(t)
+}
+
+func TestNewWithOptions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	service := mockShortURLGetter{}
+	handler := New(logger, &service)
+
+	reqBody := `{"URL": "https://bmstu.com", "redirect": 308, "query": "keep", "pathPassthrough": true, "password": "secret", "maxClicks": 3, "targets": {"android": "market://details?id=com.bmstu"}, "countries": {"kz": "https://bmstu.kz"}, "variants": [{"url": "https://bmstu.com/a", "weight": 3}, {"name": "new", "url": "https://bmstu.com/b", "weight": 1}], "sticky": "cookie"}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	service.On("GetShortenURL", "https://bmstu.com", storage.Options{RedirectStatus: 308, Query: storage.QueryKeep, PathPassthrough: true, MaxClicks: 3,
+		Targets: storage.Targets{Android: "market://details?id=com.bmstu"}, Countries: storage.Countries{"KZ": "https://bmstu.kz"},
+		Variants: storage.Variants{{Name: "A", URL: "https://bmstu.com/a", Weight: 3}, {Name: "new", URL: "https://bmstu.com/b", Weight: 1}}, Sticky: storage.StickyCookie}, "secret").
+		Return("abcabcabc", nil)
 
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 	service.AssertExpectations(t)
 }
+
+func TestNewWithWindow(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	launch := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"offset", `{"URL": "https://bmstu.com", "notBefore": "2024-06-01T12:00:00+03:00", "fallbackURL": "https://bmstu.com/soon"}`},
+		{"utc", `{"URL": "https://bmstu.com", "notBefore": "2024-06-01T09:00:00Z", "fallbackURL": "https://bmstu.com/soon"}`},
+		{"timezone", `{"URL": "https://bmstu.com", "notBefore": "2024-06-01T12:00", "timezone": "Europe/Moscow", "fallbackURL": "https://bmstu.com/soon"}`},
+		// у времени со смещением timezone не используется
+		{"offset wins", `{"URL": "https://bmstu.com", "notBefore": "2024-06-01T09:00:00Z", "timezone": "Asia/Tokyo", "fallbackURL": "https://bmstu.com/soon"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mockShortURLGetter{}
+			handler := New(logger, &service)
+			service.On("GetShortenURL", "https://bmstu.com", mock.MatchedBy(func(opts storage.Options) bool {
+				return opts.NotBefore.Equal(launch) && opts.NotAfter.IsZero() && opts.FallbackURL == "https://bmstu.com/soon"
+			}), "").Return("abcabcabc", nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+			service.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewWindowInvalid(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	service := mockShortURLGetter{}
+	handler := New(logger, &service)
+
+	tests := []struct {
+		body  string
+		field string
+	}{
+		{`{"URL": "https://bmstu.com", "notBefore": "2024-06-01T12:00"}`, "notBefore"},
+		{`{"URL": "https://bmstu.com", "notAfter": "01.06.2024"}`, "notAfter"},
+		{`{"URL": "https://bmstu.com", "notAfter": "2024-06-01T12:00", "timezone": "Mars/Olympus"}`, "timezone"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var problem httpUtils.Problem
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, tt.field, problem.InvalidParams[0].Name)
+	}
+	service.AssertNotCalled(t, "GetShortenURL")
+}
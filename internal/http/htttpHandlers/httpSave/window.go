@@ -0,0 +1,51 @@
+package httpSave
+
+import (
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/service"
+)
+
+const timezoneField = "timezone"
+
+// localLayouts are the times without an offset, they are read in the timezone of the request.
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// parseWindow reads the window of the request. A time with an offset is taken as is, a local one
+// needs the timezone, otherwise it would depend on the zone of the server.
+func parseWindow(req Request) (notBefore time.Time, notAfter time.Time, err error) {
+	location := (*time.Location)(nil)
+	if req.Timezone != "" {
+		if location, err = time.LoadLocation(req.Timezone); err != nil {
+			return time.Time{}, time.Time{}, domainError.InvalidArgument(timezoneField, "timezone must be an IANA time zone like Europe/Moscow")
+		}
+	}
+
+	if notBefore, err = parseTime(service.NotBeforeField, req.NotBefore, location); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if notAfter, err = parseTime(service.NotAfterField, req.NotAfter, location); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return notBefore, notAfter, nil
+}
+
+func parseTime(field string, value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if _, err := time.Parse(layout, value); err != nil {
+			continue
+		}
+		if location == nil {
+			return time.Time{}, domainError.InvalidArgument(field, field+" without an offset needs timezone")
+		}
+		t, _ := time.ParseInLocation(layout, value, location)
+		return t, nil
+	}
+	return time.Time{}, domainError.InvalidArgument(field, field+" must be an RFC 3339 time")
+}
@@ -3,16 +3,32 @@ package middleware
 import (
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"time"
 )
 
+// LoggingMiddleware writes one access log line per request.
 func LoggingMiddleware(logger *logrus.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger.Infof("Incomig route: %s, with Method: %s", r.URL.Path, r.Method)
-			logger.Infof("IP: %s", r.RemoteAddr)
+			start := time.Now()
+			rw := newResponseWriter(w)
 
-			next.ServeHTTP(w, r)
-			logger.Info("HTTP method completed successfully")
+			next.ServeHTTP(rw, r)
+
+			entry := logger.WithContext(r.Context()).WithFields(logrus.Fields{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"route":       routeTemplate(r),
+				"status":      rw.status,
+				"bytes":       rw.bytes,
+				"duration_ms": time.Since(start).Milliseconds(),
+				"remote_addr": r.RemoteAddr,
+			})
+			if rw.status >= http.StatusInternalServerError {
+				entry.Error("request completed")
+			} else {
+				entry.Info("request completed")
+			}
 		})
 	}
 }
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+const unknownRoute = "unknown"
+
+type httpObserver interface {
+	ObserveHTTP(route, method string, status int, duration time.Duration)
+}
+
+func MetricsMiddleware(observer httpObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := newResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			observer.ObserveHTTP(routeTemplate(r), r.Method, rw.status, time.Since(start))
+		})
+	}
+}
+
+// routeTemplate returns the route pattern instead of the path, so short URLs don't blow up label cardinality.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unknownRoute
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return unknownRoute
+	}
+	return template
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"runtime/debug"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/http/httpUtils"
+)
+
+const httpTransport = "http"
+
+type PanicObserver interface {
+	ObservePanic(transport string)
+}
+
+// RecoveryMiddleware turns a panic in the handler into 500, so the connection and the server survive.
+func RecoveryMiddleware(logger *logrus.Logger, observer PanicObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// net/http использует ErrAbortHandler, чтобы оборвать ответ, его нельзя глушить
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				logger.WithContext(r.Context()).WithFields(logrus.Fields{
+					"panic": fmt.Sprint(recovered),
+					"stack": string(debug.Stack()),
+				}).Error("panic recovered in HTTP handler")
+				if observer != nil {
+					observer.ObservePanic(httpTransport)
+				}
+
+				err := httpUtils.RenderProblem(w, domainError.Internal(errors.New("panic recovered")))
+				if err != nil {
+					logger.WithContext(r.Context()).WithError(err).Error("can't render problem")
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
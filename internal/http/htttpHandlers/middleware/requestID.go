@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+	"urlShortener/pkg/requestID"
+)
+
+// RequestIDMiddleware propagates the incoming X-Request-ID or generates a new one,
+// puts it into the request context and returns it in the response.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := requestID.FromIncoming(r.Header.Get(requestID.Header))
+
+			w.Header().Set(requestID.Header, id)
+			next.ServeHTTP(w, r.WithContext(requestID.NewContext(r.Context(), id)))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"urlShortener/internal/tracing"
+)
+
+// TracingMiddleware continues the trace from the W3C traceparent header or starts a new one.
+func TracingMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := routeTemplate(r)
+			ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(route),
+				),
+			)
+			defer span.End()
+
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(rw.status))
+			if rw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	metricsRoute   = "/metrics"
+	livenessRoute  = "/healthz"
+	readinessRoute = "/readyz"
+)
+
+type HealthChecker interface {
+	LivenessHandler() http.HandlerFunc
+	ReadinessHandler(logger *logrus.Logger) http.HandlerFunc
+}
+
+// NewAdmin builds the router of the admin listener, it must not be exposed publicly.
+func NewAdmin(logger *logrus.Logger, metrics http.Handler, health HealthChecker) *mux.Router {
+	r := mux.NewRouter()
+
+	r.Handle(metricsRoute, metrics).Methods(http.MethodGet)
+	r.Handle(livenessRoute, health.LivenessHandler()).Methods(http.MethodGet)
+	r.Handle(readinessRoute, health.ReadinessHandler(logger)).Methods(http.MethodGet)
+
+	return r
+}
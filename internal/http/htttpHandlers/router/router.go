@@ -1,31 +1,66 @@
 package router
 
 import (
+	"context"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"urlShortener/internal/http/htttpHandlers"
+	"urlShortener/internal/http/htttpHandlers/httpPreview"
+	"urlShortener/internal/http/htttpHandlers/httpQR"
 	"urlShortener/internal/http/htttpHandlers/httpRedirect"
 	"urlShortener/internal/http/htttpHandlers/httpSave"
 	"urlShortener/internal/http/htttpHandlers/middleware"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
 )
 
 const (
 	saveRoute     = "/"
 	redirectRoute = "/{" + htttpHandlers.ShortenURLQuery + "}"
+	// suffixRoute передает остаток пути ссылкам с PathPassthrough
+	suffixRoute = redirectRoute + "/{" + htttpHandlers.PathSuffixQuery + ":.*}"
+	// previewRoute - код с плюсом на конце, как у bit.ly: /{code}/info пересекался бы с суффиксом пути
+	previewRoute = redirectRoute + "+"
+	// qrRoute закрывает суффикс пути "qr" у ссылок с PathPassthrough
+	qrRoute = redirectRoute + "/qr"
 )
 
 type Service interface {
-	GetShortenURL(fullURL string) (string, error)
-	GetFullURL(shortenURL string) (string, error)
+	GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error)
+	UnlockPath(ctx context.Context, shortenURL, suffix, password, client string) (storage.Link, error)
+	CountVariant(ctx context.Context, shortenURL string, variant string) error
+	CountTarget(ctx context.Context, shortenURL string, target string) error
+	Inspect(ctx context.Context, shortenURL string) (service.Preview, error)
+	QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) ([]byte, error)
 }
 
-func New(log *logrus.Logger, service Service) *mux.Router {
+// Observer records the recovered panics and the targets chosen by redirects, nil records nothing.
+type Observer interface {
+	middleware.PanicObserver
+	httpRedirect.TargetObserver
+}
+
+// New builds the public router, publicURL is the base of short URLs in QR codes and visitors locate the
+// clients of redirects. Extra middlewares run after request ID and tracing and before the logging
+// middleware. Panics are recovered right around the handlers, so the access log and metrics still see the 500.
+func New(log *logrus.Logger, service Service, redirects *httpRedirect.Policy, visitors httpRedirect.Visitors, publicURL string, observer Observer, middlewares ...mux.MiddlewareFunc) *mux.Router {
 	r := mux.NewRouter()
 
 	r.Handle(saveRoute, httpSave.New(log, service)).Methods(http.MethodPost)
-	r.Handle(redirectRoute, httpRedirect.New(log, service)).Methods(http.MethodGet)
+	// превью регистрируем раньше редиректа: шаблон кода тоже подходит под "code+"
+	r.Handle(previewRoute, httpPreview.New(log, service)).Methods(http.MethodGet)
+	r.Handle(qrRoute, httpQR.New(log, service, publicURL)).Methods(http.MethodGet)
+	redirect := httpRedirect.New(log, service, redirects, visitors, observer)
+	// POST приходит из формы пароля защищенной ссылки
+	r.Handle(redirectRoute, redirect).Methods(http.MethodGet, http.MethodPost)
+	r.Handle(suffixRoute, redirect).Methods(http.MethodGet, http.MethodPost)
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(middleware.TracingMiddleware())
+	r.Use(middlewares...)
 	r.Use(middleware.LoggingMiddleware(log))
+	r.Use(middleware.RecoveryMiddleware(log, observer))
 
 	return r
 }
@@ -0,0 +1,501 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"urlShortener/internal/config"
+	"urlShortener/internal/http/httpUtils"
+	"urlShortener/internal/http/htttpHandlers/httpPreview"
+	"urlShortener/internal/http/htttpHandlers/httpRedirect"
+	"urlShortener/internal/http/htttpHandlers/httpSave"
+	"urlShortener/internal/lib/geoIP"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/metrics"
+	"urlShortener/internal/service"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+	"urlShortener/internal/storage/instrumented"
+	appLogger "urlShortener/pkg/logger"
+	"urlShortener/pkg/requestID"
+)
+
+const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func newTestPolicy() *httpRedirect.Policy {
+	return httpRedirect.NewPolicy(config.RedirectConfig{Status: http.StatusFound, PermanentMaxAge: 24 * time.Hour})
+}
+
+func newTestRouter(t *testing.T) (http.Handler, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	svc := service.New(instrumented.New(inMemmory.New(), metrics.New()), hashByID.New(0))
+
+	return New(logger, svc, newTestPolicy(), httpRedirect.Visitors{}, "", nil), recorder
+}
+
+func TestTracePropagatedThroughLayers(t *testing.T) {
+	router, recorder := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru"}`))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	names := make(map[string]bool)
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+		names[span.Name()] = true
+	}
+	assert.True(t, names["POST /"])
+	assert.True(t, names["service.GetShortenURL"])
+	assert.True(t, names["storage.GetShortenURL"])
+	assert.True(t, names["storage.SaveURL"])
+
+	spansBefore := len(recorder.Ended())
+	req = httptest.NewRequest(http.MethodGet, "/"+resp.ShortenURL, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	redirectSpans := recorder.Ended()[spansBefore:]
+	assert.Len(t, redirectSpans, 3)
+	assert.Equal(t, "GET /{shortenURL}", redirectSpans[len(redirectSpans)-1].Name())
+}
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	logger, err := appLogger.New("info", appLogger.FormatJSON)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	svc := service.New(inMemmory.New(), hashByID.New(0))
+	router := New(logger, svc, newTestPolicy(), httpRedirect.Visitors{}, "", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.Header.Set(requestID.Header, "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "req-42", w.Header().Get(requestID.Header))
+
+	var accessLine map[string]interface{}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.NoError(t, json.Unmarshal(lines[len(lines)-1], &accessLine))
+	assert.Equal(t, "request completed", accessLine["msg"])
+	assert.Equal(t, "req-42", accessLine["request_id"])
+	assert.Equal(t, float64(http.StatusNotFound), accessLine["status"])
+	assert.Equal(t, float64(w.Body.Len()), accessLine["bytes"])
+	assert.Contains(t, accessLine, "duration_ms")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.NotEmpty(t, w.Header().Get(requestID.Header))
+}
+
+type panickingService struct{}
+
+func (panickingService) GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (string, error) {
+	panic("boom")
+}
+
+func (panickingService) UnlockPath(ctx context.Context, shortURL, suffix, password, client string) (storage.Link, error) {
+	return storage.Link{FullURL: "https://ozon.ru"}, nil
+}
+
+func (panickingService) CountTarget(ctx context.Context, shortURL string, target string) error {
+	return nil
+}
+
+func (panickingService) CountVariant(ctx context.Context, shortURL string, variant string) error {
+	return nil
+}
+
+func (panickingService) Inspect(ctx context.Context, shortURL string) (service.Preview, error) {
+	return service.Preview{}, nil
+}
+
+func (panickingService) QRCode(ctx context.Context, base string, shortURL string, opts qrCode.Options) ([]byte, error) {
+	return nil, nil
+}
+
+type testObserver struct {
+	transports []string
+	targets    []string
+}
+
+func (o *testObserver) ObservePanic(transport string) {
+	o.transports = append(o.transports, transport)
+}
+
+func (o *testObserver) ObserveRedirectTarget(device, target string) {
+	o.targets = append(o.targets, device+":"+target)
+}
+
+func TestPanicRecovered(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	counter := &testObserver{}
+	router := New(logger, panickingService{}, newTestPolicy(), httpRedirect.Visitors{}, "", counter)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"http"}, counter.transports)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abcdefghij", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+}
+
+func TestPermanentRedirect(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru", "redirect": 301}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+resp.ShortenURL, nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://ozon.ru", w.Header().Get("Location"))
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+}
+
+func TestPassthrough(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	body := `{"URL": "https://ozon.ru/docs?utm_source=site", "query": "keep", "pathPassthrough": true}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+resp.ShortenURL+"/guide/page?utm_source=mail&lang=ru", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://ozon.ru/docs/guide/page?lang=ru&utm_source=site", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ya.ru"}`)))
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+resp.ShortenURL+"/guide", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPreview(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "http://ozon.ru/<b>"}`)))
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"+", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+	var preview httpPreview.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&preview))
+	assert.Equal(t, saved.ShortenURL, preview.Code)
+	assert.Equal(t, "http://ozon.ru/<b>", preview.FullURL)
+	assert.Equal(t, string(service.SafetyInsecure), preview.Safety)
+	assert.NotNil(t, preview.CreatedAt)
+
+	req := httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"+", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "http://ozon.ru/%3cb%3e")
+	assert.NotContains(t, w.Body.String(), "<b>")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown+", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProtectedLink(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru", "password": "secret"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Empty(t, w.Header().Get("Location"))
+
+	req := httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil)
+	req.Header.Set(httpRedirect.PasswordHeader, "secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://ozon.ru", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	req = httptest.NewRequest(http.MethodPost, "/"+saved.ShortenURL, bytes.NewBufferString("password=secret"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"+", nil))
+	var preview httpPreview.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&preview))
+	assert.True(t, preview.Protected)
+	assert.Empty(t, preview.FullURL)
+
+	for i := 0; i < 5; i++ {
+		req = httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil)
+		req.SetBasicAuth("", "guess")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil)
+	req.Header.Set(httpRedirect.PasswordHeader, "secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestOneTimeLink(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru", "maxClicks": 1}`)))
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	// превью и QR-код не тратят переход
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"+", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"/qr", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+}
+
+func TestOneTimeLinkWrongPath(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru", "maxClicks": 1}`)))
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	// путь после кода у ссылки без passthrough не тратит переход
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"/x", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://ozon.ru", w.Header().Get("Location"))
+}
+
+func TestScheduledLink(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(
+		`{"URL": "https://ozon.ru/launch", "notBefore": "2100-01-01T12:00", "timezone": "Europe/Moscow"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "Fri, 01 Jan 2100 09:00:00 GMT", w.Header().Get("Retry-After"))
+
+	// QR-код печатают до запуска
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"/qr", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(
+		`{"URL": "https://ozon.ru/sale", "notBefore": "2100-01-01T00:00:00Z", "fallbackURL": "https://ozon.ru/soon"}`)))
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://ozon.ru/soon", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestDeviceTargets(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	observer := &testObserver{}
+	st := inMemmory.New()
+	router := New(logger, service.New(st, hashByID.New(0)), newTestPolicy(), httpRedirect.Visitors{}, "", observer)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(
+		`{"URL": "https://ozon.ru/app", "targets": {"ios": "https://apps.apple.com/app/id1", "android": "market://details?id=ru.ozon.app"}}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	for _, userAgent := range []string{
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil)
+		req.Header.Set("User-Agent", userAgent)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+	}
+	assert.Equal(t, []string{"ios:ios", "android:android", "desktop:default"}, observer.targets)
+
+	// выбор цели сохраняется и для самой ссылки
+	clicks, err := st.TargetClicks(context.Background(), saved.ShortenURL)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"ios": 1, "android": 1, "default": 1}, clicks)
+}
+
+func TestCountryTargets(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	db, err := geoIP.Open("../../../lib/geoIP/testdata/countries.mmdb", geoIP.MMDB)
+	assert.NoError(t, err)
+	proxies, err := httpUtils.ParseProxies([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+	visitors := httpRedirect.Visitors{Proxies: proxies, Countries: db}
+	router := New(logger, service.New(inMemmory.New(), hashByID.New(0)), newTestPolicy(), visitors, "", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(
+		`{"URL": "https://ozon.ru/sale", "countries": {"de": "https://ozon.de/sale", "KZ": "https://ozon.kz/sale"}}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	tests := []struct {
+		forwarded string
+		location  string
+	}{
+		{"192.0.2.10", "https://ozon.de/sale"},
+		{"203.0.113.10", "https://ozon.kz/sale"},
+		{"198.51.100.10", "https://ozon.ru/sale"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil)
+		req.RemoteAddr = "10.0.0.2:4321"
+		req.Header.Set(httpUtils.ForwardedForHeader, tt.forwarded)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, tt.location, w.Header().Get("Location"), tt.forwarded)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	st := inMemmory.New()
+	router := New(logger, service.New(st, hashByID.New(0)), newTestPolicy(), httpRedirect.Visitors{}, "", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(
+		`{"URL": "https://ozon.ru/sale", "variants": [{"url": "https://ozon.ru/sale-a", "weight": 1}, {"url": "https://ozon.ru/sale-b", "weight": 1}], "sticky": "cookie"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	const visits = 20
+	for i := 0; i < visits; i++ {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL, nil))
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Contains(t, []string{"https://ozon.ru/sale-a", "https://ozon.ru/sale-b"}, w.Header().Get("Location"))
+	}
+
+	clicks, err := st.VariantClicks(context.Background(), saved.ShortenURL)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(visits), clicks["A"]+clicks["B"])
+}
+
+func TestQRCode(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"URL": "https://ozon.ru", "pathPassthrough": true}`)))
+	var saved httpSave.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&saved))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"/qr?format=svg&size=512&level=h&margin=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `width="512"`)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"/qr?format=svg&size=512&level=h&margin=2", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+saved.ShortenURL+"/qr?size=big", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown/qr", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
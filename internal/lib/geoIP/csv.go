@@ -0,0 +1,138 @@
+package geoIP
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidRange = errors.New("invalid IP range")
+
+// ipRange - адреса first..last включительно, IPv4 хранятся без отображения в IPv6.
+type ipRange struct {
+	first   netip.Addr
+	last    netip.Addr
+	country string
+}
+
+// ranges are sorted and don't overlap, so the range of an address is found with a binary search.
+type ranges []ipRange
+
+// parseCSV reads rows of "first,last,country" or "network,country", columns after the country are
+// ignored. Lines starting with # are comments, the first row is skipped if it's a header: its first
+// column has no address, as in "network,country". Errors in other rows are reported. Rows without
+// a country or with "-" and "ZZ", the unknown country, are skipped.
+func parseCSV(data []byte) (ranges, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var result ranges
+	for row := 1; ; row++ {
+		fields, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if row == 1 && isHeader(fields) {
+			continue
+		}
+		ipRange, err := parseRange(fields)
+		if err != nil {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if ipRange.country != "" {
+			result = append(result, ipRange)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].first.Less(result[j].first) })
+	for i := 1; i < len(result); i++ {
+		if !result[i-1].last.Less(result[i].first) {
+			return nil, fmt.Errorf("%w: %s overlaps %s", ErrInvalidRange, result[i].first, result[i-1].first)
+		}
+	}
+	return result, nil
+}
+
+// isHeader tells a header like "network,country" or "first,last,country" from a row with a broken range.
+func isHeader(fields []string) bool {
+	// у сети адрес до "/", у диапазона весь первый столбец
+	addr, _, _ := strings.Cut(fields[0], "/")
+	_, err := netip.ParseAddr(addr)
+	return err != nil
+}
+
+func parseRange(fields []string) (ipRange, error) {
+	var result ipRange
+	var country string
+	if len(fields) >= 3 {
+		first, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return ipRange{}, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+		}
+		last, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return ipRange{}, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+		}
+		result.first, result.last, country = first.Unmap(), last.Unmap(), fields[2]
+	} else if len(fields) == 2 {
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			return ipRange{}, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		result.first, result.last = prefixRange(prefix)
+		country = fields[1]
+	} else {
+		return ipRange{}, fmt.Errorf("%w: %d columns", ErrInvalidRange, len(fields))
+	}
+
+	if result.first.Is4() != result.last.Is4() || result.last.Less(result.first) {
+		return ipRange{}, fmt.Errorf("%w: %s-%s", ErrInvalidRange, result.first, result.last)
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country != "-" && country != "ZZ" {
+		result.country = country
+	}
+	return result, nil
+}
+
+// prefixRange returns the first and the last address of the network.
+func prefixRange(prefix netip.Prefix) (netip.Addr, netip.Addr) {
+	prefix = prefix.Masked()
+	first := prefix.Addr()
+	last := first.As16()
+	// As16 отображает IPv4 в ::ffff:0:0/96
+	bits := prefix.Bits()
+	if first.Is4() {
+		bits += 96
+	}
+	for bit := bits; bit < 128; bit++ {
+		last[bit/8] |= 1 << (7 - bit%8)
+	}
+	if first.Is4() {
+		return first, netip.AddrFrom16(last).Unmap()
+	}
+	return first, netip.AddrFrom16(last)
+}
+
+func (r ranges) Country(addr netip.Addr) string {
+	addr = addr.Unmap()
+	i := sort.Search(len(r), func(i int) bool { return !r[i].last.Less(addr) })
+	if i == len(r) || addr.Less(r[i].first) {
+		return ""
+	}
+	return r[i].country
+}
@@ -0,0 +1,56 @@
+package geoIP
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"urlShortener/utils/e"
+)
+
+// Format is the layout of the database file.
+type Format string
+
+const (
+	// MMDB is the MaxMind DB format of GeoLite2-Country, GeoIP2-Country and compatible databases.
+	MMDB Format = "mmdb"
+	// CSV lists IP ranges as "first,last,country" or "network,country" rows.
+	CSV Format = "csv"
+)
+
+var ErrUnknownFormat = errors.New("unknown IP database format")
+
+// DB finds the country of an address.
+type DB interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country, empty if the address is not in the database.
+	Country(addr netip.Addr) string
+}
+
+// Open loads the database from path into memory. An empty format is chosen by the extension of the file.
+func Open(path string, format Format) (DB, error) {
+	const fn = "lib.geoIP.Open"
+
+	if format == "" {
+		format = Format(strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")))
+	}
+	if format != MMDB && format != CSV {
+		return nil, e.WrapError(fn, ErrUnknownFormat)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+
+	var db DB
+	if format == MMDB {
+		db, err = parseMMDB(data)
+	} else {
+		db, err = parseCSV(data)
+	}
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	return db, nil
+}
@@ -0,0 +1,95 @@
+package geoIP
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountry(t *testing.T) {
+	tests := []struct {
+		addr    string
+		country string
+	}{
+		{"192.0.2.1", "DE"},
+		{"192.0.2.255", "DE"},
+		{"::ffff:192.0.2.7", "DE"},
+		{"198.51.100.42", "FR"},
+		// в MaxMind DB у сети только страна регистрации
+		{"203.0.113.5", "KZ"},
+		{"203.0.113.200", ""},
+		{"2001:db8:1::1", "NL"},
+		{"2001:db9::1", ""},
+		{"10.0.0.1", ""},
+		{"0.0.0.1", ""},
+	}
+
+	for _, path := range []string{"testdata/countries.mmdb", "testdata/countries.csv"} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			db, err := Open(path, "")
+			assert.NoError(t, err)
+			for _, tt := range tests {
+				assert.Equal(t, tt.country, db.Country(netip.MustParseAddr(tt.addr)), tt.addr)
+			}
+		})
+	}
+}
+
+func TestOpenUnknownFormat(t *testing.T) {
+	_, err := Open("testdata/countries.csv", "dat")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Open("testdata/countries.mmdb", CSV)
+	assert.Error(t, err)
+
+	_, err = Open("testdata/countries.csv", MMDB)
+	assert.ErrorIs(t, err, ErrInvalidMMDB)
+}
+
+func TestParseCSVInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"overlap", "10.0.0.0/8,DE\n10.1.0.0,10.1.0.255,FR\n"},
+		{"reversed", "10.0.0.0/8,DE\n11.0.0.255,11.0.0.0,FR\n"},
+		{"mixed families", "10.0.0.0/8,DE\n11.0.0.0,2001:db8::,FR\n"},
+		{"not an address", "10.0.0.0/8,DE\nlocalhost,FR\n"},
+		// первая строка с адресом - не заголовок, ее ошибка не теряется
+		{"first row range", "10.0.0.255,10.0.0.0,DE\n"},
+		{"first row network", "10.0.0.0/33,DE\n"},
+		{"first row columns", "10.0.0.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCSV([]byte(tt.data))
+			assert.ErrorIs(t, err, ErrInvalidRange)
+		})
+	}
+}
+
+func TestOpenNotFound(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "countries.mmdb"), "")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMMDBRecordSizes(t *testing.T) {
+	tests := []struct {
+		size  uint64
+		tree  []byte
+		left  uint64
+		right uint64
+	}{
+		{24, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}, 0x010203, 0x040506},
+		// старшие биты обеих записей лежат в среднем байте
+		{28, []byte{0x01, 0x02, 0x03, 0xab, 0x04, 0x05, 0x06}, 0xa010203, 0xb040506},
+		{32, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, 0x01020304, 0x05060708},
+	}
+	for _, tt := range tests {
+		db := &mmdb{tree: tt.tree, recordSize: tt.size, nodeCount: 1}
+		assert.Equal(t, tt.left, db.record(0, 0), tt.size)
+		assert.Equal(t, tt.right, db.record(0, 1), tt.size)
+	}
+}
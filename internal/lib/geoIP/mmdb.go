@@ -0,0 +1,364 @@
+package geoIP
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+)
+
+// Типы значений секции данных MaxMind DB.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// dataSeparator - нули между деревом поиска и секцией данных.
+const dataSeparator = 16
+
+var (
+	ErrInvalidMMDB = errors.New("invalid MaxMind DB")
+	metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+)
+
+// mmdb is a MaxMind DB: a binary search tree over the bits of addresses with records in the data section.
+// See https://maxmind.github.io/MaxMind-DB/.
+type mmdb struct {
+	tree       []byte
+	data       decoder
+	nodeCount  uint64
+	recordSize uint64
+	// ipv4Start is the node of ::/96, IPv4 addresses are searched from it in an IPv6 tree.
+	ipv4Start uint64
+	ipVersion uint64
+}
+
+func parseMMDB(buf []byte) (*mmdb, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, fmt.Errorf("%w: no metadata", ErrInvalidMMDB)
+	}
+	value, _, err := decoder(buf[start+len(metadataMarker):]).decode(0)
+	if err != nil {
+		return nil, err
+	}
+	metadata, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", ErrInvalidMMDB)
+	}
+
+	db := &mmdb{}
+	db.nodeCount, _ = metadata["node_count"].(uint64)
+	db.recordSize, _ = metadata["record_size"].(uint64)
+	db.ipVersion, _ = metadata["ip_version"].(uint64)
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, fmt.Errorf("%w: record size %d", ErrInvalidMMDB, db.recordSize)
+	}
+	if db.ipVersion != 4 && db.ipVersion != 6 {
+		return nil, fmt.Errorf("%w: IP version %d", ErrInvalidMMDB, db.ipVersion)
+	}
+
+	treeSize := db.nodeCount * db.recordSize / 4
+	if treeSize+dataSeparator > uint64(start) {
+		return nil, fmt.Errorf("%w: %d nodes don't fit", ErrInvalidMMDB, db.nodeCount)
+	}
+	db.tree = buf[:treeSize]
+	db.data = decoder(buf[treeSize+dataSeparator : start])
+
+	if db.ipVersion == 6 {
+		for i := 0; i < 96 && db.ipv4Start < db.nodeCount; i++ {
+			db.ipv4Start = db.record(db.ipv4Start, 0)
+		}
+	}
+	return db, nil
+}
+
+// Country returns the country of the network or, if it isn't known, the country where the network is registered.
+func (db *mmdb) Country(addr netip.Addr) string {
+	addr = addr.Unmap()
+	node := uint64(0)
+	var ip []byte
+	if addr.Is4() {
+		node = db.ipv4Start
+		ip4 := addr.As4()
+		ip = ip4[:]
+	} else if db.ipVersion == 6 {
+		ip16 := addr.As16()
+		ip = ip16[:]
+	}
+
+	for i := 0; i < len(ip)*8 && node < db.nodeCount; i++ {
+		node = db.record(node, uint64(ip[i/8]>>(7-i%8)&1))
+	}
+	// node_count - пустая запись, меньшие номера - адрес закончился внутри дерева
+	if node <= db.nodeCount {
+		return ""
+	}
+
+	offset := int(node - db.nodeCount - dataSeparator)
+	for _, key := range []string{"country", "registered_country"} {
+		country, err := db.data.lookupString(offset, key, "iso_code")
+		if err == nil && country != "" {
+			return country
+		}
+	}
+	return ""
+}
+
+// record returns the left (bit 0) or the right (bit 1) record of the node.
+func (db *mmdb) record(node uint64, bit uint64) uint64 {
+	switch db.recordSize {
+	case 24:
+		b := db.tree[node*6+bit*3:]
+		return uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])
+	case 28:
+		b := db.tree[node*7:]
+		if bit == 0 {
+			return uint64(b[3]&0xf0)<<20 | uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])
+		}
+		return uint64(b[3]&0x0f)<<24 | uint64(b[4])<<16 | uint64(b[5])<<8 | uint64(b[6])
+	default:
+		return uint64(binary.BigEndian.Uint32(db.tree[node*8+bit*4:]))
+	}
+}
+
+// decoder reads values of the data section, offsets of pointers are counted from its start.
+type decoder []byte
+
+// control reads the control byte of the value at offset. The size of a pointer is the rest of its
+// control byte, for the other types it's the size of the value.
+func (d decoder) control(offset int) (typ int, size int, next int, err error) {
+	if offset < 0 || offset >= len(d) {
+		return 0, 0, 0, fmt.Errorf("%w: offset %d out of data", ErrInvalidMMDB, offset)
+	}
+	ctrl := d[offset]
+	next = offset + 1
+	typ = int(ctrl >> 5)
+	if typ == typePointer {
+		return typ, int(ctrl & 0x1f), next, nil
+	}
+	if typ == typeExtended {
+		if next >= len(d) {
+			return 0, 0, 0, fmt.Errorf("%w: truncated type", ErrInvalidMMDB)
+		}
+		typ = 7 + int(d[next])
+		next++
+	}
+
+	size = int(ctrl & 0x1f)
+	if size < 29 {
+		return typ, size, next, nil
+	}
+	n := size - 28
+	if next+n > len(d) {
+		return 0, 0, 0, fmt.Errorf("%w: truncated size", ErrInvalidMMDB)
+	}
+	extra := 0
+	for _, b := range d[next : next+n] {
+		extra = extra<<8 | int(b)
+	}
+	switch n {
+	case 1:
+		size = 29 + extra
+	case 2:
+		size = 285 + extra
+	default:
+		size = 65821 + extra
+	}
+	return typ, size, next + n, nil
+}
+
+// pointer reads the target of the pointer whose control byte ended at offset.
+func (d decoder) pointer(bits int, offset int) (target int, next int, err error) {
+	n := bits>>3&3 + 1
+	if offset+n > len(d) {
+		return 0, 0, fmt.Errorf("%w: truncated pointer", ErrInvalidMMDB)
+	}
+	if n < 4 {
+		target = bits & 7
+	}
+	for _, b := range d[offset : offset+n] {
+		target = target<<8 | int(b)
+	}
+	switch n {
+	case 2:
+		target += 2048
+	case 3:
+		target += 526336
+	}
+	return target, offset + n, nil
+}
+
+// deref follows the pointer at offset, other values stay where they are.
+func (d decoder) deref(offset int) (int, error) {
+	typ, bits, next, err := d.control(offset)
+	if err != nil || typ != typePointer {
+		return offset, err
+	}
+	target, _, err := d.pointer(bits, next)
+	return target, err
+}
+
+// skip returns the offset of the value after the one at offset.
+func (d decoder) skip(offset int) (int, error) {
+	typ, size, next, err := d.control(offset)
+	if err != nil {
+		return 0, err
+	}
+	switch typ {
+	case typePointer:
+		_, next, err = d.pointer(size, next)
+		return next, err
+	case typeMap, typeArray:
+		if typ == typeMap {
+			size *= 2
+		}
+		for i := 0; i < size; i++ {
+			if next, err = d.skip(next); err != nil {
+				return 0, err
+			}
+		}
+		return next, nil
+	case typeBool, typeContainer, typeEndMarker:
+		return next, nil
+	default:
+		return next + size, nil
+	}
+}
+
+// lookupString follows the keys through nested maps, empty means there is no string at the end of the path.
+func (d decoder) lookupString(offset int, path ...string) (string, error) {
+	for _, key := range path {
+		var err error
+		if offset, err = d.deref(offset); err != nil {
+			return "", err
+		}
+		typ, size, next, err := d.control(offset)
+		if err != nil || typ != typeMap {
+			return "", err
+		}
+
+		found := false
+		for i := 0; i < size && !found; i++ {
+			name, value, err := d.decode(next)
+			if err != nil {
+				return "", err
+			}
+			if name == key {
+				offset, found = value, true
+			} else if next, err = d.skip(value); err != nil {
+				return "", err
+			}
+		}
+		if !found {
+			return "", nil
+		}
+	}
+
+	value, _, err := d.decode(offset)
+	str, _ := value.(string)
+	return str, err
+}
+
+// decode reads the value at offset, maps become map[string]any and unsigned integers uint64.
+func (d decoder) decode(offset int) (any, int, error) {
+	typ, size, next, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == typePointer {
+		target, after, err := d.pointer(size, next)
+		if err != nil {
+			return nil, 0, err
+		}
+		// указатель на указатель запрещен форматом, иначе испорченный файл зациклил бы чтение
+		if typ, _, _, err := d.control(target); err == nil && typ == typePointer {
+			return nil, 0, fmt.Errorf("%w: pointer to pointer", ErrInvalidMMDB)
+		}
+		value, _, err := d.decode(target)
+		return value, after, err
+	}
+
+	switch typ {
+	case typeMap:
+		result := make(map[string]any, size)
+		for i := 0; i < size; i++ {
+			key, value, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map key is %T", ErrInvalidMMDB, key)
+			}
+			if result[name], next, err = d.decode(value); err != nil {
+				return nil, 0, err
+			}
+		}
+		return result, next, nil
+	case typeArray:
+		result := make([]any, size)
+		for i := range result {
+			if result[i], next, err = d.decode(next); err != nil {
+				return nil, 0, err
+			}
+		}
+		return result, next, nil
+	case typeBool:
+		return size != 0, next, nil
+	case typeContainer, typeEndMarker:
+		return nil, next, nil
+	}
+
+	if next+size > len(d) {
+		return nil, 0, fmt.Errorf("%w: value out of data", ErrInvalidMMDB)
+	}
+	payload := d[next : next+size]
+	next += size
+	switch typ {
+	case typeString:
+		return string(payload), next, nil
+	case typeBytes, typeUint128:
+		return append([]byte(nil), payload...), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		var value uint64
+		for _, b := range payload {
+			value = value<<8 | uint64(b)
+		}
+		return value, next, nil
+	case typeInt32:
+		var value uint32
+		for _, b := range payload {
+			value = value<<8 | uint32(b)
+		}
+		return int32(value), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: double of %d bytes", ErrInvalidMMDB, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: float of %d bytes", ErrInvalidMMDB, size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(payload)), next, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: type %d", ErrInvalidMMDB, typ)
+	}
+}
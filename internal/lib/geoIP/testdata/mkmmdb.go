@@ -0,0 +1,172 @@
+//go:build ignore
+
+// mkmmdb writes countries.mmdb, a MaxMind DB with the networks of countries.csv:
+//
+//	go run mkmmdb.go
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/netip"
+	"os"
+	"time"
+)
+
+const (
+	typePointer = 1
+	typeString  = 2
+	typeUint16  = 5
+	typeUint32  = 6
+	typeMap     = 7
+	typeUint64  = 9
+	typeArray   = 11
+)
+
+var networks = []struct {
+	prefix  string
+	key     string
+	country string
+	name    string
+}{
+	{"192.0.2.0/24", "country", "DE", "Germany"},
+	{"198.51.100.0/24", "country", "FR", "France"},
+	// у сети известна только страна регистрации
+	{"203.0.113.0/25", "registered_country", "KZ", "Kazakhstan"},
+	{"2001:db8::/32", "country", "NL", "Netherlands"},
+}
+
+type writer struct {
+	bytes.Buffer
+	// strings are written once, the next ones are pointers like in MaxMind databases
+	strings map[string]int
+}
+
+// control writes the control byte, sizes up to 284 are enough for the fixture.
+func (w *writer) control(typ int, size int) {
+	extra := -1
+	if size >= 29 {
+		size, extra = 29, size-29
+	}
+	if typ > 7 {
+		w.WriteByte(byte(size))
+		w.WriteByte(byte(typ - 7))
+	} else {
+		w.WriteByte(byte(typ<<5 | size))
+	}
+	if extra >= 0 {
+		w.WriteByte(byte(extra))
+	}
+}
+
+func (w *writer) str(s string) {
+	if offset, ok := w.strings[s]; ok {
+		w.WriteByte(byte(typePointer<<5 | offset>>8))
+		w.WriteByte(byte(offset))
+		return
+	}
+	if w.strings != nil {
+		w.strings[s] = w.Len()
+	}
+	w.control(typeString, len(s))
+	w.WriteString(s)
+}
+
+func (w *writer) uint(typ int, value uint64) {
+	var payload []byte
+	for ; value > 0; value >>= 8 {
+		payload = append([]byte{byte(value)}, payload...)
+	}
+	w.control(typ, len(payload))
+	w.Write(payload)
+}
+
+func main() {
+	data := &writer{strings: map[string]int{}}
+	// записи: >= 0 - узел, -1 - пусто, остальные - -(смещение в данных)-2
+	nodes := [][2]int{{-1, -1}}
+	for _, network := range networks {
+		offset := data.Len()
+		data.control(typeMap, 1)
+		data.str(network.key)
+		data.control(typeMap, 3)
+		data.str("geoname_id")
+		data.uint(typeUint32, uint64(len(network.name)*1000))
+		data.str("names")
+		data.control(typeMap, 1)
+		data.str("en")
+		data.str(network.name)
+		data.str("iso_code")
+		data.str(network.country)
+
+		prefix := netip.MustParsePrefix(network.prefix)
+		ip := prefix.Addr().As16()
+		bits := prefix.Bits()
+		if prefix.Addr().Is4() {
+			// IPv4 лежит в ::/96, а не в ::ffff:0:0/96
+			ip = [16]byte{}
+			ip4 := prefix.Addr().As4()
+			copy(ip[12:], ip4[:])
+			bits += 96
+		}
+
+		node := 0
+		for i := 0; i < bits-1; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if nodes[node][bit] < 0 {
+				nodes = append(nodes, [2]int{-1, -1})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+		last := bits - 1
+		nodes[node][ip[last/8]>>(7-last%8)&1] = -offset - 2
+	}
+
+	nodeCount := len(nodes)
+	var out bytes.Buffer
+	for _, node := range nodes {
+		for _, record := range node {
+			value := record
+			switch {
+			case record == -1:
+				value = nodeCount
+			case record < -1:
+				value = nodeCount + 16 - record - 2
+			}
+			out.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	metadata := &writer{}
+	metadata.control(typeMap, 9)
+	metadata.str("binary_format_major_version")
+	metadata.uint(typeUint16, 2)
+	metadata.str("binary_format_minor_version")
+	metadata.uint(typeUint16, 0)
+	metadata.str("build_epoch")
+	metadata.uint(typeUint64, uint64(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).Unix()))
+	metadata.str("database_type")
+	metadata.str("urlShortener-Test-Country")
+	metadata.str("description")
+	metadata.control(typeMap, 1)
+	metadata.str("en")
+	metadata.str("Test networks of urlShortener")
+	metadata.str("ip_version")
+	metadata.uint(typeUint16, 6)
+	metadata.str("languages")
+	metadata.control(typeArray, 1)
+	metadata.str("en")
+	metadata.str("node_count")
+	metadata.uint(typeUint32, uint64(nodeCount))
+	metadata.str("record_size")
+	metadata.uint(typeUint16, 24)
+	out.Write(metadata.Bytes())
+
+	if err := os.WriteFile("countries.mmdb", out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -17,6 +17,8 @@ var maxURlCount = uint64(math.Pow(float64(len(alphabet)), hashLen))
 
 type SeedGenerator interface {
 	getID() uint64
+	currentID() uint64
+	advance(next uint64)
 }
 
 type HashGenerator struct {
@@ -47,3 +49,18 @@ func (h *HashGenerator) Hash() (string, error) {
 	}
 	return result + hashBuilder.String(), nil
 }
+
+// CurrentID returns the ID that will be used for the next hash.
+func (h *HashGenerator) CurrentID() uint64 {
+	return h.currentID()
+}
+
+// MaxID returns the largest ID that still fits into hashLen symbols.
+func (h *HashGenerator) MaxID() uint64 {
+	return maxURlCount
+}
+
+// Advance moves the counter forward to next if it is behind, e.g. after links with known IDs were imported.
+func (h *HashGenerator) Advance(next uint64) {
+	h.advance(next)
+}
@@ -20,6 +20,15 @@ func (m *mockIDGenerator) getID() uint64 {
 	return id.(uint64)
 }
 
+func (m *mockIDGenerator) currentID() uint64 {
+	args := m.Called()
+	return args.Get(0).(uint64)
+}
+
+func (m *mockIDGenerator) advance(next uint64) {
+	m.Called(next)
+}
+
 func TestHashLenLessThanHashLen(t *testing.T) {
 	idGen := &mockIDGenerator{}
 	hasher := HashGenerator{idGen}
@@ -77,3 +86,21 @@ func TestHashOverflow(t *testing.T) {
 
 	assert.True(t, idGen.AssertExpectations(t))
 }
+
+func TestCurrentID(t *testing.T) {
+	hasher := New(42)
+
+	assert.Equal(t, uint64(42), hasher.CurrentID())
+	_, err := hasher.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(43), hasher.CurrentID())
+	assert.Equal(t, maxURlCount, hasher.MaxID())
+}
+
+func TestAdvance(t *testing.T) {
+	hasher := New(42)
+	hasher.Advance(10)
+	assert.Equal(t, uint64(42), hasher.CurrentID())
+	hasher.Advance(100)
+	assert.Equal(t, uint64(100), hasher.CurrentID())
+}
@@ -21,3 +21,18 @@ func (gen *idGenerator) getID() uint64 {
 	gen.id++
 	return prev
 }
+
+func (gen *idGenerator) currentID() uint64 {
+	gen.mu.RLock()
+	defer gen.mu.RUnlock()
+	return gen.id
+}
+
+func (gen *idGenerator) advance(next uint64) {
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+
+	if gen.id < next {
+		gen.id = next
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"testing"
 )
 
-func TestgetIDZero(t *testing.T) {
+func TestGetIDZero(t *testing.T) {
 	gen := newIDGenerator(0)
 	res := 100000
 	wg := sync.WaitGroup{}
@@ -21,7 +21,7 @@ func TestgetIDZero(t *testing.T) {
 	assert.Equal(t, uint64(res), gen.id)
 }
 
-func TestgetIDNotZeros(t *testing.T) {
+func TestGetIDNotZeros(t *testing.T) {
 	var init uint64 = 2131
 	gen := newIDGenerator(init)
 	res := 100000
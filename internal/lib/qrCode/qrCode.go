@@ -0,0 +1,104 @@
+package qrCode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"rsc.io/qr"
+	"urlShortener/utils/e"
+)
+
+type Format string
+
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+)
+
+// Level is the error correction level, from L that restores 7% of the code to H that restores 30%.
+type Level string
+
+var levels = map[Level]qr.Level{"L": qr.L, "M": qr.M, "Q": qr.Q, "H": qr.H}
+
+// Options of the image. Size is the side in pixels, Margin is the quiet zone around the code in modules.
+type Options struct {
+	Format Format `validate:"oneof=png svg"`
+	Size   int    `validate:"min=64,max=2048"`
+	Level  Level  `validate:"oneof=L M Q H"`
+	Margin int    `validate:"min=0,max=16"`
+}
+
+// DefaultOptions - PNG 256x256 with the margin of 4 modules the standard asks for.
+func DefaultOptions() Options {
+	return Options{Format: PNG, Size: 256, Level: "M", Margin: 4}
+}
+
+func (f Format) ContentType() string {
+	if f == SVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode renders text as a QR code. A PNG is rounded down to whole pixels per module, so it may be
+// a bit smaller than Size, an SVG is scaled to exactly Size.
+func Encode(text string, opts Options) ([]byte, error) {
+	const fn = "lib.qrCode.Encode"
+
+	level, ok := levels[opts.Level]
+	if !ok {
+		return nil, e.WrapError(fn, fmt.Errorf("unknown level %q", opts.Level))
+	}
+	code, err := qr.Encode(text, level)
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+
+	var buf bytes.Buffer
+	if opts.Format == SVG {
+		writeSVG(&buf, code, opts)
+	} else if err = png.Encode(&buf, toImage(code, opts)); err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func toImage(code *qr.Code, opts Options) image.Image {
+	modules := code.Size + 2*opts.Margin
+	scale := max(opts.Size/modules, 1)
+
+	side := modules * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+			left, top := (x+opts.Margin)*scale, (y+opts.Margin)*scale
+			for py := top; py < top+scale; py++ {
+				for px := left; px < left+scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// writeSVG draws every dark module as a unit square of one path, the viewBox is measured in modules.
+func writeSVG(buf *bytes.Buffer, code *qr.Code, opts Options) {
+	modules := code.Size + 2*opts.Margin
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(buf, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+}
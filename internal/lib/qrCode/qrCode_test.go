@@ -0,0 +1,45 @@
+package qrCode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestEncodePNG(t *testing.T) {
+	opts := DefaultOptions()
+	data, err := Encode("https://sho.rt/qqqqqqqqqq", opts)
+	assert.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	side := img.Bounds().Dx()
+	assert.Equal(t, side, img.Bounds().Dy())
+	assert.LessOrEqual(t, side, opts.Size)
+	assert.Greater(t, side, opts.Size*3/4)
+
+	// угол поискового узора черный, поле вокруг белое
+	scale := side / (25 + 2*opts.Margin)
+	r, _, _, _ := img.At(opts.Margin*scale, opts.Margin*scale).RGBA()
+	assert.Zero(t, r)
+	r, _, _, _ = img.At(opts.Margin*scale-1, opts.Margin*scale-1).RGBA()
+	assert.NotZero(t, r)
+}
+
+func TestEncodeSVG(t *testing.T) {
+	opts := Options{Format: SVG, Size: 300, Level: "H", Margin: 0}
+	data, err := Encode("https://sho.rt/qqqqqqqqqq", opts)
+	assert.NoError(t, err)
+
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="300" height="300"`))
+	assert.Contains(t, svg, "M0 0h1v1h-1z")
+	assert.Equal(t, "image/svg+xml", opts.Format.ContentType())
+}
+
+func TestEncodeUnknownLevel(t *testing.T) {
+	_, err := Encode("https://sho.rt/qqqqqqqqqq", Options{Format: PNG, Size: 256, Level: "X"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package userAgent
+
+import "strings"
+
+// Device is the platform a request comes from, as far as its User-Agent tells.
+type Device string
+
+const (
+	IOS     Device = "ios"
+	Android Device = "android"
+	Desktop Device = "desktop"
+	Bot     Device = "bot"
+	// Other - пустой или незнакомый User-Agent, а также мобильные платформы кроме iOS и Android.
+	Other Device = "other"
+)
+
+// Devices lists every device Classify returns.
+var Devices = []Device{IOS, Android, Desktop, Bot, Other}
+
+// botTokens are parts of the User-Agents of crawlers, link previews and HTTP libraries.
+var botTokens = []string{
+	"googlebot", "bingbot", "yandexbot", "yandex.com/bots", "duckduckbot", "baiduspider", "applebot",
+	"twitterbot", "facebookexternalhit", "facebot", "linkedinbot", "slackbot", "slack-imgproxy",
+	"telegrambot", "discordbot", "whatsapp", "skypeuripreview", "pinterestbot", "redditbot", "embedly",
+	"crawler", "spider", "headlesschrome", "lighthouse",
+	"curl/", "wget/", "python-requests", "python-urllib", "go-http-client", "java/", "libwww-perl", "httpie/",
+}
+
+// Classify tells the device by the User-Agent header. Bots come first: crawlers like Googlebot also
+// pretend to be Android phones. iPadOS 13+ Safari asks for desktop sites and looks like a Mac, so it
+// is Desktop.
+func Classify(userAgent string) Device {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.TrimSpace(ua) == "":
+		return Other
+	case isBot(ua):
+		return Bot
+	case strings.Contains(ua, "windows phone"):
+		// старые Windows Phone пишут в User-Agent еще и Android, и iPhone
+		return Other
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return IOS
+	case strings.Contains(ua, "android"):
+		return Android
+	case strings.Contains(ua, "windows nt"), strings.Contains(ua, "macintosh"), strings.Contains(ua, "cros"),
+		strings.Contains(ua, "x11"), strings.Contains(ua, "linux"):
+		return Desktop
+	default:
+		return Other
+	}
+}
+
+func isBot(ua string) bool {
+	for _, token := range botTokens {
+		if strings.Contains(ua, token) {
+			return true
+		}
+	}
+	// "(compatible; SomeBot/1.0; +https://example.com/bot)" - так представляются почти все роботы
+	if compatible := strings.Index(ua, "compatible;"); compatible >= 0 {
+		rest := ua[compatible:]
+		if end := strings.IndexByte(rest, ')'); end >= 0 {
+			rest = rest[:end]
+		}
+		return strings.Contains(rest, "bot") || strings.Contains(rest, "+http")
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "urlshortener"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+
+	panics *prometheus.CounterVec
+
+	redirectTargets *prometheus.CounterVec
+}
+
+// HashCounter is implemented by hashers that generate short URLs from a growing ID.
+type HashCounter interface {
+	CurrentID() uint64
+	MaxID() uint64
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "Number of gRPC requests by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "gRPC request latency by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Storage operation latency by operation.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed storage operations by operation.",
+		}, []string{"operation"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "recovered_panics_total",
+			Help:      "Number of panics recovered in handlers by transport.",
+		}, []string{"transport"}),
+		redirectTargets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redirect",
+			Name:      "targets_total",
+			Help:      "Number of redirects by the device of the visitor and the chosen target.",
+		}, []string{"device", "target"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.grpcRequests, m.grpcDuration,
+		m.storageDuration, m.storageErrors,
+		m.panics,
+		m.redirectTargets,
+	)
+
+	return m
+}
+
+// Handler serves metrics in Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry allows other components to register their own collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+func (m *Metrics) ObserveHTTP(route, method string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, statusLabel).Inc()
+	m.httpDuration.WithLabelValues(route, method, statusLabel).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveGRPC(method, code string, duration time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveStorage(operation string, duration time.Duration, failed bool) {
+	m.storageDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	if failed {
+		m.storageErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (m *Metrics) ObservePanic(transport string) {
+	m.panics.WithLabelValues(transport).Inc()
+}
+
+// ObserveRedirectTarget counts redirects by device and target, the code of the link is not a label:
+// every short link would be a separate series. The counts of a link are kept in the storage instead.
+func (m *Metrics) ObserveRedirectTarget(device, target string) {
+	m.redirectTargets.WithLabelValues(device, target).Inc()
+}
+
+// RegisterHashCounter exports the current ID and the remaining ID space of the hasher,
+// so an alert fires well before the hasher overflows.
+func (m *Metrics) RegisterHashCounter(counter HashCounter) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "hasher",
+			Name:      "current_id",
+			Help:      "ID that will be used for the next short URL.",
+		}, func() float64 {
+			return float64(counter.CurrentID())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "hasher",
+			Name:      "remaining_ids",
+			Help:      "Number of short URLs that can still be generated.",
+		}, func() float64 {
+			current, max := counter.CurrentID(), counter.MaxID()
+			if current > max {
+				return 0
+			}
+			return float64(max - current)
+		}),
+	)
+}
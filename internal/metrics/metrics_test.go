@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeHashCounter struct {
+	current, max uint64
+}
+
+func (f fakeHashCounter) CurrentID() uint64 { return f.current }
+func (f fakeHashCounter) MaxID() uint64     { return f.max }
+
+func TestObserveHTTP(t *testing.T) {
+	m := New()
+
+	m.ObserveHTTP("/{shortenURL}", http.MethodGet, http.StatusFound, time.Millisecond)
+	m.ObserveHTTP("/{shortenURL}", http.MethodGet, http.StatusFound, time.Millisecond)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.httpRequests.WithLabelValues("/{shortenURL}", http.MethodGet, "302")))
+}
+
+func TestObserveStorage(t *testing.T) {
+	m := New()
+
+	m.ObserveStorage("GetFullURL", time.Millisecond, false)
+	m.ObserveStorage("GetFullURL", time.Millisecond, true)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.storageErrors.WithLabelValues("GetFullURL")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.storageDuration))
+}
+
+func TestObserveRedirectTarget(t *testing.T) {
+	m := New()
+
+	m.ObserveRedirectTarget("ios", "ios")
+	m.ObserveRedirectTarget("ios", "ios")
+	m.ObserveRedirectTarget("desktop", "default")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.redirectTargets.WithLabelValues("ios", "ios")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.redirectTargets))
+}
+
+func TestRegisterHashCounter(t *testing.T) {
+	m := New()
+	m.RegisterHashCounter(fakeHashCounter{current: 10, max: 100})
+
+	expected := `
+# HELP urlshortener_hasher_remaining_ids Number of short URLs that can still be generated.
+# TYPE urlshortener_hasher_remaining_ids gauge
+urlshortener_hasher_remaining_ids 90
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "urlshortener_hasher_remaining_ids")
+	assert.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveGRPC("/service.URLShortener/Save", "OK", time.Millisecond)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `urlshortener_grpc_requests_total{code="OK",method="/service.URLShortener/Save"} 1`)
+}
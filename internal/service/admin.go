@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/tracing"
+	"urlShortener/internal/transfer"
+	"urlShortener/utils/e"
+)
+
+// Поля запросов администратора, используются в ошибках валидации.
+const (
+	CodeField   = "code"
+	FromField   = "from"
+	ToField     = "to"
+	RecordField = "record"
+	ModeField   = "mode"
+)
+
+type HashCounter interface {
+	CurrentID() uint64
+	MaxID() uint64
+	Advance(next uint64)
+}
+
+type CounterStatus struct {
+	CurrentID uint64
+	MaxID     uint64
+	Headroom  uint64
+}
+
+type Stats struct {
+	storage.Stats
+	Counter CounterStatus
+}
+
+// Admin manages stored links. It is served on a separate listener and is never exposed to users.
+type Admin struct {
+	manager storage.Manager
+	counter HashCounter
+}
+
+func NewAdmin(manager storage.Manager, counter HashCounter) *Admin {
+	return &Admin{
+		manager: manager,
+		counter: counter,
+	}
+}
+
+func (a *Admin) GetLink(ctx context.Context, code string) (link storage.Link, err error) {
+	const fn = "service.Admin.GetLink"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if code == "" {
+		return storage.Link{}, domainError.InvalidArgument(CodeField, "code must not be empty")
+	}
+
+	link, err = a.manager.GetLink(ctx, code)
+	if err != nil {
+		return storage.Link{}, adminError(fn, err)
+	}
+	return link, nil
+}
+
+func (a *Admin) FindByFullURL(ctx context.Context, fullURL string) (link storage.Link, err error) {
+	const fn = "service.Admin.FindByFullURL"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if fullURL == "" {
+		return storage.Link{}, domainError.InvalidArgument(URLField, "URL must not be empty")
+	}
+
+	link, err = a.manager.FindByFullURL(ctx, fullURL)
+	if err != nil {
+		return storage.Link{}, adminError(fn, err)
+	}
+	return link, nil
+}
+
+func (a *Admin) DeleteLink(ctx context.Context, code string) (err error) {
+	const fn = "service.Admin.DeleteLink"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if code == "" {
+		return domainError.InvalidArgument(CodeField, "code must not be empty")
+	}
+
+	if err = a.manager.DeleteLink(ctx, code); err != nil {
+		return adminError(fn, err)
+	}
+	return nil
+}
+
+// SetDisabled disables or enables the link and returns it in the new state.
+func (a *Admin) SetDisabled(ctx context.Context, code string, disabled bool) (link storage.Link, err error) {
+	const fn = "service.Admin.SetDisabled"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if code == "" {
+		return storage.Link{}, domainError.InvalidArgument(CodeField, "code must not be empty")
+	}
+
+	if err = a.manager.SetDisabled(ctx, code, disabled); err != nil {
+		return storage.Link{}, adminError(fn, err)
+	}
+
+	link, err = a.manager.GetLink(ctx, code)
+	if err != nil {
+		return storage.Link{}, adminError(fn, err)
+	}
+	return link, nil
+}
+
+// ReassignDomain moves every link pointing to the host from to the host to and returns the number of moved links.
+func (a *Admin) ReassignDomain(ctx context.Context, from string, to string) (changed uint64, err error) {
+	const fn = "service.Admin.ReassignDomain"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if err = validate.Var(from, "required,hostname_rfc1123"); err != nil {
+		return 0, domainError.InvalidArgument(FromField, "from must be a host name")
+	}
+	if err = validate.Var(to, "required,hostname_rfc1123"); err != nil {
+		return 0, domainError.InvalidArgument(ToField, "to must be a host name")
+	}
+
+	changed, err = a.manager.ReassignDomain(ctx, from, to)
+	if errors.Is(err, storage.ErrURLExists) {
+		return 0, domainError.InvalidArgument(ToField, "some of the new destinations are already shortened")
+	} else if err != nil {
+		return 0, adminError(fn, err)
+	}
+	return changed, nil
+}
+
+func (a *Admin) CounterStatus() CounterStatus {
+	current, max := a.counter.CurrentID(), a.counter.MaxID()
+
+	status := CounterStatus{CurrentID: current, MaxID: max}
+	if current < max {
+		status.Headroom = max - current
+	}
+	return status
+}
+
+func (a *Admin) Stats(ctx context.Context) (stats Stats, err error) {
+	const fn = "service.Admin.Stats"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	storageStats, err := a.manager.Stats(ctx)
+	if err != nil {
+		return Stats{}, adminError(fn, err)
+	}
+	return Stats{Stats: storageStats, Counter: a.CounterStatus()}, nil
+}
+
+// VariantStats returns the clicks of every variant of the link in the order of its variants,
+// a link without variants has none.
+func (a *Admin) VariantStats(ctx context.Context, code string) (stats []VariantStats, err error) {
+	const fn = "service.Admin.VariantStats"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if code == "" {
+		return nil, domainError.InvalidArgument(CodeField, "code must not be empty")
+	}
+
+	link, err := a.manager.GetLink(ctx, code)
+	if err != nil {
+		return nil, adminError(fn, err)
+	}
+	variants := link.Variants
+	if len(variants) == 0 {
+		return nil, nil
+	}
+
+	counter, ok := a.manager.(storage.VariantCounter)
+	if !ok {
+		return nil, domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+	clicks, err := counter.VariantClicks(ctx, code)
+	if err != nil {
+		return nil, adminError(fn, err)
+	}
+
+	// клики вариантов, которых у ссылки больше нет, не показываем
+	stats = make([]VariantStats, 0, len(variants))
+	for _, variant := range variants {
+		stats = append(stats, VariantStats{Variant: variant, Clicks: clicks[variant.Name]})
+	}
+	return stats, nil
+}
+
+// TargetStats returns the redirects of the link by the target they chose, ordered by target. Targets
+// the link no longer has are kept: they tell where the earlier visitors went.
+func (a *Admin) TargetStats(ctx context.Context, code string) (stats []TargetStats, err error) {
+	const fn = "service.Admin.TargetStats"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if code == "" {
+		return nil, domainError.InvalidArgument(CodeField, "code must not be empty")
+	}
+
+	if _, err = a.manager.GetLink(ctx, code); err != nil {
+		return nil, adminError(fn, err)
+	}
+	counter, ok := a.manager.(storage.TargetCounter)
+	if !ok {
+		return nil, domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+	clicks, err := counter.TargetClicks(ctx, code)
+	if err != nil {
+		return nil, adminError(fn, err)
+	}
+	return sortTargets(clicks), nil
+}
+
+// ExportLinks writes every link ordered by ID.
+func (a *Admin) ExportLinks(ctx context.Context, w transfer.Writer) (err error) {
+	const fn = "service.Admin.ExportLinks"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	lister, ok := a.manager.(storage.Lister)
+	if !ok {
+		return domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+
+	if err = transfer.Export(ctx, lister, w); err != nil {
+		return adminError(fn, err)
+	}
+	return nil
+}
+
+// ImportLinks restores links with their IDs and moves the counter past the largest ID,
+// so new short URLs don't collide with the imported ones.
+func (a *Admin) ImportLinks(ctx context.Context, r transfer.Reader, opts transfer.Options) (result transfer.Result, err error) {
+	const fn = "service.Admin.ImportLinks"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if opts.Mode != "" {
+		if _, err = transfer.ParseMode(string(opts.Mode)); err != nil {
+			return transfer.Result{}, domainError.InvalidArgument(ModeField, err.Error())
+		}
+	}
+	restorer, ok := a.manager.(storage.Restorer)
+	if !ok {
+		return transfer.Result{}, domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+
+	result, err = transfer.Import(ctx, importStore{a.manager, restorer}, r, opts)
+	if !opts.DryRun && result.MaxID > 0 {
+		// счетчик двигаем и при ошибке: записи до нее уже сохранены
+		a.counter.Advance(result.MaxID + 1)
+	}
+
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.Is(err, transfer.ErrInvalidRecord):
+		return result, domainError.InvalidArgument(RecordField, err.Error())
+	case errors.Is(err, transfer.ErrExists):
+		return result, domainError.LinkExists(e.WrapError(fn, err))
+	default:
+		return result, adminError(fn, err)
+	}
+}
+
+type importStore struct {
+	storage.Manager
+	storage.Restorer
+}
+
+func adminError(fn string, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrURLNotFound):
+		return domainError.URLNotFound(e.WrapError(fn, err))
+	case errors.Is(err, storage.ErrNotSupported):
+		return domainError.NotSupported(e.WrapError(fn, err))
+	default:
+		return storageError(fn, err)
+	}
+}
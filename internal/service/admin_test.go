@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+	"urlShortener/internal/transfer"
+)
+
+func TestAdminSetDisabled(t *testing.T) {
+	st := inMemmory.New()
+	admin := NewAdmin(st, hashByID.New(0))
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+
+	link, err := admin.SetDisabled(ctx, "aaaaaaaaaa", true)
+	assert.NoError(t, err)
+	assert.True(t, link.Disabled)
+
+	_, err = admin.SetDisabled(ctx, "bbbbbbbbbb", true)
+	assert.Equal(t, domainError.CodeURLNotFound, domainError.From(err).Code)
+}
+
+func TestAdminReassignDomainValidation(t *testing.T) {
+	admin := NewAdmin(inMemmory.New(), hashByID.New(0))
+
+	_, err := admin.ReassignDomain(context.Background(), "https://ozon.ru", "ozon.com")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Equal(t, []string{FromField}, domainErr.Fields())
+}
+
+func TestAdminReassignDomainConflict(t *testing.T) {
+	st := inMemmory.New()
+	admin := NewAdmin(st, hashByID.New(0))
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru/a", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.com/a", "bbbbbbbbbb", storage.Options{}))
+
+	_, err := admin.ReassignDomain(ctx, "ozon.ru", "ozon.com")
+	assert.Equal(t, []string{ToField}, domainError.From(err).Fields())
+}
+
+func TestAdminStats(t *testing.T) {
+	st := inMemmory.New()
+	admin := NewAdmin(st, hashByID.New(10))
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+
+	stats, err := admin.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.Links)
+	assert.Equal(t, uint64(10), stats.Counter.CurrentID)
+	assert.Equal(t, stats.Counter.MaxID-10, stats.Counter.Headroom)
+}
+
+func TestAdminVariantStats(t *testing.T) {
+	st := inMemmory.New()
+	admin := NewAdmin(st, hashByID.New(0))
+	ctx := context.Background()
+	variants := []storage.Variant{{URL: "https://ozon.ru/a", Weight: 3}, {Name: "new", URL: "https://ozon.ru/b", Weight: 1}}
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{Variants: storage.NewVariants(variants)}))
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaaa", "new"))
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaaa", "new"))
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaaa", "old"))
+
+	stats, err := admin.VariantStats(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, []VariantStats{
+		{Variant: storage.Variant{Name: "A", URL: "https://ozon.ru/a", Weight: 3}},
+		{Variant: storage.Variant{Name: "new", URL: "https://ozon.ru/b", Weight: 1}, Clicks: 2},
+	}, stats)
+
+	stats, err = admin.VariantStats(ctx, "bbbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Empty(t, stats)
+	_, err = admin.VariantStats(ctx, "cccccccccc")
+	assert.Equal(t, domainError.CodeURLNotFound, domainError.From(err).Code)
+}
+
+func TestAdminTargetStats(t *testing.T) {
+	st := inMemmory.New()
+	admin := NewAdmin(st, hashByID.New(0))
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{Targets: storage.Targets{IOS: "https://apps.apple.com/app"}}))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaaa", "ios"))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaaa", "default"))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaaa", "ios"))
+
+	stats, err := admin.TargetStats(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, []TargetStats{{Target: "default", Clicks: 1}, {Target: "ios", Clicks: 2}}, stats)
+
+	_, err = admin.TargetStats(ctx, "cccccccccc")
+	assert.Equal(t, domainError.CodeURLNotFound, domainError.From(err).Code)
+}
+
+type unsupportedManager struct {
+	storage.Manager
+}
+
+func (unsupportedManager) Stats(ctx context.Context) (storage.Stats, error) {
+	return storage.Stats{}, storage.ErrNotSupported
+}
+
+func TestAdminNotSupported(t *testing.T) {
+	admin := NewAdmin(unsupportedManager{}, hashByID.New(0))
+
+	_, err := admin.Stats(context.Background())
+	assert.True(t, errors.Is(err, storage.ErrNotSupported))
+	assert.Equal(t, domainError.CodeNotSupported, domainError.From(err).Code)
+}
+
+func TestAdminImportAdvancesCounter(t *testing.T) {
+	hasher := hashByID.New(0)
+	admin := NewAdmin(inMemmory.New(), hasher)
+	input := `{"id":7,"code":"qqqqqqqqqu","url":"https://ozon.ru"}
+{"id":3,"code":"qqqqqqqqqr","url":"https://ya.ru"}
+`
+
+	result, err := admin.ImportLinks(context.Background(), transfer.NewJSONLinesReader(strings.NewReader(input)), transfer.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Imported)
+	assert.Equal(t, uint64(8), hasher.CurrentID())
+}
+
+func TestAdminImportWithoutIDAdvancesCounter(t *testing.T) {
+	hasher := hashByID.New(0)
+	admin := NewAdmin(inMemmory.New(), hasher)
+	input := `{"id":4,"code":"qqqqqqqqqu","url":"https://ozon.ru"}
+{"code":"qqqqqqqqqr","url":"https://ya.ru"}
+`
+
+	result, err := admin.ImportLinks(context.Background(), transfer.NewJSONLinesReader(strings.NewReader(input)), transfer.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Imported)
+	// вторая запись получила ID 5 в хранилище
+	assert.Equal(t, uint64(6), hasher.CurrentID())
+}
+
+func TestAdminImportStrictExists(t *testing.T) {
+	st := inMemmory.New()
+	assert.NoError(t, st.SaveURL(context.Background(), "https://ozon.ru", "qqqqqqqqqu", storage.Options{}))
+	admin := NewAdmin(st, hashByID.New(0))
+
+	_, err := admin.ImportLinks(context.Background(), transfer.NewJSONLinesReader(strings.NewReader(`{"code":"qqqqqqqqqu","url":"https://ya.ru"}`)),
+		transfer.Options{Mode: transfer.ModeStrict})
+	assert.True(t, errors.Is(err, transfer.ErrExists))
+	assert.Equal(t, domainError.CodeLinkExists, domainError.From(err).Code)
+}
+
+func TestAdminImportInvalidRecord(t *testing.T) {
+	admin := NewAdmin(inMemmory.New(), hashByID.New(0))
+
+	_, err := admin.ImportLinks(context.Background(), transfer.NewJSONLinesReader(strings.NewReader(`{"code":"","url":"https://ozon.ru"}`)), transfer.Options{})
+	assert.Equal(t, []string{RecordField}, domainError.From(err).Fields())
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"sort"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+)
+
+// CountriesField - имя поля с адресами по странам, ошибка называет страну: countries.DE.
+const CountriesField = "countries"
+
+// validateCountries checks that the rules are keyed by ISO 3166-1 alpha-2 codes and lead to absolute URLs.
+func validateCountries(rules storage.Countries) error {
+	codes := make([]string, 0, len(rules))
+	for code := range rules {
+		codes = append(codes, code)
+	}
+	// ошибка должна быть одной и той же при каждом вызове
+	sort.Strings(codes)
+	for _, code := range codes {
+		if !storage.ValidCountry(code) {
+			return domainError.InvalidArgument(CountriesField+"."+code, "country must be a two-letter ISO 3166-1 code")
+		}
+		if err := validate.Var(rules[code], "url"); err != nil {
+			return domainError.InvalidArgument(CountriesField+"."+code, "country target must be an absolute URL")
+		}
+	}
+	return nil
+}
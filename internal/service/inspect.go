@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/tracing"
+	"urlShortener/utils/e"
+)
+
+// Safety tells a visitor whether the link is fine to follow.
+type Safety string
+
+const (
+	SafetyOK Safety = "ok"
+	// SafetyInsecure - ссылка ведет на http без TLS.
+	SafetyInsecure Safety = "insecure"
+	// SafetyDisabled - ссылку отключил администратор, адрес не показываем.
+	SafetyDisabled Safety = "disabled"
+)
+
+// Preview is what a visitor may see about a link before following it.
+type Preview struct {
+	Code string
+	// FullURL is empty for a disabled or protected link and outside of the window of the link.
+	FullURL   string
+	CreatedAt time.Time
+	Safety    Safety
+	// Protected links need a password, their URL is shown only after it.
+	Protected bool
+	// NotBefore and NotAfter are the window when the link redirects, zero for an open side.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// Inspect describes the link without following it, so the visit isn't counted as a click.
+func (s *Service) Inspect(ctx context.Context, shortenURL string) (preview Preview, err error) {
+	const fn = "service.Inspect"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if shortenURL == "" {
+		return Preview{}, domainError.InvalidArgument(URLField, "short URL must not be empty")
+	}
+
+	link, err := s.Storager.Resolve(ctx, shortenURL)
+	if errors.Is(err, storage.ErrURLDisabled) {
+		return Preview{Code: shortenURL, Safety: SafetyDisabled}, nil
+	} else if errors.Is(err, storage.ErrURLNotFound) {
+		return Preview{}, domainError.URLNotFound(e.WrapError(fn, err))
+	} else if err != nil {
+		return Preview{}, storageError(fn, err)
+	}
+
+	preview = Preview{
+		Code:      shortenURL,
+		FullURL:   link.FullURL,
+		CreatedAt: link.CreatedAt,
+		Safety:    safety(link.FullURL),
+		Protected: link.PasswordHash != "",
+		NotBefore: link.NotBefore,
+		NotAfter:  link.NotAfter,
+	}
+	// адрес до запуска не раскрываем
+	if _, fallback, err := s.activeLink(fn, link); preview.Protected || fallback || err != nil {
+		preview.FullURL = ""
+	}
+	return preview, nil
+}
+
+func safety(fullURL string) Safety {
+	parsed, err := url.Parse(fullURL)
+	if err != nil || parsed.Scheme != "https" {
+		return SafetyInsecure
+	}
+	return SafetyOK
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/utils/e"
+)
+
+const (
+	defaultMaxAttempts   = 5
+	defaultAttemptWindow = 15 * time.Minute
+)
+
+var (
+	errPasswordRequired = errors.New("link is protected with a password")
+	errWrongPassword    = errors.New("wrong password")
+	errTooManyAttempts  = errors.New("too many wrong passwords")
+)
+
+// SetPasswordAttempts replaces the limits of WithPasswordAttempts while serving.
+func (s *Service) SetPasswordAttempts(max int, window time.Duration) {
+	s.attempts.setLimits(max, window)
+}
+
+func (s *Service) checkPassword(fn string, shortenURL, hash, password, client string) error {
+	key := attemptKey{code: shortenURL, client: client}
+	if password == "" {
+		if !s.attempts.allowed(key) {
+			return domainError.TooManyAttempts(e.WrapError(fn, errTooManyAttempts))
+		}
+		return domainError.PasswordRequired(e.WrapError(fn, errPasswordRequired))
+	}
+	// попытка занимается до bcrypt, иначе параллельные запросы проверят больше паролей, чем max
+	if !s.attempts.take(key) {
+		return domainError.TooManyAttempts(e.WrapError(fn, errTooManyAttempts))
+	}
+	if s.compare([]byte(hash), []byte(password)) != nil {
+		return domainError.WrongPassword(e.WrapError(fn, errWrongPassword))
+	}
+
+	s.attempts.reset(key)
+	return nil
+}
+
+type attemptKey struct {
+	code   string
+	client string
+}
+
+type attemptWindow struct {
+	start    time.Time
+	attempts int
+}
+
+// attemptLimiter counts password attempts in fixed windows, a right password clears the count. Счетчики живут в памяти процесса,
+// у каждой реплики сервиса они свои.
+type attemptLimiter struct {
+	mu      sync.Mutex
+	max     int
+	window  time.Duration
+	now     func() time.Time
+	windows map[attemptKey]*attemptWindow
+	swept   time.Time
+}
+
+func newAttemptLimiter(max int, window time.Duration, now func() time.Time) *attemptLimiter {
+	return &attemptLimiter{
+		max:     max,
+		window:  window,
+		now:     now,
+		windows: make(map[attemptKey]*attemptWindow),
+		swept:   now(),
+	}
+}
+
+// setLimits replaces the limits, the counted attempts are kept and checked against the new ones.
+func (l *attemptLimiter) setLimits(max int, window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.max = max
+	l.window = window
+}
+
+func (l *attemptLimiter) allowed(key attemptKey) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w := l.current(key, l.now())
+	return w == nil || w.attempts < l.max
+}
+
+// take counts an attempt of the key if the limit allows it. The attempt stays counted until reset.
+func (l *attemptLimiter) take(key attemptKey) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	w := l.current(key, now)
+	if w == nil {
+		w = &attemptWindow{start: now}
+		l.windows[key] = w
+	}
+	if w.attempts >= l.max {
+		return false
+	}
+	w.attempts++
+	return true
+}
+
+func (l *attemptLimiter) reset(key attemptKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.windows, key)
+}
+
+// current returns the window of the key if it hasn't expired yet.
+func (l *attemptLimiter) current(key attemptKey, now time.Time) *attemptWindow {
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		return nil
+	}
+	return w
+}
+
+// sweep drops expired windows once per window, so the map doesn't grow with every guessed code.
+func (l *attemptLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+	l.swept = now
+}
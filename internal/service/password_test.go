@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+)
+
+func passwordHash(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return string(hash)
+}
+
+func TestGetShortenURLHashesPassword(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
+	mockHash.On(hash).Return("aaaaaaaaaa", nil)
+	mockStorage.On(saveURL, fullurl, "aaaaaaaaaa", mock.MatchedBy(func(opts storage.Options) bool {
+		return bcrypt.CompareHashAndPassword([]byte(opts.PasswordHash), []byte("secret")) == nil
+	})).Return(nil)
+
+	shortenURL, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{PasswordHash: "forged"}, "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+}
+
+func TestGetShortenURLOtherPassword(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	link := storage.Link{FullURL: fullurl, Options: storage.Options{PasswordHash: passwordHash(t, "secret")}}
+	mockStorage.On(getShortenURL, fullurl).Return("aaaaaaaaaa", nil)
+	mockStorage.On(resolve, "aaaaaaaaaa").Return(link, nil)
+
+	shortenURL, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
+
+	for _, password := range []string{"guess", ""} {
+		_, err = service.GetShortenURL(context.Background(), fullurl, storage.Options{RedirectStatus: 301}, password)
+		assert.Equal(t, domainError.CodeURLConflict, domainError.From(err).Code)
+	}
+	mockHash.AssertNotCalled(t, hash)
+}
+
+func TestGetShortenURLPasswordTooLong(t *testing.T) {
+	service := New(&mockStorager{}, &mockHasher{})
+
+	_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", storage.Options{}, strings.Repeat("a", 73))
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Contains(t, domainErr.Details, PasswordField)
+}
+
+func TestUnlock(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{})
+
+	open := storage.Link{FullURL: "https://ozon.ru"}
+	protected := storage.Link{FullURL: "https://ya.ru", Options: storage.Options{PasswordHash: passwordHash(t, "secret")}}
+	mockStorage.On(resolve, "open").Return(open, nil)
+	mockStorage.On(resolve, "protected").Return(protected, nil)
+
+	link, err := service.Unlock(context.Background(), "open", "", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, open, link)
+
+	_, err = service.Unlock(context.Background(), "protected", "", "10.0.0.1")
+	assert.Equal(t, domainError.CodePasswordRequired, domainError.From(err).Code)
+
+	_, err = service.Unlock(context.Background(), "protected", "guess", "10.0.0.1")
+	assert.Equal(t, "wrong password", domainError.From(err).Message)
+
+	link, err = service.Unlock(context.Background(), "protected", "secret", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, protected, link)
+}
+
+func TestUnlockTooManyAttempts(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{}, WithPasswordAttempts(2, time.Minute))
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	service.attempts.now = func() time.Time { return now }
+
+	protected := storage.Link{Options: storage.Options{PasswordHash: passwordHash(t, "secret")}}
+	mockStorage.On(resolve, "protected").Return(protected, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Unlock(context.Background(), "protected", "guess", "10.0.0.1")
+		assert.Equal(t, domainError.CodePasswordRequired, domainError.From(err).Code)
+	}
+	_, err := service.Unlock(context.Background(), "protected", "secret", "10.0.0.1")
+	assert.Equal(t, domainError.CodeTooManyAttempts, domainError.From(err).Code)
+
+	// другой клиент не заблокирован
+	_, err = service.Unlock(context.Background(), "protected", "secret", "10.0.0.2")
+	assert.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = service.Unlock(context.Background(), "protected", "secret", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestSetPasswordAttempts(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{}, WithPasswordAttempts(1, time.Minute))
+
+	protected := storage.Link{Options: storage.Options{PasswordHash: passwordHash(t, "secret")}}
+	mockStorage.On(resolve, "protected").Return(protected, nil)
+
+	_, err := service.Unlock(context.Background(), "protected", "guess", "10.0.0.1")
+	assert.Equal(t, domainError.CodePasswordRequired, domainError.From(err).Code)
+	_, err = service.Unlock(context.Background(), "protected", "guess", "10.0.0.1")
+	assert.Equal(t, domainError.CodeTooManyAttempts, domainError.From(err).Code)
+
+	// после reload уже сделанная попытка учитывается в новом лимите
+	service.SetPasswordAttempts(2, time.Minute)
+	_, err = service.Unlock(context.Background(), "protected", "guess", "10.0.0.1")
+	assert.Equal(t, domainError.CodePasswordRequired, domainError.From(err).Code)
+	_, err = service.Unlock(context.Background(), "protected", "secret", "10.0.0.1")
+	assert.Equal(t, domainError.CodeTooManyAttempts, domainError.From(err).Code)
+}
+
+func TestUnlockConcurrentAttempts(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{}, WithPasswordAttempts(3, time.Minute))
+	var compared atomic.Int32
+	service.compare = func(hash, password []byte) error {
+		compared.Add(1)
+		return bcrypt.CompareHashAndPassword(hash, password)
+	}
+
+	protected := storage.Link{Options: storage.Options{PasswordHash: passwordHash(t, "secret")}}
+	mockStorage.On(resolve, "protected").Return(protected, nil)
+
+	const guesses = 20
+	var wg sync.WaitGroup
+	var limited atomic.Int32
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Unlock(context.Background(), "protected", "guess", "10.0.0.1")
+			if domainError.From(err).Code == domainError.CodeTooManyAttempts {
+				limited.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// до bcrypt доходят только max попыток, остальные отклонены сразу
+	assert.Equal(t, int32(3), compared.Load())
+	assert.Equal(t, int32(guesses-3), limited.Load())
+}
+
+func TestAttemptLimiterSweep(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newAttemptLimiter(2, time.Minute, func() time.Time { return now })
+
+	limiter.take(attemptKey{code: "a", client: "1"})
+	now = now.Add(time.Minute)
+	limiter.take(attemptKey{code: "b", client: "1"})
+
+	assert.Len(t, limiter.windows, 1)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"strings"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/tracing"
+	"urlShortener/utils/e"
+)
+
+// QRCode renders the QR code of the short URL of an enabled link. base is the public URL of the
+// service the code is appended to.
+func (s *Service) QRCode(ctx context.Context, base string, shortenURL string, opts qrCode.Options) (image []byte, err error) {
+	const fn = "service.QRCode"
+
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if err = validate.Struct(opts); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			field := strings.ToLower(fieldErrs[0].Field())
+			return nil, domainError.InvalidArgument(field, field+" is out of range")
+		}
+		return nil, domainError.Internal(e.WrapError(fn, err))
+	}
+
+	// код в картинке должен вести на рабочую ссылку, но окно не проверяем: QR печатают заранее
+	if _, err = s.lookup(ctx, fn, shortenURL); err != nil {
+		return nil, err
+	}
+
+	image, err = qrCode.Encode(strings.TrimSuffix(base, "/")+"/"+shortenURL, opts)
+	if err != nil {
+		return nil, domainError.Internal(e.WrapError(fn, err))
+	}
+	return image, nil
+}
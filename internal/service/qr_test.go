@@ -0,0 +1,45 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/lib/qrCode"
+	"urlShortener/internal/storage"
+)
+
+func TestQRCodeSuccess(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{})
+
+	mockStorage.On(resolve, "aaaaaaaaaa").Return(storage.Link{FullURL: "https://ozon.ru"}, nil)
+
+	image, err := service.QRCode(context.Background(), "https://sho.rt/", "aaaaaaaaaa", qrCode.DefaultOptions())
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(image, []byte("\x89PNG")))
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+}
+
+func TestQRCodeInvalidOptions(t *testing.T) {
+	service := New(&mockStorager{}, &mockHasher{})
+
+	opts := qrCode.DefaultOptions()
+	opts.Size = 10000
+	_, err := service.QRCode(context.Background(), "https://sho.rt", "aaaaaaaaaa", opts)
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Contains(t, domainErr.Details, "size")
+}
+
+func TestQRCodeDisabled(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{})
+
+	mockStorage.On(resolve, "aaaaaaaaaa").Return(storage.Link{}, storage.ErrURLDisabled)
+
+	_, err := service.QRCode(context.Background(), "https://sho.rt", "aaaaaaaaaa", qrCode.DefaultOptions())
+	assert.Equal(t, domainError.CodeURLDisabled, domainError.From(err).Code)
+}
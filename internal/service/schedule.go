@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+var (
+	errNotActive = errors.New("link is not active yet")
+	errExpired   = errors.New("link has expired")
+)
+
+// windowTime keeps the bound in UTC with the precision of postgres, so a stored window equals the requested one.
+func windowTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// validateWindow checks the window and the fallback of a new link.
+func (s *Service) validateWindow(opts storage.Options) error {
+	if !opts.NotBefore.IsZero() && !opts.NotAfter.IsZero() && !opts.NotAfter.After(opts.NotBefore) {
+		return domainError.InvalidArgument(NotAfterField, "notAfter must be after notBefore")
+	}
+	if !opts.NotAfter.IsZero() && !opts.NotAfter.After(s.now()) {
+		return domainError.InvalidArgument(NotAfterField, "notAfter must be in the future")
+	}
+	if opts.FallbackURL == "" {
+		return nil
+	}
+	if opts.NotBefore.IsZero() && opts.NotAfter.IsZero() {
+		return domainError.InvalidArgument(FallbackField, "fallback URL needs notBefore or notAfter")
+	}
+	if err := validate.Var(opts.FallbackURL, "url"); err != nil {
+		return domainError.InvalidArgument(FallbackField, "fallback URL must be an absolute URL")
+	}
+	return nil
+}
+
+// activeLink checks that the link is inside its window [NotBefore, NotAfter) now. Outside of it a link
+// with a fallback URL is replaced with the fallback, fallback reports that.
+func (s *Service) activeLink(fn string, link storage.Link) (active storage.Link, fallback bool, err error) {
+	now := s.now()
+	notYet := !link.NotBefore.IsZero() && now.Before(link.NotBefore)
+	expired := !link.NotAfter.IsZero() && !now.Before(link.NotAfter)
+	if !notYet && !expired {
+		return link, false, nil
+	}
+
+	if link.FallbackURL != "" {
+		return fallbackLink(link), true, nil
+	}
+	if notYet {
+		return storage.Link{}, false, domainError.LinkNotActive(link.NotBefore, e.WrapError(fn, errNotActive))
+	}
+	return storage.Link{}, false, domainError.LinkExpired(e.WrapError(fn, errExpired))
+}
+
+// fallbackLink redirects temporarily to the fallback URL. Пароль и лимит переходов защищают основной
+// адрес, на запасной они не распространяются.
+func fallbackLink(link storage.Link) storage.Link {
+	return storage.Link{
+		ID:        link.ID,
+		Code:      link.Code,
+		FullURL:   link.FallbackURL,
+		CreatedAt: link.CreatedAt,
+		Options: storage.Options{
+			RedirectStatus: http.StatusFound,
+			Query:          link.Query,
+			NotBefore:      link.NotBefore,
+			NotAfter:       link.NotAfter,
+			FallbackURL:    link.FallbackURL,
+		},
+	}
+}
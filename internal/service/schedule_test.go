@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+var launch = time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func TestGetShortenURLWindowInvalid(t *testing.T) {
+	now := launch
+	service := New(&mockStorager{}, &mockHasher{}, WithClock(func() time.Time { return now }))
+
+	tests := []struct {
+		name  string
+		opts  storage.Options
+		field string
+	}{
+		{"after before", storage.Options{NotBefore: launch.Add(time.Hour), NotAfter: launch.Add(time.Minute)}, NotAfterField},
+		{"after in the past", storage.Options{NotAfter: launch}, NotAfterField},
+		{"fallback without window", storage.Options{FallbackURL: "https://ya.ru"}, FallbackField},
+		{"fallback not URL", storage.Options{NotBefore: launch, FallbackURL: "ya.ru"}, FallbackField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", tt.opts, "")
+			domainErr := domainError.From(err)
+			assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+			assert.Contains(t, domainErr.Details, tt.field)
+		})
+	}
+}
+
+func TestGetShortenURLWindowUTC(t *testing.T) {
+	now := launch.Add(-time.Hour)
+	service := New(inMemmory.New(), hashByID.New(0), WithClock(func() time.Time { return now }))
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	opts := storage.Options{NotBefore: launch.In(moscow)}
+	code, err := service.GetShortenURL(context.Background(), "https://ozon.ru", opts, "")
+	assert.NoError(t, err)
+
+	preview, err := service.Inspect(context.Background(), code)
+	assert.NoError(t, err)
+	assert.Equal(t, launch, preview.NotBefore)
+
+	// то же окно в другой зоне - те же настройки
+	again, err := service.GetShortenURL(context.Background(), "https://ozon.ru", storage.Options{NotBefore: launch}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, code, again)
+}
+
+func TestUnlockWindow(t *testing.T) {
+	ctx := context.Background()
+	now := launch.Add(-time.Minute)
+	service := New(inMemmory.New(), hashByID.New(0), WithClock(func() time.Time { return now }))
+
+	code, err := service.GetShortenURL(ctx, "https://ozon.ru", storage.Options{NotBefore: launch, NotAfter: launch.Add(time.Hour)}, "")
+	assert.NoError(t, err)
+
+	_, err = service.Unlock(ctx, code, "", "10.0.0.1")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeLinkNotActive, domainErr.Code)
+	assert.Equal(t, launch, domainErr.RetryAt)
+
+	preview, err := service.Inspect(ctx, code)
+	assert.NoError(t, err)
+	assert.Empty(t, preview.FullURL)
+
+	now = launch
+	link, err := service.Unlock(ctx, code, "", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullURL)
+
+	now = launch.Add(time.Hour)
+	_, err = service.Unlock(ctx, code, "", "10.0.0.1")
+	assert.Equal(t, domainError.CodeLinkExpired, domainError.From(err).Code)
+}
+
+func TestUnlockFallback(t *testing.T) {
+	ctx := context.Background()
+	now := launch.Add(-time.Minute)
+	service := New(inMemmory.New(), hashByID.New(0), WithClock(func() time.Time { return now }))
+
+	opts := storage.Options{RedirectStatus: 301, MaxClicks: 1, NotBefore: launch, FallbackURL: "https://ozon.ru/soon"}
+	code, err := service.GetShortenURL(ctx, "https://ozon.ru/launch", opts, "secret")
+	assert.NoError(t, err)
+
+	// до запуска пароль не нужен и переходы не тратятся
+	for i := 0; i < 2; i++ {
+		link, err := service.Unlock(ctx, code, "", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://ozon.ru/soon", link.FullURL)
+		assert.Equal(t, http.StatusFound, link.RedirectStatus)
+		assert.Empty(t, link.PasswordHash)
+	}
+
+	now = launch
+	_, err = service.Unlock(ctx, code, "", "10.0.0.1")
+	assert.Equal(t, domainError.CodePasswordRequired, domainError.From(err).Code)
+
+	link, err := service.Unlock(ctx, code, "secret", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru/launch", link.FullURL)
+	assert.Equal(t, uint64(0), link.ClicksLeft)
+}
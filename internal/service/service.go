@@ -1,53 +1,242 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+	"urlShortener/internal/domainError"
 	"urlShortener/internal/lib/linkShortening"
+	"urlShortener/internal/lib/linkShortening/hashByID"
 	"urlShortener/internal/storage"
+	"urlShortener/internal/tracing"
 	"urlShortener/utils/e"
 )
 
+// URLField - имя поля с URL в запросах обоих транспортов.
+const URLField = "URL"
+
+// RedirectField - имя поля со статусом редиректа.
+const RedirectField = "redirect"
+
+// QueryField - имя поля с правилом объединения query.
+const QueryField = "query"
+
+// PasswordField - имя поля с паролем ссылки.
+const PasswordField = "password"
+
+// NotBeforeField и NotAfterField - имена полей окна работы ссылки.
+const (
+	NotBeforeField = "notBefore"
+	NotAfterField  = "notAfter"
+)
+
+// FallbackField - имя поля с адресом, куда ведет ссылка вне окна.
+const FallbackField = "fallbackURL"
+
+// maxPasswordLen - bcrypt не принимает пароли длиннее 72 байт.
+const maxPasswordLen = 72
+
+var errOptionsDiffer = errors.New("URL is stored with other options")
+
+var validate = validator.New()
+
 type Service struct {
 	storage.Storager
 	linkShortening.Hasher
+	now           func() time.Time
+	maxAttempts   int
+	attemptWindow time.Duration
+	attempts      *attemptLimiter
+	compare       func(hash, password []byte) error
+}
+
+type Option func(*Service)
+
+// WithPasswordAttempts allows a client max wrong passwords for a link within the window.
+func WithPasswordAttempts(max int, window time.Duration) Option {
+	return func(s *Service) {
+		s.maxAttempts = max
+		s.attemptWindow = window
+	}
 }
 
-func New(storage storage.Storager, hasher linkShortening.Hasher) *Service {
-	return &Service{
-		Storager: storage,
-		Hasher:   hasher,
+// WithClock replaces time.Now for the activation windows of links and for the password attempts.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
 	}
 }
 
-func (s *Service) GetShortenURL(fullURL string) (string, error) {
+func New(storage storage.Storager, hasher linkShortening.Hasher, opts ...Option) *Service {
+	s := &Service{
+		Storager:      storage,
+		Hasher:        hasher,
+		now:           time.Now,
+		maxAttempts:   defaultMaxAttempts,
+		attemptWindow: defaultAttemptWindow,
+		compare:       bcrypt.CompareHashAndPassword,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.attempts = newAttemptLimiter(s.maxAttempts, s.attemptWindow, s.now)
+	return s
+}
+
+// GetShortenURL returns the code of fullURL and creates it with opts if the URL is new, a non-empty
+// password protects the new link. An already shortened URL keeps its options, a request for other
+// explicit options or another password is a conflict. The window of the link is kept in UTC.
+func (s *Service) GetShortenURL(ctx context.Context, fullURL string, opts storage.Options, password string) (shortenURL string, err error) {
 	const fn = "service.GetShortenURL"
 
-	shortenURL, err := s.Storager.GetShortenURL(fullURL)
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	if err = validate.Var(fullURL, "required,url"); err != nil {
+		return "", domainError.InvalidArgument(URLField, "URL must be an absolute URL")
+	}
+	if !storage.ValidRedirectStatus(opts.RedirectStatus) {
+		return "", domainError.InvalidArgument(RedirectField, "redirect must be 301, 302, 307 or 308")
+	}
+	if !storage.ValidQueryMerge(opts.Query) {
+		return "", domainError.InvalidArgument(QueryField, "query must be keep, override or append")
+	}
+	if len(password) > maxPasswordLen {
+		return "", domainError.InvalidArgument(PasswordField, "password must be at most 72 bytes")
+	}
+	opts.NotBefore, opts.NotAfter = windowTime(opts.NotBefore), windowTime(opts.NotAfter)
+	if err = s.validateWindow(opts); err != nil {
+		return "", err
+	}
+	if err = validateTargets(opts.Targets); err != nil {
+		return "", err
+	}
+	if err = validateCountries(opts.Countries); err != nil {
+		return "", err
+	}
+	if err = validateVariants(opts); err != nil {
+		return "", err
+	}
+	// хеш считается только для новой ссылки, переданный снаружи хеш не принимается
+	opts.PasswordHash = ""
+
+	shortenURL, err = s.Storager.GetShortenURL(ctx, fullURL)
 	if err == nil {
+		if err = s.checkOptions(ctx, fn, shortenURL, opts, password); err != nil {
+			return "", err
+		}
 		return shortenURL, nil
 	} else if !errors.Is(err, storage.ErrURLNotFound) {
-		return "", e.WrapError(fn, err)
+		return "", storageError(fn, err)
 	}
 
 	shortenURL, err = s.Hash()
-	if err != nil {
-		return "", e.WrapError(fn, err)
+	if errors.Is(err, hashByID.ErrOverFlow) {
+		return "", domainError.IDSpaceExhausted(e.WrapError(fn, err))
+	} else if err != nil {
+		return "", domainError.Internal(e.WrapError(fn, err))
 	}
-	err = s.SaveURL(fullURL, shortenURL)
-	if err != nil {
-		return "", e.WrapError(fn, err)
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", domainError.Internal(e.WrapError(fn, err))
+		}
+		opts.PasswordHash = string(hash)
+	}
+
+	err = s.SaveURL(ctx, fullURL, shortenURL, opts)
+	if errors.Is(err, storage.ErrURLExists) {
+		// ссылку мог сохранить параллельный запрос, а реплика могла еще не получить ее: читаем с primary
+		ctx = storage.WithPrimary(ctx)
+		existing, lookupErr := s.Storager.GetShortenURL(ctx, fullURL)
+		if lookupErr == nil {
+			opts.PasswordHash = ""
+			if err = s.checkOptions(ctx, fn, existing, opts, password); err != nil {
+				return "", err
+			}
+			return existing, nil
+		}
+		return "", domainError.URLConflict(e.WrapError(fn, err))
+	} else if err != nil {
+		return "", storageError(fn, err)
 	}
 
 	return shortenURL, nil
 }
 
-func (s *Service) GetFullURL(shortenURL string) (string, error) {
-	const fn = "service.GetFullURL"
+// checkOptions compares the explicitly requested options and password with the ones of the stored link.
+func (s *Service) checkOptions(ctx context.Context, fn string, shortenURL string, opts storage.Options, password string) error {
+	if opts.Equal(storage.Options{}) && password == "" {
+		return nil
+	}
+
+	link, err := s.Storager.Resolve(ctx, shortenURL)
+	if errors.Is(err, storage.ErrURLDisabled) {
+		// у отключенной ссылки настройки не узнать, ее код вернется как раньше
+		return nil
+	} else if err != nil {
+		return storageError(fn, err)
+	}
+	hash := link.PasswordHash
+	link.PasswordHash = ""
+	if !link.Options.Equal(opts) || !samePassword(hash, password) {
+		return domainError.OptionsConflict(e.WrapError(fn, errOptionsDiffer))
+	}
+	return nil
+}
+
+func samePassword(hash, password string) bool {
+	if hash == "" || password == "" {
+		return hash == password
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Resolve returns the enabled link to redirect to. Outside of its window the link is refused or
+// replaced with its fallback.
+func (s *Service) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	link, _, err := s.resolveActive(ctx, shortenURL)
+	return link, err
+}
+
+// resolveActive is Resolve that also reports whether the link was replaced with its fallback.
+func (s *Service) resolveActive(ctx context.Context, shortenURL string) (link storage.Link, fallback bool, err error) {
+	const fn = "service.Resolve"
 
-	fullURL, err := s.Storager.GetFullURL(shortenURL)
+	ctx, span := tracing.Tracer().Start(ctx, fn)
+	defer func() { tracing.End(span, err) }()
+
+	link, err = s.lookup(ctx, fn, shortenURL)
 	if err != nil {
-		return "", e.WrapError(fn, err)
+		return storage.Link{}, false, err
 	}
+	return s.activeLink(fn, link)
+}
 
-	return fullURL, nil
+// lookup returns the enabled link whatever its window is.
+func (s *Service) lookup(ctx context.Context, fn string, shortenURL string) (storage.Link, error) {
+	if shortenURL == "" {
+		return storage.Link{}, domainError.InvalidArgument(URLField, "short URL must not be empty")
+	}
+
+	link, err := s.Storager.Resolve(ctx, shortenURL)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return storage.Link{}, domainError.URLNotFound(e.WrapError(fn, err))
+	} else if errors.Is(err, storage.ErrURLDisabled) {
+		return storage.Link{}, domainError.URLDisabled(e.WrapError(fn, err))
+	} else if err != nil {
+		return storage.Link{}, storageError(fn, err)
+	}
+
+	return link, nil
+}
+
+// storageError maps unexpected storage errors, a storage that is known to be down is reported as retryable.
+func storageError(fn string, err error) error {
+	if errors.Is(err, storage.ErrUnavailable) {
+		return domainError.Unavailable(e.WrapError(fn, err))
+	}
+	return domainError.Internal(e.WrapError(fn, err))
 }
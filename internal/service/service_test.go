@@ -1,16 +1,18 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
+	"urlShortener/internal/domainError"
 	"urlShortener/internal/lib/linkShortening/hashByID"
 	"urlShortener/internal/storage"
 )
 
 const (
-	getFullURL    = "GetFullURL"
+	resolve       = "Resolve"
 	saveURL       = "SaveURL"
 	getShortenURL = "GetShortenURL"
 	hash          = "Hash"
@@ -20,17 +22,17 @@ type mockStorager struct {
 	mock.Mock
 }
 
-func (m *mockStorager) SaveURL(urlToSave string, shortenURL string) error {
-	args := m.Called(urlToSave, shortenURL)
+func (m *mockStorager) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	args := m.Called(urlToSave, shortenURL, opts)
 	return args.Error(0)
 }
 
-func (m *mockStorager) GetFullURL(shortenURL string) (string, error) {
+func (m *mockStorager) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
 	args := m.Called(shortenURL)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(storage.Link), args.Error(1)
 }
 
-func (m *mockStorager) GetShortenURL(fullURL string) (string, error) {
+func (m *mockStorager) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
 	args := m.Called(fullURL)
 	return args.String(0), args.Error(1)
 }
@@ -49,13 +51,13 @@ func TestGetShortenURLNotFound(t *testing.T) {
 	mockHash := &mockHasher{}
 	service := New(mockStorage, mockHash)
 
-	fullurl := "ozon.ru"
+	fullurl := "https://ozon.ru"
 	expextedShortenURL := "aaaaaaaaaa"
 	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
 	mockHash.On(hash).Return(expextedShortenURL, nil)
-	mockStorage.On(saveURL, fullurl, expextedShortenURL).Return(nil)
+	mockStorage.On(saveURL, fullurl, expextedShortenURL, storage.Options{}).Return(nil)
 
-	resultShortenURL, err := service.GetShortenURL(fullurl)
+	resultShortenURL, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
 	assert.NoError(t, err)
 	assert.Equal(t, resultShortenURL, expextedShortenURL)
 
@@ -68,11 +70,11 @@ func TestGetShortenURLFound(t *testing.T) {
 	mockHash := &mockHasher{}
 	service := New(mockStorage, mockHash)
 
-	fullurl := "ozon.ru"
+	fullurl := "https://ozon.ru"
 	expextedShortenURL := "aaaaaaaaaa"
 	mockStorage.On(getShortenURL, fullurl).Return(expextedShortenURL, nil)
 
-	resultShortenURL, err := service.GetShortenURL(fullurl)
+	resultShortenURL, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
 	assert.NoError(t, err)
 	assert.Equal(t, resultShortenURL, expextedShortenURL)
 
@@ -84,27 +86,42 @@ func TestGetShortenUnexpectedError(t *testing.T) {
 	mockHash := &mockHasher{}
 	service := New(mockStorage, mockHash)
 
-	fullurl := "ozon.ru"
+	fullurl := "https://ozon.ru"
 	mockStorage.On(getShortenURL, fullurl).Return("", errors.New("unknown"))
 
-	_, err := service.GetShortenURL(fullurl)
+	_, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
 	assert.Error(t, err)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
 	assert.True(t, mockHash.AssertExpectations(t))
 }
 
+func TestGetShortenStorageUnavailable(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrUnavailable)
+
+	_, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
+	assert.Equal(t, domainError.CodeUnavailable, domainError.From(err).Code)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+}
+
 func TestGetShortenOverflow(t *testing.T) {
 	mockStorage := &mockStorager{}
 	mockHash := &mockHasher{}
 	service := New(mockStorage, mockHash)
 
-	fullurl := "ozon.ru"
+	fullurl := "https://ozon.ru"
 	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
 	mockHash.On(hash).Return("", hashByID.ErrOverFlow)
 
-	_, err := service.GetShortenURL(fullurl)
+	_, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
 	assert.True(t, errors.Is(err, hashByID.ErrOverFlow))
+	assert.Equal(t, domainError.CodeIDSpaceExhausted, domainError.From(err).Code)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
 	assert.True(t, mockHash.AssertExpectations(t))
@@ -115,11 +132,11 @@ func TestGetShortenUnknownError(t *testing.T) {
 	mockHash := &mockHasher{}
 	service := New(mockStorage, mockHash)
 
-	fullurl := "ozon.ru"
+	fullurl := "https://ozon.ru"
 	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
 	mockHash.On(hash).Return("", errors.New("wtf just happend i fell asleep"))
 
-	_, err := service.GetShortenURL(fullurl)
+	_, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
 	assert.Error(t, err)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
@@ -131,13 +148,13 @@ func TestGetShortenSavingError(t *testing.T) {
 	mockHash := &mockHasher{}
 	service := New(mockStorage, mockHash)
 
-	fullurl := "ozon.ru"
+	fullurl := "https://ozon.ru"
 	expextedShortenURL := "aaaaaaaaaa"
 	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
 	mockHash.On(hash).Return(expextedShortenURL, nil)
-	mockStorage.On(saveURL, fullurl, expextedShortenURL).Return(errors.New("unknown"))
+	mockStorage.On(saveURL, fullurl, expextedShortenURL, storage.Options{}).Return(errors.New("unknown"))
 
-	_, err := service.GetShortenURL(fullurl)
+	_, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
 	assert.Error(t, err)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
@@ -151,11 +168,11 @@ func TestGetFullURLSuccess(t *testing.T) {
 
 	expectedFullURL := "ozon.ru"
 	shortenURL := "aaaaaaaaaa"
-	mockStorage.On(getFullURL, shortenURL).Return(expectedFullURL, nil)
+	mockStorage.On(resolve, shortenURL).Return(storage.Link{FullURL: expectedFullURL}, nil)
 
-	resultFullURL, err := service.GetFullURL(shortenURL)
+	link, err := service.Resolve(context.Background(), shortenURL)
 	assert.NoError(t, err)
-	assert.Equal(t, resultFullURL, expectedFullURL)
+	assert.Equal(t, link.FullURL, expectedFullURL)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
 	assert.True(t, mockHash.AssertExpectations(t))
@@ -167,10 +184,26 @@ func TestGetFullURLNotFound(t *testing.T) {
 	service := New(mockStorage, mockHash)
 
 	shortenURL := "aaaaaaaaaa"
-	mockStorage.On(getFullURL, shortenURL).Return("", storage.ErrURLNotFound)
+	mockStorage.On(resolve, shortenURL).Return(storage.Link{}, storage.ErrURLNotFound)
 
-	_, err := service.GetFullURL(shortenURL)
+	_, err := service.Resolve(context.Background(), shortenURL)
 	assert.True(t, errors.Is(err, storage.ErrURLNotFound))
+	assert.Equal(t, domainError.CodeURLNotFound, domainError.From(err).Code)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+	assert.True(t, mockHash.AssertExpectations(t))
+}
+
+func TestGetFullURLDisabled(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	shortenURL := "aaaaaaaaaa"
+	mockStorage.On(resolve, shortenURL).Return(storage.Link{}, storage.ErrURLDisabled)
+
+	_, err := service.Resolve(context.Background(), shortenURL)
+	assert.Equal(t, domainError.CodeURLDisabled, domainError.From(err).Code)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
 	assert.True(t, mockHash.AssertExpectations(t))
@@ -182,11 +215,222 @@ func TestGetFullURLUnknownError(t *testing.T) {
 	service := New(mockStorage, mockHash)
 
 	shortenURL := "aaaaaaaaaa"
-	mockStorage.On(getFullURL, shortenURL).Return("", errors.New("unknown"))
+	mockStorage.On(resolve, shortenURL).Return(storage.Link{}, errors.New("unknown"))
 
-	_, err := service.GetFullURL(shortenURL)
-	assert.Error(t, err)
+	_, err := service.Resolve(context.Background(), shortenURL)
+	assert.Equal(t, domainError.CodeInternal, domainError.From(err).Code)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+	assert.True(t, mockHash.AssertExpectations(t))
+}
+
+func TestGetShortenURLInvalidURL(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	_, err := service.GetShortenURL(context.Background(), "ozon.ru", storage.Options{}, "")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Contains(t, domainErr.Details, URLField)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+	assert.True(t, mockHash.AssertExpectations(t))
+}
+
+func TestGetShortenURLSavedConcurrently(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound).Once()
+	mockHash.On(hash).Return("aaaaaaaaab", nil)
+	mockStorage.On(saveURL, fullurl, "aaaaaaaaab", storage.Options{}).Return(storage.ErrURLExists)
+	mockStorage.On(getShortenURL, fullurl).Return("aaaaaaaaaa", nil).Once()
+
+	shortenURL, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
 
 	assert.True(t, mockStorage.AssertExpectations(t))
 	assert.True(t, mockHash.AssertExpectations(t))
 }
+
+func TestGetShortenURLConflict(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	expextedShortenURL := "aaaaaaaaaa"
+	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
+	mockHash.On(hash).Return(expextedShortenURL, nil)
+	mockStorage.On(saveURL, fullurl, expextedShortenURL, storage.Options{}).Return(storage.ErrURLExists)
+
+	_, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{}, "")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeURLConflict, domainErr.Code)
+	assert.True(t, domainErr.Retryable)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+	assert.True(t, mockHash.AssertExpectations(t))
+}
+
+func TestGetFullURLEmpty(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	_, err := service.Resolve(context.Background(), "")
+	assert.Equal(t, domainError.CodeInvalidArgument, domainError.From(err).Code)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+}
+
+func TestGetShortenURLInvalidRedirect(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", storage.Options{RedirectStatus: 200}, "")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Contains(t, domainErr.Details, RedirectField)
+}
+
+func TestGetShortenURLInvalidQuery(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", storage.Options{Query: "merge"}, "")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Contains(t, domainErr.Details, QueryField)
+}
+
+func TestGetShortenURLInvalidTarget(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	targets := storage.Targets{IOS: "itms-apps://apps.apple.com/app/id1", Android: "play.google.com"}
+	_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", storage.Options{Targets: targets}, "")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+	assert.Equal(t, []string{TargetsField + ".android"}, domainErr.Fields())
+}
+
+func TestGetShortenURLInvalidCountry(t *testing.T) {
+	service := New(&mockStorager{}, &mockHasher{})
+
+	tests := []struct {
+		rules map[string]string
+		field string
+	}{
+		{map[string]string{"de": "https://ozon.de", "kz": "ozon.kz"}, CountriesField + ".KZ"},
+		{map[string]string{"DEU": "https://ozon.de"}, CountriesField + ".DEU"},
+	}
+	for _, tt := range tests {
+		opts := storage.Options{Countries: storage.NewCountries(tt.rules)}
+		_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", opts, "")
+		domainErr := domainError.From(err)
+		assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+		assert.Equal(t, []string{tt.field}, domainErr.Fields())
+	}
+}
+
+func TestGetShortenURLInvalidVariants(t *testing.T) {
+	service := New(&mockStorager{}, &mockHasher{})
+
+	ab := func(variants ...storage.Variant) storage.Variants {
+		return storage.NewVariants(variants)
+	}
+	tests := []struct {
+		opts  storage.Options
+		field string
+	}{
+		{storage.Options{Variants: ab(storage.Variant{URL: "https://ozon.ru/a", Weight: 1})}, VariantsField},
+		{storage.Options{Variants: ab(storage.Variant{URL: "https://ozon.ru/a", Weight: 1}, storage.Variant{URL: "ozon.ru/b", Weight: 1})}, VariantsField + ".1"},
+		{storage.Options{Variants: ab(storage.Variant{URL: "https://ozon.ru/a", Weight: 1}, storage.Variant{URL: "https://ozon.ru/b"})}, VariantsField + ".1"},
+		{storage.Options{Variants: ab(storage.Variant{Name: "new", URL: "https://ozon.ru/a", Weight: 1}, storage.Variant{Name: "new", URL: "https://ozon.ru/b", Weight: 1})}, VariantsField + ".1"},
+		{storage.Options{Variants: ab(storage.Variant{Name: "new page", URL: "https://ozon.ru/a", Weight: 1}, storage.Variant{URL: "https://ozon.ru/b", Weight: 1})}, VariantsField + ".0"},
+		{storage.Options{Sticky: storage.StickyCookie}, StickyField},
+		{storage.Options{Sticky: "session"}, StickyField},
+	}
+	for _, tt := range tests {
+		_, err := service.GetShortenURL(context.Background(), "https://ozon.ru", tt.opts, "")
+		domainErr := domainError.From(err)
+		assert.Equal(t, domainError.CodeInvalidArgument, domainErr.Code)
+		assert.Equal(t, []string{tt.field}, domainErr.Fields())
+	}
+}
+
+func TestGetShortenURLSavesOptions(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	opts := storage.Options{RedirectStatus: 308}
+	mockStorage.On(getShortenURL, fullurl).Return("", storage.ErrURLNotFound)
+	mockHash.On(hash).Return("aaaaaaaaaa", nil)
+	mockStorage.On(saveURL, fullurl, "aaaaaaaaaa", opts).Return(nil)
+
+	shortenURL, err := service.GetShortenURL(context.Background(), fullurl, opts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+}
+
+func TestGetShortenURLOtherOptions(t *testing.T) {
+	mockStorage := &mockStorager{}
+	mockHash := &mockHasher{}
+	service := New(mockStorage, mockHash)
+
+	fullurl := "https://ozon.ru"
+	mockStorage.On(getShortenURL, fullurl).Return("aaaaaaaaaa", nil)
+	mockStorage.On(resolve, "aaaaaaaaaa").Return(storage.Link{FullURL: fullurl, Options: storage.Options{RedirectStatus: 301}}, nil)
+
+	shortenURL, err := service.GetShortenURL(context.Background(), fullurl, storage.Options{RedirectStatus: 301}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
+
+	_, err = service.GetShortenURL(context.Background(), fullurl, storage.Options{RedirectStatus: 307}, "")
+	domainErr := domainError.From(err)
+	assert.Equal(t, domainError.CodeURLConflict, domainErr.Code)
+	assert.False(t, domainErr.Retryable)
+
+	assert.True(t, mockStorage.AssertExpectations(t))
+	mockHash.AssertNotCalled(t, hash)
+}
+
+func TestGetShortenURLSameRules(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{})
+
+	variants := []storage.Variant{{URL: "https://ozon.ru/a", Weight: 1}, {URL: "https://ozon.ru/b", Weight: 1}}
+	stored := storage.Options{
+		Countries: storage.NewCountries(map[string]string{"DE": "https://ozon.de", "KZ": "https://ozon.kz"}),
+		Variants:  storage.NewVariants(variants),
+	}
+	fullurl := "https://ozon.ru"
+	mockStorage.On(getShortenURL, fullurl).Return("aaaaaaaaaa", nil)
+	mockStorage.On(resolve, "aaaaaaaaaa").Return(storage.Link{FullURL: fullurl, Options: stored}, nil)
+
+	// правила сравниваются по содержимому, а не по виду, в котором их прислали
+	same := storage.Options{
+		Countries: storage.NewCountries(map[string]string{"kz": "https://ozon.kz", "de": "https://ozon.de"}),
+		Variants:  storage.NewVariants([]storage.Variant{{Name: "A", URL: "https://ozon.ru/a", Weight: 1}, {URL: "https://ozon.ru/b", Weight: 1}}),
+	}
+	shortenURL, err := service.GetShortenURL(context.Background(), fullurl, same, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
+
+	variants[1].Weight = 2
+	other := storage.Options{Countries: stored.Countries, Variants: storage.NewVariants(variants)}
+	_, err = service.GetShortenURL(context.Background(), fullurl, other, "")
+	assert.Equal(t, domainError.CodeURLConflict, domainError.From(err).Code)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+// TargetsField - имя поля с адресами по устройствам, ошибка называет устройство: targets.ios.
+const TargetsField = "targets"
+
+// TargetStats are the redirects of a link to one target: the device, the country code or "default".
+type TargetStats struct {
+	Target string
+	Clicks uint64
+}
+
+// validateTargets checks that every device target is an absolute URL. Схемы приложений вроде
+// itms-apps:// и market:// тоже подходят.
+func validateTargets(targets storage.Targets) error {
+	for _, target := range []struct{ device, url string }{
+		{"ios", targets.IOS},
+		{"android", targets.Android},
+		{"desktop", targets.Desktop},
+		{"bot", targets.Bot},
+	} {
+		if target.url == "" {
+			continue
+		}
+		if err := validate.Var(target.url, "url"); err != nil {
+			return domainError.InvalidArgument(TargetsField+"."+target.device, "target must be an absolute URL")
+		}
+	}
+	return nil
+}
+
+// CountTarget records the target a redirect of the link chose in the click analytics.
+// Своего span нет: редирект и так трассируется через Resolve.
+func (s *Service) CountTarget(ctx context.Context, shortenURL string, target string) error {
+	const fn = "service.CountTarget"
+
+	counter, ok := s.Storager.(storage.TargetCounter)
+	if !ok {
+		return domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+	err := counter.CountTarget(ctx, shortenURL, target)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return domainError.URLNotFound(e.WrapError(fn, err))
+	} else if errors.Is(err, storage.ErrNotSupported) {
+		return domainError.NotSupported(e.WrapError(fn, err))
+	} else if err != nil {
+		return storageError(fn, err)
+	}
+	return nil
+}
+
+// sortTargets orders the targets by name, so the stats of a link always read the same.
+func sortTargets(clicks map[string]uint64) []TargetStats {
+	stats := make([]TargetStats, 0, len(clicks))
+	for target, count := range clicks {
+		stats = append(stats, TargetStats{Target: target, Clicks: count})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Target < stats[j].Target })
+	return stats
+}
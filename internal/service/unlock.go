@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+// ErrNoPassthrough is returned for a path after the code of a link that doesn't pass it through.
+var ErrNoPassthrough = errors.New("link doesn't pass the path through")
+
+// Unlock returns the enabled link to redirect to if the password opens it, an open link needs no password.
+// Wrong passwords are counted per link and client, a client that made too many is refused for a while
+// even with the right password. Every unlock of a link with MaxClicks uses one of its clicks.
+// The fallback of a link outside of its window is open and doesn't use clicks.
+// Своего span нет: редирект и так трассируется через Resolve.
+func (s *Service) Unlock(ctx context.Context, shortenURL, password, client string) (storage.Link, error) {
+	return s.UnlockPath(ctx, shortenURL, "", password, client)
+}
+
+// UnlockPath unlocks the link for a request with the path suffix after the code. A link without
+// PathPassthrough has no such paths, the request gets URL_NOT_FOUND before a click is used.
+func (s *Service) UnlockPath(ctx context.Context, shortenURL, suffix, password, client string) (storage.Link, error) {
+	const fn = "service.UnlockPath"
+
+	link, fallback, err := s.resolveActive(ctx, shortenURL)
+	if err != nil {
+		return link, err
+	}
+	if suffix != "" && !link.PathPassthrough {
+		return storage.Link{}, domainError.URLNotFound(e.WrapError(fn, ErrNoPassthrough))
+	}
+	if fallback {
+		return link, nil
+	}
+	if link.PasswordHash != "" {
+		if err = s.checkPassword(fn, shortenURL, link.PasswordHash, password, client); err != nil {
+			return storage.Link{}, err
+		}
+	}
+	if link.MaxClicks > 0 {
+		if link.ClicksLeft, err = s.click(ctx, fn, shortenURL); err != nil {
+			return storage.Link{}, err
+		}
+	}
+	return link, nil
+}
+
+// click uses one click of the link and returns how many are left.
+func (s *Service) click(ctx context.Context, fn string, shortenURL string) (uint64, error) {
+	clicker, ok := s.Storager.(storage.Clicker)
+	if !ok {
+		return 0, domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+	left, err := clicker.Click(ctx, shortenURL)
+	if errors.Is(err, storage.ErrClicksExhausted) {
+		return 0, domainError.ClicksExhausted(e.WrapError(fn, err))
+	} else if errors.Is(err, storage.ErrNotSupported) {
+		return 0, domainError.NotSupported(e.WrapError(fn, err))
+	} else if err != nil {
+		return 0, storageError(fn, err)
+	}
+	return left, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/lib/linkShortening/hashByID"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+func TestUnlockMaxClicks(t *testing.T) {
+	ctx := context.Background()
+	service := New(inMemmory.New(), hashByID.New(0))
+
+	code, err := service.GetShortenURL(ctx, "https://ozon.ru", storage.Options{MaxClicks: 1}, "secret")
+	assert.NoError(t, err)
+
+	// неверный пароль не тратит переход
+	_, err = service.Unlock(ctx, code, "guess", "10.0.0.1")
+	assert.Equal(t, domainError.CodePasswordRequired, domainError.From(err).Code)
+
+	link, err := service.Unlock(ctx, code, "secret", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), link.ClicksLeft)
+
+	_, err = service.Unlock(ctx, code, "secret", "10.0.0.1")
+	assert.Equal(t, domainError.CodeClicksExhausted, domainError.From(err).Code)
+
+	// превью не тратит переходы
+	_, err = service.Inspect(ctx, code)
+	assert.NoError(t, err)
+}
+
+func TestUnlockPathWithoutPassthrough(t *testing.T) {
+	ctx := context.Background()
+	service := New(inMemmory.New(), hashByID.New(0))
+
+	code, err := service.GetShortenURL(ctx, "https://ozon.ru", storage.Options{MaxClicks: 1}, "")
+	assert.NoError(t, err)
+
+	_, err = service.UnlockPath(ctx, code, "guide", "", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrNoPassthrough)
+	assert.Equal(t, domainError.CodeURLNotFound, domainError.From(err).Code)
+
+	link, err := service.UnlockPath(ctx, code, "", "", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), link.ClicksLeft)
+
+	passthrough, err := service.GetShortenURL(ctx, "https://ya.ru", storage.Options{MaxClicks: 1, PathPassthrough: true}, "")
+	assert.NoError(t, err)
+	_, err = service.UnlockPath(ctx, passthrough, "guide", "", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestUnlockMaxClicksContention(t *testing.T) {
+	const (
+		maxClicks = 50
+		visitors  = 5000
+	)
+	ctx := context.Background()
+	service := New(inMemmory.New(), hashByID.New(0))
+	code, err := service.GetShortenURL(ctx, "https://ozon.ru", storage.Options{MaxClicks: maxClicks}, "")
+	assert.NoError(t, err)
+
+	var (
+		wg                  sync.WaitGroup
+		start               = make(chan struct{})
+		redirected, refused atomic.Int64
+	)
+	for i := 0; i < visitors; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := service.Unlock(ctx, code, "", "")
+			if err == nil {
+				redirected.Add(1)
+			} else if domainError.From(err).Code == domainError.CodeClicksExhausted {
+				refused.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(maxClicks), redirected.Load())
+	assert.Equal(t, int64(visitors-maxClicks), refused.Load())
+}
+
+func TestUnlockClicksNotSupported(t *testing.T) {
+	mockStorage := &mockStorager{}
+	service := New(mockStorage, &mockHasher{})
+	mockStorage.On(resolve, "limited").Return(storage.Link{Options: storage.Options{MaxClicks: 1}, ClicksLeft: 1}, nil)
+
+	_, err := service.Unlock(context.Background(), "limited", "", "")
+	assert.Equal(t, domainError.CodeNotSupported, domainError.From(err).Code)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"urlShortener/internal/domainError"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+// VariantsField и StickyField - имена полей A/B ссылки, ошибка называет вариант по номеру: variants.1.
+const (
+	VariantsField = "variants"
+	StickyField   = "sticky"
+)
+
+// maxVariants - сколько адресов может быть у одной ссылки.
+const maxVariants = 26
+
+// variantName - имя варианта попадает в cookie посетителя и в метрики, поэтому без пробелов и спецсимволов.
+var variantName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// VariantStats are the clicks of a variant of a link.
+type VariantStats struct {
+	storage.Variant
+	Clicks uint64
+}
+
+// validateVariants checks that an A/B link has at least two variants with unique names, absolute URLs
+// and positive weights, and that stickiness is set only for such a link.
+func validateVariants(opts storage.Options) error {
+	if !storage.ValidStickiness(opts.Sticky) {
+		return domainError.InvalidArgument(StickyField, "sticky must be cookie or hash")
+	}
+	variants := opts.Variants
+	if len(variants) == 0 {
+		if opts.Sticky != storage.StickyNone {
+			return domainError.InvalidArgument(StickyField, "sticky needs variants")
+		}
+		return nil
+	}
+	if len(variants) < 2 || len(variants) > maxVariants {
+		return domainError.InvalidArgument(VariantsField, "variants must have from 2 to 26 URLs")
+	}
+
+	names := make(map[string]struct{}, len(variants))
+	for i, variant := range variants {
+		field := VariantsField + "." + strconv.Itoa(i)
+		if !variantName.MatchString(variant.Name) {
+			return domainError.InvalidArgument(field, "variant name must be up to 32 letters, digits, _ or -")
+		}
+		if _, ok := names[variant.Name]; ok {
+			return domainError.InvalidArgument(field, "variant names must be unique")
+		}
+		names[variant.Name] = struct{}{}
+		if err := validate.Var(variant.URL, "required,url"); err != nil {
+			return domainError.InvalidArgument(field, "variant must be an absolute URL")
+		}
+		if variant.Weight == 0 {
+			return domainError.InvalidArgument(field, "variant weight must be positive")
+		}
+	}
+	return nil
+}
+
+// CountVariant records a redirect to the variant of the link in the click analytics.
+// Своего span нет: редирект и так трассируется через Resolve.
+func (s *Service) CountVariant(ctx context.Context, shortenURL string, variant string) error {
+	const fn = "service.CountVariant"
+
+	counter, ok := s.Storager.(storage.VariantCounter)
+	if !ok {
+		return domainError.NotSupported(e.WrapError(fn, storage.ErrNotSupported))
+	}
+	err := counter.CountVariant(ctx, shortenURL, variant)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return domainError.URLNotFound(e.WrapError(fn, err))
+	} else if errors.Is(err, storage.ErrNotSupported) {
+		return domainError.NotSupported(e.WrapError(fn, err))
+	} else if err != nil {
+		return storageError(fn, err)
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"sync"
+	"time"
+	"urlShortener/internal/storage"
+)
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// Storage stops calling the wrapped storage after several failures in a row and returns
+// storage.ErrUnavailable at once, so requests don't wait for a database that is down.
+// After the cooldown one call is let through, its success closes the breaker.
+type Storage struct {
+	storage   storage.Storager
+	threshold int
+	cooldown  time.Duration
+	logger    *logrus.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+func New(storage storage.Storager, threshold int, cooldown time.Duration, logger *logrus.Logger) *Storage {
+	return &Storage{
+		storage:   storage,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *Storage) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	if !s.allow() {
+		return storage.ErrUnavailable
+	}
+	err := s.storage.SaveURL(ctx, urlToSave, shortenURL, opts)
+	s.done(err)
+	return err
+}
+
+func (s *Storage) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	if !s.allow() {
+		return storage.Link{}, storage.ErrUnavailable
+	}
+	link, err := s.storage.Resolve(ctx, shortenURL)
+	s.done(err)
+	return link, err
+}
+
+func (s *Storage) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	if !s.allow() {
+		return "", storage.ErrUnavailable
+	}
+	shortenURL, err := s.storage.GetShortenURL(ctx, fullURL)
+	s.done(err)
+	return shortenURL, err
+}
+
+// Ping always reaches the storage, so readiness shows the real state. A successful ping closes the breaker.
+func (s *Storage) Ping(ctx context.Context) error {
+	pinger, ok := s.storage.(storage.Pinger)
+	if !ok {
+		return nil
+	}
+	err := pinger.Ping(ctx)
+	s.done(err)
+	return err
+}
+
+func (s *Storage) GetLink(ctx context.Context, code string) (link storage.Link, err error) {
+	err = s.manage(func(manager storage.Manager) error {
+		link, err = manager.GetLink(ctx, code)
+		return err
+	})
+	return link, err
+}
+
+func (s *Storage) FindByFullURL(ctx context.Context, fullURL string) (link storage.Link, err error) {
+	err = s.manage(func(manager storage.Manager) error {
+		link, err = manager.FindByFullURL(ctx, fullURL)
+		return err
+	})
+	return link, err
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, code string) error {
+	return s.manage(func(manager storage.Manager) error {
+		return manager.DeleteLink(ctx, code)
+	})
+}
+
+func (s *Storage) SetDisabled(ctx context.Context, code string, disabled bool) error {
+	return s.manage(func(manager storage.Manager) error {
+		return manager.SetDisabled(ctx, code, disabled)
+	})
+}
+
+func (s *Storage) ReassignDomain(ctx context.Context, from string, to string) (changed uint64, err error) {
+	err = s.manage(func(manager storage.Manager) error {
+		changed, err = manager.ReassignDomain(ctx, from, to)
+		return err
+	})
+	return changed, err
+}
+
+func (s *Storage) Stats(ctx context.Context) (stats storage.Stats, err error) {
+	err = s.manage(func(manager storage.Manager) error {
+		stats, err = manager.Stats(ctx)
+		return err
+	})
+	return stats, err
+}
+
+func (s *Storage) ForEachLink(ctx context.Context, visit func(link storage.Link) error) error {
+	lister, ok := s.storage.(storage.Lister)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return storage.ErrUnavailable
+	}
+	err := lister.ForEachLink(ctx, visit)
+	s.done(err)
+	return err
+}
+
+func (s *Storage) RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (uint64, error) {
+	restorer, ok := s.storage.(storage.Restorer)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return 0, storage.ErrUnavailable
+	}
+	id, err := restorer.RestoreLink(ctx, link, overwrite)
+	s.done(err)
+	return id, err
+}
+
+func (s *Storage) Click(ctx context.Context, code string) (uint64, error) {
+	clicker, ok := s.storage.(storage.Clicker)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return 0, storage.ErrUnavailable
+	}
+	left, err := clicker.Click(ctx, code)
+	s.done(err)
+	return left, err
+}
+
+func (s *Storage) CountVariant(ctx context.Context, code string, variant string) error {
+	counter, ok := s.storage.(storage.VariantCounter)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return storage.ErrUnavailable
+	}
+	err := counter.CountVariant(ctx, code, variant)
+	s.done(err)
+	return err
+}
+
+func (s *Storage) VariantClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	counter, ok := s.storage.(storage.VariantCounter)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return nil, storage.ErrUnavailable
+	}
+	clicks, err := counter.VariantClicks(ctx, code)
+	s.done(err)
+	return clicks, err
+}
+
+func (s *Storage) CountTarget(ctx context.Context, code string, target string) error {
+	counter, ok := s.storage.(storage.TargetCounter)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return storage.ErrUnavailable
+	}
+	err := counter.CountTarget(ctx, code, target)
+	s.done(err)
+	return err
+}
+
+func (s *Storage) TargetClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	counter, ok := s.storage.(storage.TargetCounter)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return nil, storage.ErrUnavailable
+	}
+	clicks, err := counter.TargetClicks(ctx, code)
+	s.done(err)
+	return clicks, err
+}
+
+func (s *Storage) manage(call func(manager storage.Manager) error) error {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	if !s.allow() {
+		return storage.ErrUnavailable
+	}
+	err := call(manager)
+	s.done(err)
+	return err
+}
+
+func (s *Storage) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.state {
+	case open:
+		if s.now().Sub(s.openedAt) < s.cooldown {
+			return false
+		}
+		// пропускаем один пробный вызов, остальные ждут его результата
+		s.state = halfOpen
+		s.logger.Info("storage.breaker: probing the storage")
+		return true
+	case halfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (s *Storage) done(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		// клиент ушел, о хранилище это ничего не говорит: следующий вызов снова будет пробным
+		if s.state == halfOpen {
+			s.state = open
+		}
+		return
+	}
+
+	if !failed(err) {
+		if s.state != closed {
+			s.logger.Info("storage.breaker: storage is available again")
+		}
+		s.state = closed
+		s.failures = 0
+		return
+	}
+
+	s.failures++
+	if s.state == halfOpen || (s.state == closed && s.failures >= s.threshold) {
+		s.state = open
+		s.openedAt = s.now()
+		s.logger.WithField("failures", s.failures).Warnf("storage.breaker: storage is unavailable, failing fast for %s: %v", s.cooldown, err)
+	}
+}
+
+// failed reports whether err means the storage is unhealthy. Answers like "not found" are successful calls.
+func failed(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, storage.ErrURLNotFound),
+		errors.Is(err, storage.ErrURLExists),
+		errors.Is(err, storage.ErrURLDisabled),
+		errors.Is(err, storage.ErrClicksExhausted),
+		errors.Is(err, storage.ErrNotSupported):
+		return false
+	default:
+		return true
+	}
+}
+
+// Close closes the wrapped storage if it holds connections.
+func (s *Storage) Close() error {
+	closer, ok := s.storage.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return closer.Close()
+}
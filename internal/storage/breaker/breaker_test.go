@@ -0,0 +1,115 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"urlShortener/internal/storage"
+)
+
+type mockStorager struct {
+	mock.Mock
+}
+
+func (m *mockStorager) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	args := m.Called(urlToSave, shortenURL, opts)
+	return args.Error(0)
+}
+
+func (m *mockStorager) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	args := m.Called(shortenURL)
+	return args.Get(0).(storage.Link), args.Error(1)
+}
+
+func (m *mockStorager) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	args := m.Called(fullURL)
+	return args.String(0), args.Error(1)
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestBreaker(st storage.Storager) (*Storage, *clock) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	c := &clock{now: time.Now()}
+	b := New(st, 2, time.Second, logger)
+	b.now = c.Now
+	return b, c
+}
+
+func TestOpensAfterFailures(t *testing.T) {
+	st := new(mockStorager)
+	st.On("Resolve", "aaaaaaaaaa").Return(storage.Link{}, errors.New("connection refused")).Twice()
+	b, _ := newTestBreaker(st)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Resolve(ctx, "aaaaaaaaaa")
+		assert.NotErrorIs(t, err, storage.ErrUnavailable)
+	}
+
+	_, err := b.Resolve(ctx, "aaaaaaaaaa")
+	assert.ErrorIs(t, err, storage.ErrUnavailable)
+	st.AssertNumberOfCalls(t, "Resolve", 2)
+}
+
+func TestNotFoundIsNotFailure(t *testing.T) {
+	st := new(mockStorager)
+	st.On("Resolve", "aaaaaaaaaa").Return(storage.Link{}, storage.ErrURLNotFound)
+	b, _ := newTestBreaker(st)
+
+	for i := 0; i < 3; i++ {
+		_, err := b.Resolve(context.Background(), "aaaaaaaaaa")
+		assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	}
+}
+
+func TestRecoversAfterCooldown(t *testing.T) {
+	st := new(mockStorager)
+	st.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(errors.New("connection refused")).Twice()
+	b, c := newTestBreaker(st)
+	ctx := context.Background()
+
+	_ = b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{})
+	_ = b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{})
+	assert.ErrorIs(t, b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}), storage.ErrUnavailable)
+
+	// пробный вызов снова неудачный: ждем еще один cooldown
+	c.now = c.now.Add(time.Second)
+	st.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(errors.New("connection refused")).Once()
+	assert.NotErrorIs(t, b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}), storage.ErrUnavailable)
+	assert.ErrorIs(t, b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}), storage.ErrUnavailable)
+
+	c.now = c.now.Add(time.Second)
+	st.ExpectedCalls = nil
+	st.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(nil)
+	assert.NoError(t, b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, b.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+}
+
+func TestHalfOpenLetsOneProbe(t *testing.T) {
+	st := new(mockStorager)
+	st.On("Resolve", "aaaaaaaaaa").Return(storage.Link{}, errors.New("connection refused")).Twice()
+	b, c := newTestBreaker(st)
+	ctx := context.Background()
+
+	_, _ = b.Resolve(ctx, "aaaaaaaaaa")
+	_, _ = b.Resolve(ctx, "aaaaaaaaaa")
+
+	c.now = c.now.Add(time.Second)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	// пробный вызов отменил клиент, следующий вызов снова пробный
+	b.done(context.Canceled)
+	assert.True(t, b.allow())
+}
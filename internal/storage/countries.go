@@ -0,0 +1,42 @@
+package storage
+
+import "strings"
+
+// Countries replace the URL of a link for visitors from some countries, the keys are
+// ISO 3166-1 alpha-2 codes in upper case.
+type Countries map[string]string
+
+// NewCountries builds the rules from country codes to URLs, codes are upper-cased and empty URLs dropped.
+func NewCountries(urls map[string]string) Countries {
+	rules := make(Countries, len(urls))
+	for country, url := range urls {
+		if url != "" {
+			rules[strings.ToUpper(country)] = url
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return rules
+}
+
+// ValidCountry reports whether code is an ISO 3166-1 alpha-2 code in either case, like DE or kz.
+func ValidCountry(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range strings.ToUpper(code) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// URL returns the URL for visitors from the country, empty if the link has no rule for it.
+func (c Countries) URL(country string) string {
+	if country == "" {
+		return ""
+	}
+	return c[strings.ToUpper(country)]
+}
@@ -0,0 +1,125 @@
+package dualWrite
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+type Progress struct {
+	// Total is the number of links in the source when the backfill started, zero if the source can't count them.
+	Total uint64
+	// Copied links were written to the target by the backfill.
+	Copied uint64
+	// Existing links were already in the target, usually written through the dual-write.
+	Existing uint64
+}
+
+func (p Progress) Done() uint64 {
+	return p.Copied + p.Existing
+}
+
+// Backfill copies every link of the source to the target with its ID and metadata. Links that the target
+// already has are not overwritten. report is called after every `every` links and once at the end.
+func Backfill(ctx context.Context, source storage.Lister, target storage.Restorer, every uint64, report func(Progress)) (Progress, error) {
+	const fn = "storage.dualWrite.Backfill"
+
+	var progress Progress
+	if manager, ok := source.(storage.Manager); ok {
+		stats, err := manager.Stats(ctx)
+		if err != nil {
+			return progress, e.WrapError(fn, err)
+		}
+		progress.Total = stats.Links
+	}
+
+	err := source.ForEachLink(ctx, func(link storage.Link) error {
+		_, err := target.RestoreLink(ctx, link, false)
+		switch {
+		case err == nil:
+			progress.Copied++
+		case errors.Is(err, storage.ErrURLExists):
+			progress.Existing++
+		default:
+			return err
+		}
+
+		if every > 0 && progress.Done()%every == 0 {
+			report(progress)
+		}
+		return nil
+	})
+	if err != nil {
+		return progress, e.WrapError(fn, err)
+	}
+
+	report(progress)
+	return progress, nil
+}
+
+type JobOptions struct {
+	Backfill      bool
+	Verify        bool
+	ProgressEvery uint64
+}
+
+// RunJob runs the backfill from source to target and then the verification, as enabled in opts.
+// It's meant to run in the background while the dual-write storage serves requests.
+func RunJob(ctx context.Context, source storage.Storager, target storage.Storager, opts JobOptions, logger *logrus.Logger) error {
+	const fn = "storage.dualWrite.RunJob"
+
+	sourceLister, ok := source.(storage.Lister)
+	if !ok {
+		return e.WrapError(fn, storage.ErrNotSupported)
+	}
+	targetLister, ok := target.(storage.Lister)
+	if !ok {
+		return e.WrapError(fn, storage.ErrNotSupported)
+	}
+	targetRestorer, ok := target.(storage.Restorer)
+	if !ok {
+		return e.WrapError(fn, storage.ErrNotSupported)
+	}
+
+	if opts.Backfill {
+		logger.Info("migration: backfill started")
+		progress, err := Backfill(ctx, sourceLister, targetRestorer, opts.ProgressEvery, func(progress Progress) {
+			logger.WithFields(logrus.Fields{
+				"total":    progress.Total,
+				"copied":   progress.Copied,
+				"existing": progress.Existing,
+			}).Info("migration: backfill progress")
+		})
+		if err != nil {
+			return e.WrapError(fn, err)
+		}
+		logger.WithFields(logrus.Fields{
+			"copied":   progress.Copied,
+			"existing": progress.Existing,
+		}).Info("migration: backfill finished")
+	}
+
+	if opts.Verify {
+		diff, err := Verify(ctx, sourceLister, targetLister)
+		if err != nil {
+			return e.WrapError(fn, err)
+		}
+
+		entry := logger.WithFields(logrus.Fields{
+			"source":          diff.Source,
+			"target":          diff.Target,
+			"missingInTarget": diff.MissingInTarget,
+			"missingInSource": diff.MissingInSource,
+			"mismatched":      diff.Mismatched,
+			"samples":         diff.Samples,
+		})
+		if diff.Equal() {
+			entry.Info("migration: storages are in sync")
+		} else {
+			entry.Warn("migration: storages differ")
+		}
+	}
+	return nil
+}
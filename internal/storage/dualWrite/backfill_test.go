@@ -0,0 +1,74 @@
+package dualWrite
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	source, target := inMemmory.New(), inMemmory.New()
+	assert.NoError(t, source.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, source.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+	assert.NoError(t, source.SaveURL(ctx, "https://vk.com", "cccccccccc", storage.Options{}))
+	// эту ссылку уже записала двойная запись
+	assert.NoError(t, target.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+
+	var reports []Progress
+	progress, err := Backfill(ctx, source, target, 2, func(progress Progress) {
+		reports = append(reports, progress)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Progress{Total: 3, Copied: 2, Existing: 1}, progress)
+	assert.Equal(t, []Progress{{Total: 3, Copied: 1, Existing: 1}, progress}, reports)
+
+	link, err := target.GetLink(ctx, "cccccccccc")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), link.ID)
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	source, target := inMemmory.New(), inMemmory.New()
+	assert.NoError(t, source.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, source.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+	assert.NoError(t, target.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, target.SaveURL(ctx, "https://vk.com", "cccccccccc", storage.Options{}))
+
+	diff, err := Verify(ctx, source, target)
+	assert.NoError(t, err)
+	assert.False(t, diff.Equal())
+	assert.Equal(t, Diff{
+		Source:          2,
+		Target:          2,
+		MissingInTarget: 1,
+		MissingInSource: 1,
+		Samples:         []string{"cccccccccc", "bbbbbbbbbb"},
+	}, diff)
+
+	assert.NoError(t, target.SetDisabled(ctx, "aaaaaaaaaa", true))
+	_, err = target.RestoreLink(ctx, storage.Link{Code: "bbbbbbbbbb", FullURL: "https://ya.ru"}, false)
+	assert.NoError(t, err)
+	assert.NoError(t, target.DeleteLink(ctx, "cccccccccc"))
+
+	diff, err = Verify(ctx, source, target)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), diff.Mismatched)
+	assert.Equal(t, []string{"aaaaaaaaaa"}, diff.Samples)
+}
+
+func TestRunJob(t *testing.T) {
+	ctx := context.Background()
+	source, target := inMemmory.New(), inMemmory.New()
+	assert.NoError(t, source.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+
+	err := RunJob(ctx, source, target, JobOptions{Backfill: true, Verify: true, ProgressEvery: 1000}, newTestLogger())
+	assert.NoError(t, err)
+
+	diff, err := Verify(ctx, source, target)
+	assert.NoError(t, err)
+	assert.True(t, diff.Equal())
+}
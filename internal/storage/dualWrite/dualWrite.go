@@ -0,0 +1,361 @@
+package dualWrite
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"urlShortener/internal/storage"
+)
+
+// Storage writes to both storages and reads from the primary one, falling back to the secondary
+// while the link is not found there. The primary decides the result of a write, a failed write
+// to the secondary is only logged: the verification pass finds such links.
+type Storage struct {
+	primary   storage.Storager
+	secondary storage.Storager
+	logger    *logrus.Logger
+}
+
+func New(primary storage.Storager, secondary storage.Storager, logger *logrus.Logger) *Storage {
+	return &Storage{
+		primary:   primary,
+		secondary: secondary,
+		logger:    logger,
+	}
+}
+
+func (s *Storage) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	const fn = "storage.dualWrite.SaveURL"
+
+	if err := s.primary.SaveURL(ctx, urlToSave, shortenURL, opts); err != nil {
+		return err
+	}
+
+	// если можно, копируем ссылку целиком, чтобы id и время создания совпадали в обоих хранилищах
+	var err error
+	manager, isManager := s.primary.(storage.Manager)
+	restorer, isRestorer := s.secondary.(storage.Restorer)
+	if isManager && isRestorer {
+		var link storage.Link
+		if link, err = manager.GetLink(ctx, shortenURL); err == nil {
+			_, err = restorer.RestoreLink(ctx, link, false)
+		}
+	} else {
+		err = s.secondary.SaveURL(ctx, urlToSave, shortenURL, opts)
+	}
+	s.logSecondary(fn, shortenURL, err)
+	return nil
+}
+
+func (s *Storage) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	link, err := s.primary.Resolve(ctx, shortenURL)
+	if !fallback(err) {
+		return link, err
+	}
+
+	link, secondaryErr := s.secondary.Resolve(ctx, shortenURL)
+	if errors.Is(secondaryErr, storage.ErrURLNotFound) {
+		return storage.Link{}, err
+	}
+	return link, secondaryErr
+}
+
+func (s *Storage) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	shortenURL, err := s.primary.GetShortenURL(ctx, fullURL)
+	if !fallback(err) {
+		return shortenURL, err
+	}
+
+	shortenURL, secondaryErr := s.secondary.GetShortenURL(ctx, fullURL)
+	if errors.Is(secondaryErr, storage.ErrURLNotFound) {
+		return "", err
+	}
+	return shortenURL, secondaryErr
+}
+
+// Ping checks only the primary storage: the service keeps working while the secondary is down.
+func (s *Storage) Ping(ctx context.Context) error {
+	pinger, ok := s.primary.(storage.Pinger)
+	if !ok {
+		return nil
+	}
+	return pinger.Ping(ctx)
+}
+
+func (s *Storage) GetLink(ctx context.Context, code string) (storage.Link, error) {
+	return s.readLink(ctx, func(manager storage.Manager) (storage.Link, error) {
+		return manager.GetLink(ctx, code)
+	})
+}
+
+func (s *Storage) FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error) {
+	return s.readLink(ctx, func(manager storage.Manager) (storage.Link, error) {
+		return manager.FindByFullURL(ctx, fullURL)
+	})
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, code string) error {
+	const fn = "storage.dualWrite.DeleteLink"
+
+	return s.writeLink(fn, code, func(manager storage.Manager) error {
+		return manager.DeleteLink(ctx, code)
+	})
+}
+
+func (s *Storage) SetDisabled(ctx context.Context, code string, disabled bool) error {
+	const fn = "storage.dualWrite.SetDisabled"
+
+	return s.writeLink(fn, code, func(manager storage.Manager) error {
+		return manager.SetDisabled(ctx, code, disabled)
+	})
+}
+
+// ReassignDomain returns the number of links changed in the primary storage.
+func (s *Storage) ReassignDomain(ctx context.Context, from string, to string) (uint64, error) {
+	const fn = "storage.dualWrite.ReassignDomain"
+
+	primary, secondary, err := s.managers()
+	if err != nil {
+		return 0, err
+	}
+
+	changed, err := primary.ReassignDomain(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	_, err = secondary.ReassignDomain(ctx, from, to)
+	s.logSecondary(fn, from, err)
+	return changed, nil
+}
+
+func (s *Storage) Stats(ctx context.Context) (storage.Stats, error) {
+	manager, ok := s.primary.(storage.Manager)
+	if !ok {
+		return storage.Stats{}, storage.ErrNotSupported
+	}
+	return manager.Stats(ctx)
+}
+
+func (s *Storage) ForEachLink(ctx context.Context, visit func(link storage.Link) error) error {
+	lister, ok := s.primary.(storage.Lister)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return lister.ForEachLink(ctx, visit)
+}
+
+// RestoreLink restores the link in the primary storage and copies it to the secondary with the ID the
+// primary stored it with.
+func (s *Storage) RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (uint64, error) {
+	const fn = "storage.dualWrite.RestoreLink"
+
+	primary, ok := s.primary.(storage.Restorer)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	secondary, ok := s.secondary.(storage.Restorer)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+
+	id, err := primary.RestoreLink(ctx, link, overwrite)
+	if err != nil {
+		return 0, err
+	}
+	link.ID = id
+	_, err = secondary.RestoreLink(ctx, link, overwrite)
+	s.logSecondary(fn, link.Code, err)
+	return id, nil
+}
+
+// Click uses the click in the primary storage and repeats it in the secondary. A link that is not copied
+// to the primary yet is clicked in the secondary only.
+func (s *Storage) Click(ctx context.Context, code string) (uint64, error) {
+	const fn = "storage.dualWrite.Click"
+
+	primary, ok := s.primary.(storage.Clicker)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	secondary, ok := s.secondary.(storage.Clicker)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+
+	left, err := primary.Click(ctx, code)
+	if errors.Is(err, storage.ErrClicksExhausted) {
+		// в primary ссылки может еще не быть: тогда клики считает secondary
+		if _, resolveErr := s.primary.Resolve(storage.WithPrimary(ctx), code); errors.Is(resolveErr, storage.ErrURLNotFound) {
+			return secondary.Click(ctx, code)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, secondaryErr := secondary.Click(ctx, code)
+	s.logSecondary(fn, code, secondaryErr)
+	return left, nil
+}
+
+// CountVariant counts the click in the primary storage and repeats it in the secondary. A link that is not
+// copied to the primary yet is counted in the secondary only.
+func (s *Storage) CountVariant(ctx context.Context, code string, variant string) error {
+	const fn = "storage.dualWrite.CountVariant"
+
+	primary, secondary, err := s.variantCounters()
+	if err != nil {
+		return err
+	}
+
+	err = primary.CountVariant(ctx, code, variant)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return secondary.CountVariant(ctx, code, variant)
+	}
+	if err != nil {
+		return err
+	}
+	s.logSecondary(fn, code, secondary.CountVariant(ctx, code, variant))
+	return nil
+}
+
+// VariantClicks reads the primary storage. The backfill doesn't copy clicks, so a link without them
+// in the primary is read from the secondary.
+func (s *Storage) VariantClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	primary, secondary, err := s.variantCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	clicks, err := primary.VariantClicks(ctx, code)
+	if err != nil || len(clicks) > 0 {
+		return clicks, err
+	}
+	return secondary.VariantClicks(ctx, code)
+}
+
+func (s *Storage) variantCounters() (storage.VariantCounter, storage.VariantCounter, error) {
+	primary, ok := s.primary.(storage.VariantCounter)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	secondary, ok := s.secondary.(storage.VariantCounter)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	return primary, secondary, nil
+}
+
+// CountTarget counts the redirect like CountVariant counts a click.
+func (s *Storage) CountTarget(ctx context.Context, code string, target string) error {
+	const fn = "storage.dualWrite.CountTarget"
+
+	primary, secondary, err := s.targetCounters()
+	if err != nil {
+		return err
+	}
+
+	err = primary.CountTarget(ctx, code, target)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return secondary.CountTarget(ctx, code, target)
+	}
+	if err != nil {
+		return err
+	}
+	s.logSecondary(fn, code, secondary.CountTarget(ctx, code, target))
+	return nil
+}
+
+// TargetClicks reads the primary storage and falls back to the secondary like VariantClicks.
+func (s *Storage) TargetClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	primary, secondary, err := s.targetCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	clicks, err := primary.TargetClicks(ctx, code)
+	if err != nil || len(clicks) > 0 {
+		return clicks, err
+	}
+	return secondary.TargetClicks(ctx, code)
+}
+
+func (s *Storage) targetCounters() (storage.TargetCounter, storage.TargetCounter, error) {
+	primary, ok := s.primary.(storage.TargetCounter)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	secondary, ok := s.secondary.(storage.TargetCounter)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	return primary, secondary, nil
+}
+
+func (s *Storage) managers() (storage.Manager, storage.Manager, error) {
+	primary, ok := s.primary.(storage.Manager)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	secondary, ok := s.secondary.(storage.Manager)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	return primary, secondary, nil
+}
+
+func (s *Storage) readLink(ctx context.Context, read func(manager storage.Manager) (storage.Link, error)) (storage.Link, error) {
+	primary, secondary, err := s.managers()
+	if err != nil {
+		return storage.Link{}, err
+	}
+
+	link, err := read(primary)
+	if !fallback(err) {
+		return link, err
+	}
+
+	link, secondaryErr := read(secondary)
+	if errors.Is(secondaryErr, storage.ErrURLNotFound) {
+		return storage.Link{}, err
+	}
+	return link, secondaryErr
+}
+
+// writeLink changes the link in both storages. A link that is not copied to the primary yet
+// is changed in the secondary only, so the result doesn't depend on the backfill progress.
+func (s *Storage) writeLink(fn string, code string, write func(manager storage.Manager) error) error {
+	primary, secondary, err := s.managers()
+	if err != nil {
+		return err
+	}
+
+	err = write(primary)
+	if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+		return err
+	}
+
+	secondaryErr := write(secondary)
+	if err != nil {
+		if secondaryErr == nil {
+			return nil
+		}
+		return err
+	}
+	if !errors.Is(secondaryErr, storage.ErrURLNotFound) {
+		s.logSecondary(fn, code, secondaryErr)
+	}
+	return nil
+}
+
+func (s *Storage) logSecondary(fn string, key string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.WithField("key", key).Errorf("%s: secondary storage is out of sync: %v", fn, err)
+}
+
+// fallback reports whether the secondary storage should be asked. A disabled link is an answer, not a miss.
+func fallback(err error) bool {
+	return err != nil && !errors.Is(err, storage.ErrURLDisabled)
+}
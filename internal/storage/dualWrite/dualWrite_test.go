@@ -0,0 +1,223 @@
+package dualWrite
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+type mockStorager struct {
+	mock.Mock
+}
+
+func (m *mockStorager) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	args := m.Called(urlToSave, shortenURL, opts)
+	return args.Error(0)
+}
+
+func (m *mockStorager) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	args := m.Called(shortenURL)
+	return args.Get(0).(storage.Link), args.Error(1)
+}
+
+func (m *mockStorager) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	args := m.Called(fullURL)
+	return args.String(0), args.Error(1)
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return logger
+}
+
+func TestSaveURLCopiesLink(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := inMemmory.New(), inMemmory.New()
+	st := New(primary, secondary, newTestLogger())
+
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{RedirectStatus: 308}))
+
+	primaryLink, err := primary.GetLink(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	secondaryLink, err := secondary.GetLink(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, primaryLink, secondaryLink)
+}
+
+func TestRestoreLinkKeepsPrimaryID(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := inMemmory.New(), inMemmory.New()
+	assert.NoError(t, primary.SaveURL(ctx, "https://ya.ru", "aaaaaaaaaa", storage.Options{}))
+	st := New(primary, secondary, newTestLogger())
+
+	id, err := st.RestoreLink(ctx, storage.Link{Code: "bbbbbbbbbb", FullURL: "https://ozon.ru"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), id)
+
+	secondaryLink, err := secondary.GetLink(ctx, "bbbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, id, secondaryLink.ID)
+}
+
+func TestSaveURLPrimaryFails(t *testing.T) {
+	primary, secondary := new(mockStorager), new(mockStorager)
+	primary.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(storage.ErrURLExists)
+	st := New(primary, secondary, newTestLogger())
+
+	err := st.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", storage.Options{})
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+	secondary.AssertNotCalled(t, "SaveURL", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSaveURLSecondaryFails(t *testing.T) {
+	primary, secondary := new(mockStorager), new(mockStorager)
+	primary.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(nil)
+	secondary.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(errors.New("connection refused"))
+	st := New(primary, secondary, newTestLogger())
+
+	assert.NoError(t, st.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	secondary.AssertExpectations(t)
+}
+
+func TestGetFullURLFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		primaryErr   error
+		secondaryURL string
+		secondaryErr error
+		wantURL      string
+		wantErr      error
+	}{
+		{"missing in primary", storage.ErrURLNotFound, "https://ozon.ru", nil, "https://ozon.ru", nil},
+		{"missing in both", storage.ErrURLNotFound, "", storage.ErrURLNotFound, "", storage.ErrURLNotFound},
+		{"disabled in primary", storage.ErrURLDisabled, "", nil, "", storage.ErrURLDisabled},
+		{"primary unavailable", errors.New("connection refused"), "https://ozon.ru", nil, "https://ozon.ru", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, secondary := new(mockStorager), new(mockStorager)
+			primary.On("Resolve", "aaaaaaaaaa").Return(storage.Link{}, tt.primaryErr)
+			secondary.On("Resolve", "aaaaaaaaaa").Return(storage.Link{FullURL: tt.secondaryURL}, tt.secondaryErr)
+			st := New(primary, secondary, newTestLogger())
+
+			link, err := st.Resolve(context.Background(), "aaaaaaaaaa")
+			assert.Equal(t, tt.wantURL, link.FullURL)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetDisabledNotCopiedYet(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := inMemmory.New(), inMemmory.New()
+	assert.NoError(t, secondary.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	st := New(primary, secondary, newTestLogger())
+
+	assert.NoError(t, st.SetDisabled(ctx, "aaaaaaaaaa", true))
+	_, err := st.Resolve(ctx, "aaaaaaaaaa")
+	assert.ErrorIs(t, err, storage.ErrURLDisabled)
+
+	assert.ErrorIs(t, st.DeleteLink(ctx, "bbbbbbbbbb"), storage.ErrURLNotFound)
+}
+
+func TestClick(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := inMemmory.New(), inMemmory.New()
+	st := New(primary, secondary, newTestLogger())
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{MaxClicks: 2}))
+	assert.NoError(t, secondary.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{MaxClicks: 1}))
+
+	left, err := st.Click(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), left)
+	link, err := secondary.GetLink(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), link.ClicksLeft)
+
+	// ссылка еще не скопирована в primary
+	left, err = st.Click(ctx, "bbbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), left)
+	_, err = st.Click(ctx, "bbbbbbbbbb")
+	assert.ErrorIs(t, err, storage.ErrClicksExhausted)
+}
+
+func TestCountVariant(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := inMemmory.New(), inMemmory.New()
+	st := New(primary, secondary, newTestLogger())
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, secondary.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaaa", "A"))
+	clicks, err := secondary.VariantClicks(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"A": 1}, clicks)
+
+	// ссылка еще не скопирована в primary
+	assert.NoError(t, st.CountVariant(ctx, "bbbbbbbbbb", "B"))
+	clicks, err = st.VariantClicks(ctx, "bbbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"B": 1}, clicks)
+	assert.ErrorIs(t, st.CountVariant(ctx, "cccccccccc", "A"), storage.ErrURLNotFound)
+}
+
+func TestCountTarget(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := inMemmory.New(), inMemmory.New()
+	st := New(primary, secondary, newTestLogger())
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	assert.NoError(t, secondary.SaveURL(ctx, "https://ya.ru", "bbbbbbbbbb", storage.Options{}))
+
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaaa", "ios"))
+	clicks, err := secondary.TargetClicks(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"ios": 1}, clicks)
+
+	// ссылка еще не скопирована в primary
+	assert.NoError(t, st.CountTarget(ctx, "bbbbbbbbbb", "default"))
+	clicks, err = st.TargetClicks(ctx, "bbbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"default": 1}, clicks)
+	assert.ErrorIs(t, st.CountTarget(ctx, "cccccccccc", "ios"), storage.ErrURLNotFound)
+}
+
+func TestNotSupported(t *testing.T) {
+	st := New(new(mockStorager), inMemmory.New(), newTestLogger())
+
+	_, err := st.GetLink(context.Background(), "aaaaaaaaaa")
+	assert.ErrorIs(t, err, storage.ErrNotSupported)
+	_, err = st.Click(context.Background(), "aaaaaaaaaa")
+	assert.ErrorIs(t, err, storage.ErrNotSupported)
+	assert.ErrorIs(t, st.CountVariant(context.Background(), "aaaaaaaaaa", "A"), storage.ErrNotSupported)
+	assert.ErrorIs(t, st.CountTarget(context.Background(), "aaaaaaaaaa", "ios"), storage.ErrNotSupported)
+	assert.ErrorIs(t, st.ForEachLink(context.Background(), nil), storage.ErrNotSupported)
+}
+
+func TestSwitch(t *testing.T) {
+	ctx := context.Background()
+	source := new(mockStorager)
+	sw := NewSwitch(source)
+
+	_, err := sw.GetLink(ctx, "aaaaaaaaaa")
+	assert.ErrorIs(t, err, storage.ErrNotSupported)
+
+	target := inMemmory.New()
+	sw.Set(target)
+	assert.NoError(t, sw.SaveURL(ctx, "https://ozon.ru", "aaaaaaaaaa", storage.Options{}))
+	link, err := sw.GetLink(ctx, "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullURL)
+	source.AssertNotCalled(t, "SaveURL", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,158 @@
+package dualWrite
+
+import (
+	"context"
+	"sync/atomic"
+	"urlShortener/internal/storage"
+)
+
+// Switch serves requests from the storage it was last switched to, so a running server can start
+// writing to both storages without a restart that would lose the links of the in-memory storage.
+type Switch struct {
+	current atomic.Pointer[switched]
+}
+
+// switched wraps the interface, atomic.Pointer needs a concrete type.
+type switched struct {
+	storage.Storager
+}
+
+func NewSwitch(storager storage.Storager) *Switch {
+	s := &Switch{}
+	s.Set(storager)
+	return s
+}
+
+// Set sends the next requests to storager, the requests in flight finish with the previous one.
+func (s *Switch) Set(storager storage.Storager) {
+	s.current.Store(&switched{storager})
+}
+
+// Current returns the storage requests are sent to.
+func (s *Switch) Current() storage.Storager {
+	return s.current.Load().Storager
+}
+
+func (s *Switch) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	return s.Current().SaveURL(ctx, urlToSave, shortenURL, opts)
+}
+
+func (s *Switch) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	return s.Current().Resolve(ctx, shortenURL)
+}
+
+func (s *Switch) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	return s.Current().GetShortenURL(ctx, fullURL)
+}
+
+func (s *Switch) Ping(ctx context.Context) error {
+	pinger, ok := s.Current().(storage.Pinger)
+	if !ok {
+		return nil
+	}
+	return pinger.Ping(ctx)
+}
+
+func (s *Switch) GetLink(ctx context.Context, code string) (storage.Link, error) {
+	manager, ok := s.Current().(storage.Manager)
+	if !ok {
+		return storage.Link{}, storage.ErrNotSupported
+	}
+	return manager.GetLink(ctx, code)
+}
+
+func (s *Switch) FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error) {
+	manager, ok := s.Current().(storage.Manager)
+	if !ok {
+		return storage.Link{}, storage.ErrNotSupported
+	}
+	return manager.FindByFullURL(ctx, fullURL)
+}
+
+func (s *Switch) DeleteLink(ctx context.Context, code string) error {
+	manager, ok := s.Current().(storage.Manager)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return manager.DeleteLink(ctx, code)
+}
+
+func (s *Switch) SetDisabled(ctx context.Context, code string, disabled bool) error {
+	manager, ok := s.Current().(storage.Manager)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return manager.SetDisabled(ctx, code, disabled)
+}
+
+func (s *Switch) ReassignDomain(ctx context.Context, from string, to string) (uint64, error) {
+	manager, ok := s.Current().(storage.Manager)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return manager.ReassignDomain(ctx, from, to)
+}
+
+func (s *Switch) Stats(ctx context.Context) (storage.Stats, error) {
+	manager, ok := s.Current().(storage.Manager)
+	if !ok {
+		return storage.Stats{}, storage.ErrNotSupported
+	}
+	return manager.Stats(ctx)
+}
+
+func (s *Switch) ForEachLink(ctx context.Context, visit func(link storage.Link) error) error {
+	lister, ok := s.Current().(storage.Lister)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return lister.ForEachLink(ctx, visit)
+}
+
+func (s *Switch) RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (uint64, error) {
+	restorer, ok := s.Current().(storage.Restorer)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return restorer.RestoreLink(ctx, link, overwrite)
+}
+
+func (s *Switch) Click(ctx context.Context, code string) (uint64, error) {
+	clicker, ok := s.Current().(storage.Clicker)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return clicker.Click(ctx, code)
+}
+
+func (s *Switch) CountVariant(ctx context.Context, code string, variant string) error {
+	counter, ok := s.Current().(storage.VariantCounter)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return counter.CountVariant(ctx, code, variant)
+}
+
+func (s *Switch) VariantClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	counter, ok := s.Current().(storage.VariantCounter)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return counter.VariantClicks(ctx, code)
+}
+
+func (s *Switch) CountTarget(ctx context.Context, code string, target string) error {
+	counter, ok := s.Current().(storage.TargetCounter)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return counter.CountTarget(ctx, code, target)
+}
+
+func (s *Switch) TargetClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	counter, ok := s.Current().(storage.TargetCounter)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return counter.TargetClicks(ctx, code)
+}
@@ -0,0 +1,81 @@
+package dualWrite
+
+import (
+	"context"
+	"sort"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+const maxDiffSamples = 20
+
+// Diff is the result of comparing two storages by link code.
+type Diff struct {
+	Source          uint64
+	Target          uint64
+	MissingInTarget uint64
+	MissingInSource uint64
+	// Mismatched links have the same code but a different destination, state or options.
+	Mismatched uint64
+	// Samples holds the codes of some differing links to look at.
+	Samples []string
+}
+
+func (d Diff) Equal() bool {
+	return d.MissingInTarget == 0 && d.MissingInSource == 0 && d.Mismatched == 0
+}
+
+// Verify compares the links of both storages. IDs and creation times are not compared:
+// links written before the migration may get other IDs in the target.
+func Verify(ctx context.Context, source storage.Lister, target storage.Lister) (Diff, error) {
+	const fn = "storage.dualWrite.Verify"
+
+	links := make(map[string]storage.Link)
+	err := source.ForEachLink(ctx, func(link storage.Link) error {
+		links[link.Code] = link
+		return nil
+	})
+	if err != nil {
+		return Diff{}, e.WrapError(fn, err)
+	}
+
+	diff := Diff{Source: uint64(len(links))}
+	err = target.ForEachLink(ctx, func(link storage.Link) error {
+		diff.Target++
+
+		sourceLink, ok := links[link.Code]
+		if !ok {
+			diff.MissingInSource++
+			diff.sample(link.Code)
+			return nil
+		}
+		delete(links, link.Code)
+
+		if sourceLink.FullURL != link.FullURL || sourceLink.Disabled != link.Disabled || !sourceLink.Options.Equal(link.Options) {
+			diff.Mismatched++
+			diff.sample(link.Code)
+		}
+		return nil
+	})
+	if err != nil {
+		return Diff{}, e.WrapError(fn, err)
+	}
+
+	// в links остались только ссылки, которых нет в target
+	missing := make([]string, 0, len(links))
+	for code := range links {
+		missing = append(missing, code)
+	}
+	sort.Strings(missing)
+	for _, code := range missing {
+		diff.MissingInTarget++
+		diff.sample(code)
+	}
+	return diff, nil
+}
+
+func (d *Diff) sample(code string) {
+	if len(d.Samples) < maxDiffSamples {
+		d.Samples = append(d.Samples, code)
+	}
+}
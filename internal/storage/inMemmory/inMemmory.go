@@ -1,37 +1,49 @@
 package inMemmory
 
 import (
+	"context"
+	"sort"
 	"sync"
+	"time"
 	"urlShortener/internal/storage"
 )
 
 type Storage struct {
 	mu            sync.RWMutex
-	keyShortenURL map[string]string
+	keyShortenURL map[string]*storage.Link
 	keyFullURL    map[string]string
+	// variantClicks are the clicks of the variants by code and variant name
+	variantClicks map[string]map[string]uint64
+	// targetClicks are the redirects by code and chosen target
+	targetClicks map[string]map[string]uint64
+	lastID       uint64
 }
 
 func New() *Storage {
 	return &Storage{
 		mu:            sync.RWMutex{},
-		keyShortenURL: make(map[string]string),
+		keyShortenURL: make(map[string]*storage.Link),
 		keyFullURL:    make(map[string]string),
+		variantClicks: make(map[string]map[string]uint64),
+		targetClicks:  make(map[string]map[string]uint64),
 	}
 }
 
-func (s *Storage) GetFullURL(shortenURL string) (string, error) {
+func (s *Storage) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fullURL, ok := s.keyShortenURL[shortenURL]
-	if ok {
-		return fullURL, nil
-	} else {
-		return "", storage.ErrURLNotFound
+	link, ok := s.keyShortenURL[shortenURL]
+	if !ok {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+	if link.Disabled {
+		return storage.Link{}, storage.ErrURLDisabled
 	}
+	return *link, nil
 }
 
-func (s *Storage) GetShortenURL(fullURL string) (string, error) {
+func (s *Storage) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -43,15 +55,250 @@ func (s *Storage) GetShortenURL(fullURL string) (string, error) {
 	}
 }
 
-func (s *Storage) SaveURL(fullURL string, shortenURL string) error {
+func (s *Storage) SaveURL(ctx context.Context, fullURL string, shortenURL string, opts storage.Options) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if _, ok := s.keyFullURL[fullURL]; ok {
 		return storage.ErrURLExists
 	}
+	// как UNIQUE на shortenurl в postgres: чужой код нельзя перезаписать
+	if _, ok := s.keyShortenURL[shortenURL]; ok {
+		return storage.ErrURLExists
+	}
 
+	s.lastID++
 	s.keyFullURL[fullURL] = shortenURL
-	s.keyShortenURL[shortenURL] = fullURL
+	s.keyShortenURL[shortenURL] = &storage.Link{
+		ID:         s.lastID,
+		Code:       shortenURL,
+		FullURL:    fullURL,
+		CreatedAt:  time.Now(),
+		Options:    opts,
+		ClicksLeft: opts.MaxClicks,
+	}
+	return nil
+}
+
+// Click checks and decrements the clicks under the write lock, so concurrent clicks are serialized.
+func (s *Storage) Click(ctx context.Context, code string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.keyShortenURL[code]
+	if !ok || link.MaxClicks == 0 || link.ClicksLeft == 0 {
+		return 0, storage.ErrClicksExhausted
+	}
+	link.ClicksLeft--
+	return link.ClicksLeft, nil
+}
+
+func (s *Storage) CountVariant(ctx context.Context, code string, variant string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keyShortenURL[code]; !ok {
+		return storage.ErrURLNotFound
+	}
+	clicks, ok := s.variantClicks[code]
+	if !ok {
+		clicks = make(map[string]uint64)
+		s.variantClicks[code] = clicks
+	}
+	clicks[variant]++
+	return nil
+}
+
+func (s *Storage) VariantClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clicks := make(map[string]uint64, len(s.variantClicks[code]))
+	for variant, count := range s.variantClicks[code] {
+		clicks[variant] = count
+	}
+	return clicks, nil
+}
+
+func (s *Storage) CountTarget(ctx context.Context, code string, target string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keyShortenURL[code]; !ok {
+		return storage.ErrURLNotFound
+	}
+	clicks, ok := s.targetClicks[code]
+	if !ok {
+		clicks = make(map[string]uint64)
+		s.targetClicks[code] = clicks
+	}
+	clicks[target]++
+	return nil
+}
+
+func (s *Storage) TargetClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clicks := make(map[string]uint64, len(s.targetClicks[code]))
+	for target, count := range s.targetClicks[code] {
+		clicks[target] = count
+	}
+	return clicks, nil
+}
+
+func (s *Storage) GetLink(ctx context.Context, code string) (storage.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.keyShortenURL[code]
+	if !ok {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+	return *link, nil
+}
+
+func (s *Storage) FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	code, ok := s.keyFullURL[fullURL]
+	if !ok {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+	return *s.keyShortenURL[code], nil
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.keyShortenURL[code]
+	if !ok {
+		return storage.ErrURLNotFound
+	}
+	delete(s.keyFullURL, link.FullURL)
+	delete(s.keyShortenURL, code)
+	delete(s.variantClicks, code)
+	delete(s.targetClicks, code)
+	return nil
+}
+
+func (s *Storage) SetDisabled(ctx context.Context, code string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.keyShortenURL[code]
+	if !ok {
+		return storage.ErrURLNotFound
+	}
+	link.Disabled = disabled
+	return nil
+}
+
+func (s *Storage) ReassignDomain(ctx context.Context, from string, to string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// сначала проверяем все ссылки, чтобы при конфликте не оставить хранилище изменённым наполовину
+	changed := make(map[string]string)
+	for code, link := range s.keyShortenURL {
+		newURL, ok := storage.ReplaceHost(link.FullURL, from, to)
+		if !ok || newURL == link.FullURL {
+			continue
+		}
+		changed[code] = newURL
+	}
+
+	targets := make(map[string]struct{}, len(changed))
+	for _, newURL := range changed {
+		if owner, ok := s.keyFullURL[newURL]; ok {
+			if _, moves := changed[owner]; !moves {
+				return 0, storage.ErrURLExists
+			}
+		}
+		if _, ok := targets[newURL]; ok {
+			return 0, storage.ErrURLExists
+		}
+		targets[newURL] = struct{}{}
+	}
+
+	for code := range changed {
+		delete(s.keyFullURL, s.keyShortenURL[code].FullURL)
+	}
+	for code, newURL := range changed {
+		s.keyShortenURL[code].FullURL = newURL
+		s.keyFullURL[newURL] = code
+	}
+	return uint64(len(changed)), nil
+}
+
+func (s *Storage) Stats(ctx context.Context) (storage.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := storage.Stats{Links: uint64(len(s.keyShortenURL))}
+	for _, link := range s.keyShortenURL {
+		if link.Disabled {
+			stats.DisabledLinks++
+		}
+	}
+	return stats, nil
+}
+
+func (s *Storage) RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if owner, ok := s.keyFullURL[link.FullURL]; ok && owner != link.Code {
+		return 0, storage.ErrURLExists
+	}
+	existing, exists := s.keyShortenURL[link.Code]
+	if exists && !overwrite {
+		return 0, storage.ErrURLExists
+	}
+
+	switch {
+	case link.ID == 0 && exists:
+		link.ID = existing.ID
+	case link.ID == 0:
+		s.lastID++
+		link.ID = s.lastID
+	case link.ID > s.lastID:
+		s.lastID = link.ID
+	}
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+
+	if exists {
+		delete(s.keyFullURL, existing.FullURL)
+	}
+	s.keyShortenURL[link.Code] = &link
+	s.keyFullURL[link.FullURL] = link.Code
+	return link.ID, nil
+}
+
+// ForEachLink calls visit for a snapshot of the links, so visit may use the storage itself.
+func (s *Storage) ForEachLink(ctx context.Context, visit func(link storage.Link) error) error {
+	s.mu.RLock()
+	links := make([]storage.Link, 0, len(s.keyShortenURL))
+	for _, link := range s.keyShortenURL {
+		links = append(links, *link)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID < links[j].ID
+	})
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := visit(link); err != nil {
+			return err
+		}
+	}
 	return nil
 }
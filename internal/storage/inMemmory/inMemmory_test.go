@@ -1,8 +1,11 @@
 package inMemmory
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"urlShortener/internal/storage"
 )
@@ -12,18 +15,18 @@ func TestGetFullURLSuccess(t *testing.T) {
 	fullURL := "ya.ru"
 	shortURL := "aaaaaaaaa"
 	st.keyFullURL[fullURL] = shortURL
-	st.keyShortenURL[shortURL] = fullURL
+	st.keyShortenURL[shortURL] = &storage.Link{Code: shortURL, FullURL: fullURL}
 
-	resultFullURL, err := st.GetFullURL(shortURL)
+	link, err := st.Resolve(context.Background(), shortURL)
 	assert.NoError(t, err)
-	assert.Equal(t, resultFullURL, fullURL)
+	assert.Equal(t, link.FullURL, fullURL)
 }
 
 func TestGetFullURLNotFound(t *testing.T) {
 	st := New()
 	shortURL := "aaaaaaaaa"
 
-	_, err := st.GetFullURL(shortURL)
+	_, err := st.Resolve(context.Background(), shortURL)
 	assert.True(t, errors.Is(err, storage.ErrURLNotFound))
 }
 
@@ -32,9 +35,9 @@ func TestGetShortenURLSuccess(t *testing.T) {
 	fullURL := "ya.ru"
 	shortURL := "aaaaaaaaa"
 	st.keyFullURL[fullURL] = shortURL
-	st.keyShortenURL[shortURL] = fullURL
+	st.keyShortenURL[shortURL] = &storage.Link{Code: shortURL, FullURL: fullURL}
 
-	resultShortURL, err := st.GetShortenURL(fullURL)
+	resultShortURL, err := st.GetShortenURL(context.Background(), fullURL)
 	assert.NoError(t, err)
 	assert.Equal(t, resultShortURL, shortURL)
 }
@@ -43,7 +46,7 @@ func TestGetShortenURLNotFound(t *testing.T) {
 	st := New()
 	fullURL := "ya.ru"
 
-	_, err := st.GetShortenURL(fullURL)
+	_, err := st.GetShortenURL(context.Background(), fullURL)
 	assert.True(t, errors.Is(err, storage.ErrURLNotFound))
 }
 
@@ -52,7 +55,7 @@ func TestSaveURLSuccess(t *testing.T) {
 	fullURL := "ya.ru"
 	shortURL := "aaaaaaaaa"
 
-	err := st.SaveURL(fullURL, shortURL)
+	err := st.SaveURL(context.Background(), fullURL, shortURL, storage.Options{})
 	assert.NoError(t, err)
 }
 
@@ -62,8 +65,224 @@ func TestSaveURLAlreadyExist(t *testing.T) {
 	shortURL := "aaaaaaaaa"
 
 	st.keyFullURL[fullURL] = shortURL
-	st.keyShortenURL[shortURL] = fullURL
+	st.keyShortenURL[shortURL] = &storage.Link{Code: shortURL, FullURL: fullURL}
 
-	err := st.SaveURL(fullURL, shortURL)
+	err := st.SaveURL(context.Background(), fullURL, shortURL, storage.Options{})
 	assert.True(t, errors.Is(err, storage.ErrURLExists))
 }
+
+func TestGetFullURLDisabled(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{}))
+	assert.NoError(t, st.SetDisabled(ctx, "aaaaaaaaa", true))
+
+	_, err := st.Resolve(ctx, "aaaaaaaaa")
+	assert.True(t, errors.Is(err, storage.ErrURLDisabled))
+
+	stats, err := st.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.Stats{Links: 1, DisabledLinks: 1}, stats)
+}
+
+func TestDeleteLink(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{}))
+
+	assert.NoError(t, st.DeleteLink(ctx, "aaaaaaaaa"))
+	_, err := st.GetShortenURL(ctx, "https://ya.ru")
+	assert.True(t, errors.Is(err, storage.ErrURLNotFound))
+	assert.True(t, errors.Is(st.DeleteLink(ctx, "aaaaaaaaa"), storage.ErrURLNotFound))
+}
+
+func TestReassignDomain(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://old.ru/a?x=1", "aaaaaaaaa", storage.Options{}))
+	assert.NoError(t, st.SaveURL(ctx, "https://old.ru:8080/b", "bbbbbbbbb", storage.Options{}))
+	assert.NoError(t, st.SaveURL(ctx, "https://other.ru/a", "ccccccccc", storage.Options{}))
+
+	changed, err := st.ReassignDomain(ctx, "old.ru", "new.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), changed)
+
+	link, err := st.FindByFullURL(ctx, "https://new.ru/a?x=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaa", link.Code)
+	link, err = st.Resolve(ctx, "bbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://new.ru:8080/b", link.FullURL)
+}
+
+func TestReassignDomainConflict(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://old.ru/a", "aaaaaaaaa", storage.Options{}))
+	assert.NoError(t, st.SaveURL(ctx, "https://new.ru/a", "bbbbbbbbb", storage.Options{}))
+
+	_, err := st.ReassignDomain(ctx, "old.ru", "new.ru")
+	assert.True(t, errors.Is(err, storage.ErrURLExists))
+
+	link, err := st.Resolve(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://old.ru/a", link.FullURL)
+}
+
+func TestForEachLinkOrderedByID(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru/1", "bbbbbbbbb", storage.Options{}))
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru/2", "aaaaaaaaa", storage.Options{}))
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru/3", "ccccccccc", storage.Options{}))
+
+	var codes []string
+	err := st.ForEachLink(ctx, func(link storage.Link) error {
+		codes = append(codes, link.Code)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bbbbbbbbb", "aaaaaaaaa", "ccccccccc"}, codes)
+}
+
+func TestRestoreLink(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+
+	id, err := st.RestoreLink(ctx, storage.Link{ID: 42, Code: "aaaaaaaaa", FullURL: "https://ya.ru", Disabled: true}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), id)
+	link, err := st.GetLink(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), link.ID)
+	assert.True(t, link.Disabled)
+	assert.False(t, link.CreatedAt.IsZero())
+
+	_, err = st.RestoreLink(ctx, storage.Link{Code: "aaaaaaaaa", FullURL: "https://ozon.ru"}, false)
+	assert.True(t, errors.Is(err, storage.ErrURLExists))
+
+	id, err = st.RestoreLink(ctx, storage.Link{Code: "aaaaaaaaa", FullURL: "https://ozon.ru"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), id)
+	link, err = st.FindByFullURL(ctx, "https://ozon.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), link.ID)
+	_, err = st.GetShortenURL(ctx, "https://ya.ru")
+	assert.True(t, errors.Is(err, storage.ErrURLNotFound))
+
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "bbbbbbbbb", storage.Options{}))
+	link, err = st.GetLink(ctx, "bbbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(43), link.ID)
+
+	_, err = st.RestoreLink(ctx, storage.Link{Code: "ccccccccc", FullURL: "https://ya.ru"}, true)
+	assert.True(t, errors.Is(err, storage.ErrURLExists))
+
+	id, err = st.RestoreLink(ctx, storage.Link{Code: "ddddddddd", FullURL: "https://ya.ru/new"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(44), id)
+}
+
+func TestSaveURLKeepsOptions(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{RedirectStatus: 308}))
+
+	link, err := st.Resolve(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, 308, link.RedirectStatus)
+}
+
+func TestClick(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{MaxClicks: 2}))
+	assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "bbbbbbbbb", storage.Options{}))
+
+	left, err := st.Click(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), left)
+	left, err = st.Click(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), left)
+
+	_, err = st.Click(ctx, "aaaaaaaaa")
+	assert.True(t, errors.Is(err, storage.ErrClicksExhausted))
+	_, err = st.Click(ctx, "bbbbbbbbb")
+	assert.True(t, errors.Is(err, storage.ErrClicksExhausted))
+}
+
+func TestClickContention(t *testing.T) {
+	const (
+		maxClicks = 25
+		clickers  = 2000
+	)
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{MaxClicks: maxClicks}))
+
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		clicked atomic.Int64
+	)
+	for i := 0; i < clickers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := st.Click(ctx, "aaaaaaaaa"); err == nil {
+				clicked.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(maxClicks), clicked.Load())
+	link, err := st.GetLink(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), link.ClicksLeft)
+}
+
+func TestCountVariant(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{}))
+
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaa", "A"))
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaa", "B"))
+	assert.NoError(t, st.CountVariant(ctx, "aaaaaaaaa", "B"))
+	assert.True(t, errors.Is(st.CountVariant(ctx, "bbbbbbbbb", "A"), storage.ErrURLNotFound))
+
+	clicks, err := st.VariantClicks(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"A": 1, "B": 2}, clicks)
+
+	// клики удаленной ссылки не достаются новой с тем же кодом
+	assert.NoError(t, st.DeleteLink(ctx, "aaaaaaaaa"))
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{}))
+	clicks, err = st.VariantClicks(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Empty(t, clicks)
+}
+
+func TestCountTarget(t *testing.T) {
+	st := New()
+	ctx := context.Background()
+	assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "aaaaaaaaa", storage.Options{}))
+
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaa", "ios"))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaa", "DE"))
+	assert.NoError(t, st.CountTarget(ctx, "aaaaaaaaa", "DE"))
+	assert.True(t, errors.Is(st.CountTarget(ctx, "bbbbbbbbb", "ios"), storage.ErrURLNotFound))
+
+	clicks, err := st.TargetClicks(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"ios": 1, "DE": 2}, clicks)
+
+	assert.NoError(t, st.DeleteLink(ctx, "aaaaaaaaa"))
+	clicks, err = st.TargetClicks(ctx, "aaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Empty(t, clicks)
+}
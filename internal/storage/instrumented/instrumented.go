@@ -0,0 +1,238 @@
+package instrumented
+
+import (
+	"context"
+	"errors"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/tracing"
+)
+
+const (
+	opSaveURL       = "SaveURL"
+	opResolve       = "Resolve"
+	opGetShortenURL = "GetShortenURL"
+
+	opGetLink        = "GetLink"
+	opFindByFullURL  = "FindByFullURL"
+	opDeleteLink     = "DeleteLink"
+	opSetDisabled    = "SetDisabled"
+	opReassignDomain = "ReassignDomain"
+	opStats          = "Stats"
+	opForEachLink    = "ForEachLink"
+	opRestoreLink    = "RestoreLink"
+	opClick          = "Click"
+	opCountVariant   = "CountVariant"
+	opVariantClicks  = "VariantClicks"
+	opCountTarget    = "CountTarget"
+	opTargetClicks   = "TargetClicks"
+)
+
+type storageObserver interface {
+	ObserveStorage(operation string, duration time.Duration, failed bool)
+}
+
+// Storage wraps every operation of the wrapped storage into a span and reports its latency and errors.
+type Storage struct {
+	storage  storage.Storager
+	observer storageObserver
+}
+
+func New(storage storage.Storager, observer storageObserver) *Storage {
+	return &Storage{
+		storage:  storage,
+		observer: observer,
+	}
+}
+
+func (s *Storage) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	ctx, finish := s.start(ctx, opSaveURL)
+	err := s.storage.SaveURL(ctx, urlToSave, shortenURL, opts)
+	finish(err)
+	return err
+}
+
+func (s *Storage) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	ctx, finish := s.start(ctx, opResolve)
+	link, err := s.storage.Resolve(ctx, shortenURL)
+	finish(err)
+	return link, err
+}
+
+func (s *Storage) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	ctx, finish := s.start(ctx, opGetShortenURL)
+	shortenURL, err := s.storage.GetShortenURL(ctx, fullURL)
+	finish(err)
+	return shortenURL, err
+}
+
+// Ping checks the wrapped storage if it implements storage.Pinger.
+func (s *Storage) Ping(ctx context.Context) error {
+	pinger, ok := s.storage.(storage.Pinger)
+	if !ok {
+		return nil
+	}
+	return pinger.Ping(ctx)
+}
+
+func (s *Storage) GetLink(ctx context.Context, code string) (storage.Link, error) {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return storage.Link{}, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opGetLink)
+	link, err := manager.GetLink(ctx, code)
+	finish(err)
+	return link, err
+}
+
+func (s *Storage) FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error) {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return storage.Link{}, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opFindByFullURL)
+	link, err := manager.FindByFullURL(ctx, fullURL)
+	finish(err)
+	return link, err
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, code string) error {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opDeleteLink)
+	err := manager.DeleteLink(ctx, code)
+	finish(err)
+	return err
+}
+
+func (s *Storage) SetDisabled(ctx context.Context, code string, disabled bool) error {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opSetDisabled)
+	err := manager.SetDisabled(ctx, code, disabled)
+	finish(err)
+	return err
+}
+
+func (s *Storage) ReassignDomain(ctx context.Context, from string, to string) (uint64, error) {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opReassignDomain)
+	changed, err := manager.ReassignDomain(ctx, from, to)
+	finish(err)
+	return changed, err
+}
+
+func (s *Storage) Stats(ctx context.Context) (storage.Stats, error) {
+	manager, ok := s.storage.(storage.Manager)
+	if !ok {
+		return storage.Stats{}, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opStats)
+	stats, err := manager.Stats(ctx)
+	finish(err)
+	return stats, err
+}
+
+func (s *Storage) ForEachLink(ctx context.Context, visit func(link storage.Link) error) error {
+	lister, ok := s.storage.(storage.Lister)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opForEachLink)
+	err := lister.ForEachLink(ctx, visit)
+	finish(err)
+	return err
+}
+
+func (s *Storage) RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (uint64, error) {
+	restorer, ok := s.storage.(storage.Restorer)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opRestoreLink)
+	id, err := restorer.RestoreLink(ctx, link, overwrite)
+	finish(err)
+	return id, err
+}
+
+func (s *Storage) Click(ctx context.Context, code string) (uint64, error) {
+	clicker, ok := s.storage.(storage.Clicker)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opClick)
+	left, err := clicker.Click(ctx, code)
+	finish(err)
+	return left, err
+}
+
+func (s *Storage) CountVariant(ctx context.Context, code string, variant string) error {
+	counter, ok := s.storage.(storage.VariantCounter)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opCountVariant)
+	err := counter.CountVariant(ctx, code, variant)
+	finish(err)
+	return err
+}
+
+func (s *Storage) VariantClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	counter, ok := s.storage.(storage.VariantCounter)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opVariantClicks)
+	clicks, err := counter.VariantClicks(ctx, code)
+	finish(err)
+	return clicks, err
+}
+
+func (s *Storage) CountTarget(ctx context.Context, code string, target string) error {
+	counter, ok := s.storage.(storage.TargetCounter)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opCountTarget)
+	err := counter.CountTarget(ctx, code, target)
+	finish(err)
+	return err
+}
+
+func (s *Storage) TargetClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	counter, ok := s.storage.(storage.TargetCounter)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	ctx, finish := s.start(ctx, opTargetClicks)
+	clicks, err := counter.TargetClicks(ctx, code)
+	finish(err)
+	return clicks, err
+}
+
+func (s *Storage) start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBOperation(operation)),
+	)
+
+	return ctx, func(err error) {
+		// ErrURLNotFound, ErrURLDisabled и ErrClicksExhausted не считаются ошибками: это обычные ответы хранилища.
+		if errors.Is(err, storage.ErrURLNotFound) || errors.Is(err, storage.ErrURLDisabled) || errors.Is(err, storage.ErrClicksExhausted) {
+			err = nil
+		}
+		s.observer.ObserveStorage(operation, time.Since(start), err != nil)
+		tracing.End(span, err)
+	}
+}
@@ -0,0 +1,92 @@
+package instrumented
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"urlShortener/internal/storage"
+)
+
+type mockStorager struct {
+	mock.Mock
+}
+
+func (m *mockStorager) SaveURL(ctx context.Context, urlToSave string, shortenURL string, opts storage.Options) error {
+	args := m.Called(urlToSave, shortenURL, opts)
+	return args.Error(0)
+}
+
+func (m *mockStorager) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	args := m.Called(shortenURL)
+	return args.Get(0).(storage.Link), args.Error(1)
+}
+
+func (m *mockStorager) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	args := m.Called(fullURL)
+	return args.String(0), args.Error(1)
+}
+
+type mockObserver struct {
+	mock.Mock
+}
+
+func (m *mockObserver) ObserveStorage(operation string, duration time.Duration, failed bool) {
+	m.Called(operation, failed)
+}
+
+func TestGetFullURLObserved(t *testing.T) {
+	st := &mockStorager{}
+	observer := &mockObserver{}
+	instrumented := New(st, observer)
+
+	st.On("Resolve", "aaaaaaaaaa").Return(storage.Link{FullURL: "https://ozon.ru"}, nil)
+	observer.On("ObserveStorage", opResolve, false).Return()
+
+	link, err := instrumented.Resolve(context.Background(), "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullURL)
+
+	st.AssertExpectations(t)
+	observer.AssertExpectations(t)
+}
+
+func TestNotFoundIsNotFailure(t *testing.T) {
+	st := &mockStorager{}
+	observer := &mockObserver{}
+	instrumented := New(st, observer)
+
+	st.On("GetShortenURL", "https://ozon.ru").Return("", storage.ErrURLNotFound)
+	observer.On("ObserveStorage", opGetShortenURL, false).Return()
+
+	_, err := instrumented.GetShortenURL(context.Background(), "https://ozon.ru")
+	assert.True(t, errors.Is(err, storage.ErrURLNotFound))
+
+	st.AssertExpectations(t)
+	observer.AssertExpectations(t)
+}
+
+func TestSaveURLFailure(t *testing.T) {
+	st := &mockStorager{}
+	observer := &mockObserver{}
+	instrumented := New(st, observer)
+
+	st.On("SaveURL", "https://ozon.ru", "aaaaaaaaaa", storage.Options{}).Return(errors.New("connection refused"))
+	observer.On("ObserveStorage", opSaveURL, true).Return()
+
+	err := instrumented.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", storage.Options{})
+	assert.Error(t, err)
+
+	st.AssertExpectations(t)
+	observer.AssertExpectations(t)
+}
+
+func TestManagerNotSupported(t *testing.T) {
+	instrumented := New(&mockStorager{}, &mockObserver{})
+
+	_, err := instrumented.Stats(context.Background())
+	assert.True(t, errors.Is(err, storage.ErrNotSupported))
+	assert.True(t, errors.Is(instrumented.DeleteLink(context.Background(), "aaaaaaaaaa"), storage.ErrNotSupported))
+}
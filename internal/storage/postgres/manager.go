@@ -0,0 +1,379 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+// optionColumns keep storage.Options in the order of optionValues.
+const optionColumns = `redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky`
+
+const linkColumns = `id, shortenurl, fullurl, created_at, disabled, ` + optionColumns + `, clicks_left`
+
+func optionValues(opts storage.Options) []any {
+	return []any{opts.RedirectStatus, string(opts.Query), opts.PathPassthrough, opts.PasswordHash, opts.MaxClicks,
+		nullTime(opts.NotBefore), nullTime(opts.NotAfter), opts.FallbackURL, jsonTargets(opts.Targets), jsonCountries(opts.Countries),
+		jsonVariants(opts.Variants), string(opts.Sticky)}
+}
+
+// scanLink reads a row of linkColumns.
+func scanLink(row interface{ Scan(dest ...any) error }, link *storage.Link) error {
+	var notBefore, notAfter sql.NullTime
+	err := row.Scan(&link.ID, &link.Code, &link.FullURL, &link.CreatedAt, &link.Disabled,
+		&link.RedirectStatus, &link.Query, &link.PathPassthrough, &link.PasswordHash, &link.MaxClicks,
+		&notBefore, &notAfter, &link.FallbackURL, (*jsonTargets)(&link.Targets), (*jsonCountries)(&link.Countries),
+		(*jsonVariants)(&link.Variants), &link.Sticky, &link.ClicksLeft)
+	if err != nil {
+		return err
+	}
+	link.NotBefore = fromNullTime(notBefore)
+	link.NotAfter = fromNullTime(notAfter)
+	return nil
+}
+
+// nullTime stores an open side of the window as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// jsonTargets keeps storage.Targets in a JSONB column.
+type jsonTargets storage.Targets
+
+func (t jsonTargets) Value() (driver.Value, error) {
+	data, err := json.Marshal(storage.Targets(t))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (t *jsonTargets) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, (*storage.Targets)(t))
+	case string:
+		return json.Unmarshal([]byte(data), (*storage.Targets)(t))
+	case nil:
+		*t = jsonTargets{}
+		return nil
+	default:
+		return fmt.Errorf("can't scan %T into targets", src)
+	}
+}
+
+// jsonCountries keeps storage.Countries in a JSONB column.
+type jsonCountries storage.Countries
+
+func (c jsonCountries) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(c))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan decodes the rules once per read, an empty object becomes nil like in storage.NewCountries.
+func (c *jsonCountries) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return fmt.Errorf("can't scan %T into countries", src)
+	}
+
+	var rules map[string]string
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	*c = jsonCountries(storage.NewCountries(rules))
+	return nil
+}
+
+// jsonVariants keeps storage.Variants in a JSONB column.
+type jsonVariants storage.Variants
+
+func (v jsonVariants) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]storage.Variant(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan decodes the variants once per read, an empty array becomes nil like in storage.NewVariants.
+func (v *jsonVariants) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	case nil:
+		*v = nil
+		return nil
+	default:
+		return fmt.Errorf("can't scan %T into variants", src)
+	}
+
+	var variants []storage.Variant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return err
+	}
+	*v = jsonVariants(storage.NewVariants(variants))
+	return nil
+}
+
+// fromNullTime returns the time in UTC: the driver returns it in the zone of the session.
+func fromNullTime(t sql.NullTime) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+	return t.Time.UTC()
+}
+
+func (s *Storage) GetLink(ctx context.Context, code string) (storage.Link, error) {
+	const fn = "storage.postgres.GetLink"
+
+	link, err := s.queryLink(ctx, s.db, `SELECT `+linkColumns+` FROM url WHERE shortenurl = ($1)`, code)
+	if err != nil {
+		return storage.Link{}, e.WrapError(fn, err)
+	}
+	return link, nil
+}
+
+func (s *Storage) FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error) {
+	const fn = "storage.postgres.FindByFullURL"
+
+	link, err := s.queryLink(ctx, s.db, `SELECT `+linkColumns+` FROM url WHERE fullurl = ($1)`, fullURL)
+	if err != nil {
+		return storage.Link{}, e.WrapError(fn, err)
+	}
+	return link, nil
+}
+
+func (s *Storage) queryLink(ctx context.Context, db *sql.DB, queryText string, arg string) (storage.Link, error) {
+	query, err := db.PrepareContext(ctx, queryText)
+	if err != nil {
+		return storage.Link{}, err
+	}
+	defer func() {
+		err = query.Close()
+		if err != nil {
+			s.logger.Errorf("storage.postgres.queryLink: can't close query %v", err)
+		}
+	}()
+
+	var link storage.Link
+	err = scanLink(query.QueryRowContext(ctx, arg), &link)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+	return link, err
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, code string) error {
+	const fn = "storage.postgres.DeleteLink"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM url WHERE shortenurl = ($1)`, code)
+	if err != nil {
+		return e.WrapError(fn, err)
+	}
+	if err = affectedOne(res); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+func (s *Storage) SetDisabled(ctx context.Context, code string, disabled bool) error {
+	const fn = "storage.postgres.SetDisabled"
+
+	res, err := s.db.ExecContext(ctx, `UPDATE url SET disabled = ($1) WHERE shortenurl = ($2)`, disabled, code)
+	if err != nil {
+		return e.WrapError(fn, err)
+	}
+	if err = affectedOne(res); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+func affectedOne(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrURLNotFound
+	}
+	return nil
+}
+
+// ReassignDomain rewrites matching destinations in one transaction, so a conflict leaves the table untouched.
+func (s *Storage) ReassignDomain(ctx context.Context, from string, to string) (changed uint64, err error) {
+	const fn = "storage.postgres.ReassignDomain"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.logger.Errorf("%s: can't rollback %v", fn, rollbackErr)
+			}
+		}
+	}()
+
+	// LIKE только отсекает заведомо лишние строки, точное сравнение хоста делает ReplaceHost
+	rows, err := tx.QueryContext(ctx, `SELECT id, fullurl FROM url WHERE fullurl ILIKE ('%' || $1 || '%') FOR UPDATE`, from)
+	if err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+
+	updates := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var fullURL string
+		if err = rows.Scan(&id, &fullURL); err != nil {
+			_ = rows.Close()
+			return 0, e.WrapError(fn, err)
+		}
+		if newURL, ok := storage.ReplaceHost(fullURL, from, to); ok && newURL != fullURL {
+			updates[id] = newURL
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+
+	for id, newURL := range updates {
+		_, err = tx.ExecContext(ctx, `UPDATE url SET fullurl = ($1) WHERE id = ($2)`, newURL, id)
+		if err != nil {
+			if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+				err = storage.ErrURLExists
+			}
+			return 0, e.WrapError(fn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	return uint64(len(updates)), nil
+}
+
+func (s *Storage) Stats(ctx context.Context) (storage.Stats, error) {
+	const fn = "storage.postgres.Stats"
+
+	var stats storage.Stats
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), COUNT(*) FILTER (WHERE disabled) FROM url`).
+		Scan(&stats.Links, &stats.DisabledLinks)
+	if err != nil {
+		return storage.Stats{}, e.WrapError(fn, err)
+	}
+	return stats, nil
+}
+
+func (s *Storage) ForEachLink(ctx context.Context, visit func(link storage.Link) error) error {
+	const fn = "storage.postgres.ForEachLink"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+linkColumns+` FROM url ORDER BY id`)
+	if err != nil {
+		return e.WrapError(fn, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.Errorf("%s: can't close rows %v", fn, err)
+		}
+	}()
+
+	for rows.Next() {
+		var link storage.Link
+		if err = scanLink(rows, &link); err != nil {
+			return e.WrapError(fn, err)
+		}
+		if err = visit(link); err != nil {
+			return e.WrapError(fn, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+// RestoreLink inserts the link with its ID and moves the id sequence past it, so SaveURL doesn't reuse the ID.
+func (s *Storage) RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (id uint64, err error) {
+	const fn = "storage.postgres.RestoreLink"
+
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+	linkID := sql.NullInt64{Int64: int64(link.ID), Valid: link.ID != 0}
+
+	insert := `INSERT INTO url(id, shortenurl, fullurl, created_at, disabled, ` + optionColumns + `, clicks_left)
+VALUES (COALESCE($1, nextval(pg_get_serial_sequence('url', 'id'))), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+	if overwrite {
+		// id существующей ссылки не меняем, иначе можно задеть чужой первичный ключ
+		insert += ` ON CONFLICT (shortenurl) DO UPDATE SET fullurl = EXCLUDED.fullurl, created_at = EXCLUDED.created_at, disabled = EXCLUDED.disabled,
+redirect_status = EXCLUDED.redirect_status, query_merge = EXCLUDED.query_merge, path_passthrough = EXCLUDED.path_passthrough,
+password_hash = EXCLUDED.password_hash, max_clicks = EXCLUDED.max_clicks, not_before = EXCLUDED.not_before, not_after = EXCLUDED.not_after,
+fallback_url = EXCLUDED.fallback_url, targets = EXCLUDED.targets,
+countries = EXCLUDED.countries, variants = EXCLUDED.variants, sticky = EXCLUDED.sticky, clicks_left = EXCLUDED.clicks_left`
+	}
+	insert += ` RETURNING id`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.logger.Errorf("%s: can't rollback %v", fn, rollbackErr)
+			}
+		}
+	}()
+
+	args := append([]any{linkID, link.Code, link.FullURL, link.CreatedAt, link.Disabled}, optionValues(link.Options)...)
+	args = append(args, link.ClicksLeft)
+	err = tx.QueryRowContext(ctx, insert, args...).Scan(&id)
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+			err = storage.ErrURLExists
+		}
+		return 0, e.WrapError(fn, err)
+	}
+
+	if linkID.Valid {
+		_, err = tx.ExecContext(ctx, `SELECT setval(pg_get_serial_sequence('url', 'id'), (SELECT MAX(id) FROM url))`)
+		if err != nil {
+			return 0, e.WrapError(fn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	st "urlShortener/internal/storage"
+)
+
+func TestGetLinkSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	notBefore := time.Date(2024, 6, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	mock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs("qqqqqqqqqa").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "shortenurl", "fullurl", "created_at", "disabled", "redirect_status", "query_merge", "path_passthrough", "password_hash", "max_clicks", "not_before", "not_after", "fallback_url", "targets", "countries", "variants", "sticky", "clicks_left"}).
+			AddRow(10, "qqqqqqqqqa", "https://ya.ru", createdAt, true, 301, "keep", true, "hash", 3, notBefore, nil, "https://ya.ru/soon", []byte(`{"ios":"https://apps.apple.com/app/id1"}`), []byte(`{"DE": "https://ya.ru/de", "BY": "https://ya.ru/by"}`),
+				[]byte(`[{"url": "https://ya.ru/a", "name": "A", "weight": 3}, {"url": "https://ya.ru/b", "name": "new", "weight": 1}]`), "cookie", 1))
+
+	link, err := storage.GetLink(context.Background(), "qqqqqqqqqa")
+	assert.NoError(t, err)
+	assert.Equal(t, st.Link{ID: 10, Code: "qqqqqqqqqa", FullURL: "https://ya.ru", CreatedAt: createdAt, Disabled: true, Options: st.Options{RedirectStatus: 301, Query: st.QueryKeep, PathPassthrough: true, PasswordHash: "hash", MaxClicks: 3,
+		NotBefore: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), FallbackURL: "https://ya.ru/soon",
+		Targets:   st.Targets{IOS: "https://apps.apple.com/app/id1"},
+		Countries: st.Countries{"BY": "https://ya.ru/by", "DE": "https://ya.ru/de"},
+		Variants:  st.Variants{{Name: "A", URL: "https://ya.ru/a", Weight: 3}, {Name: "new", URL: "https://ya.ru/b", Weight: 1}}, Sticky: st.StickyCookie}, ClicksLeft: 1}, link)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteLinkNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	mock.ExpectExec(`DELETE FROM url WHERE shortenurl = \(\$1\)`).
+		WithArgs("qqqqqqqqqa").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = storage.DeleteLink(context.Background(), "qqqqqqqqqa")
+	assert.True(t, errors.Is(err, st.ErrURLNotFound))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetDisabledSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	mock.ExpectExec(`UPDATE url SET disabled = \(\$1\) WHERE shortenurl = \(\$2\)`).
+		WithArgs(true, "qqqqqqqqqa").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, storage.SetDisabled(context.Background(), "qqqqqqqqqa", true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReassignDomainSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, fullurl FROM url WHERE fullurl ILIKE`).WithArgs("old.ru").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fullurl"}).
+			AddRow(1, "https://old.ru/a").
+			AddRow(2, "https://notold.ru/b"))
+	mock.ExpectExec(`UPDATE url SET fullurl = \(\$1\) WHERE id = \(\$2\)`).
+		WithArgs("https://new.ru/a", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	changed, err := storage.ReassignDomain(context.Background(), "old.ru", "new.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), changed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReassignDomainConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, fullurl FROM url WHERE fullurl ILIKE`).WithArgs("old.ru").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fullurl"}).AddRow(1, "https://old.ru/a"))
+	mock.ExpectExec(`UPDATE url SET fullurl = \(\$1\) WHERE id = \(\$2\)`).
+		WithArgs("https://new.ru/a", 1).WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err = storage.ReassignDomain(context.Background(), "old.ru", "new.ru")
+	assert.True(t, errors.Is(err, st.ErrURLExists))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\), COUNT\(\*\) FILTER \(WHERE disabled\) FROM url`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "count"}).AddRow(5, 2))
+
+	stats, err := storage.Stats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, st.Stats{Links: 5, DisabledLinks: 2}, stats)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForEachLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "shortenurl", "fullurl", "created_at", "disabled", "redirect_status", "query_merge", "path_passthrough", "password_hash", "max_clicks", "not_before", "not_after", "fallback_url", "targets", "countries", "variants", "sticky", "clicks_left"}).
+			AddRow(1, "qqqqqqqqqw", "https://ya.ru", createdAt, false, 0, "", false, "", 0, nil, nil, "", "{}", "{}", "[]", "", 0).
+			AddRow(2, "qqqqqqqqqe", "https://ozon.ru", createdAt, true, 308, "", false, "", 0, nil, nil, "", "{}", "{}", "[]", "", 0))
+
+	var links []st.Link
+	err = storage.ForEachLink(context.Background(), func(link st.Link) error {
+		links = append(links, link)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+	assert.Equal(t, "https://ozon.ru", links[1].FullURL)
+	assert.True(t, links[1].Disabled)
+	assert.Equal(t, 308, links[1].RedirectStatus)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreLinkWithID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	notAfter := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO url\(id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\)`).
+		WithArgs(int64(42), "qqqqqqqqqa", "https://ya.ru", createdAt, true, 307, "override", true, "hash", uint64(5), nil, notAfter, "https://ya.ru/over", `{"android":"https://play.google.com/store/apps/details?id=ru.ya"}`, `{"KZ":"https://ya.kz"}`,
+			`[{"name":"A","url":"https://ya.ru/a","weight":1},{"name":"B","url":"https://ya.ru/b","weight":1}]`, "hash", uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectExec(`SELECT setval`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	link := st.Link{ID: 42, Code: "qqqqqqqqqa", FullURL: "https://ya.ru", CreatedAt: createdAt, Disabled: true, Options: st.Options{RedirectStatus: 307, Query: st.QueryOverride, PathPassthrough: true, PasswordHash: "hash", MaxClicks: 5, NotAfter: notAfter, FallbackURL: "https://ya.ru/over",
+		Targets: st.Targets{Android: "https://play.google.com/store/apps/details?id=ru.ya"}, Countries: st.NewCountries(map[string]string{"kz": "https://ya.kz"}),
+		Variants: st.NewVariants([]st.Variant{{URL: "https://ya.ru/a", Weight: 1}, {URL: "https://ya.ru/b", Weight: 1}}), Sticky: st.StickyHash}, ClicksLeft: 2}
+	id, err := storage.RestoreLink(context.Background(), link, false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), id)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreLinkExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO url\(id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\)`).
+		WithArgs(nil, "qqqqqqqqqa", "https://ya.ru", sqlmock.AnyArg(), false, 0, "", false, "", uint64(0), nil, nil, "", "{}", "{}", "[]", "", uint64(0)).
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err = storage.RestoreLink(context.Background(), st.Link{Code: "qqqqqqqqqa", FullURL: "https://ya.ru"}, false)
+	assert.True(t, errors.Is(err, st.ErrURLExists))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/sirupsen/logrus"
+	"urlShortener/utils/e"
+)
+
+// migrations are applied in order on every start after createTable, so each of them must be idempotent.
+var migrations = []string{
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT false;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS redirect_status SMALLINT NOT NULL DEFAULT 0;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS query_merge TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS path_passthrough BOOLEAN NOT NULL DEFAULT false;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS max_clicks BIGINT NOT NULL DEFAULT 0;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS clicks_left BIGINT NOT NULL DEFAULT 0;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS not_before TIMESTAMPTZ;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS not_after TIMESTAMPTZ;`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS fallback_url TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS targets JSONB NOT NULL DEFAULT '{}';`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS countries JSONB NOT NULL DEFAULT '{}';`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE url ADD COLUMN IF NOT EXISTS sticky TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE IF NOT EXISTS variant_clicks (
+    shortenurl TEXT NOT NULL REFERENCES url (shortenurl) ON DELETE CASCADE ON UPDATE CASCADE,
+    variant TEXT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (shortenurl, variant));`,
+	`CREATE TABLE IF NOT EXISTS target_clicks (
+    shortenurl TEXT NOT NULL REFERENCES url (shortenurl) ON DELETE CASCADE ON UPDATE CASCADE,
+    target TEXT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (shortenurl, target));`,
+}
+
+func migrate(ctx context.Context, db *sql.DB, logger *logrus.Logger) error {
+	const fn = "storage.postgres.migrate"
+
+	for i, migration := range migrations {
+		if _, err := db.ExecContext(ctx, migration); err != nil {
+			return e.WrapError(fn, err)
+		}
+		logger.Debugf("%s: migration %d applied", fn, i+1)
+	}
+
+	return nil
+}
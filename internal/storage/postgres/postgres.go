@@ -7,27 +7,44 @@ import (
 	"fmt"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+	"sync/atomic"
+	"time"
 	"urlShortener/internal/config"
 	"urlShortener/internal/storage"
 	"urlShortener/utils/e"
 )
 
+const maxConnectBackoff = 5 * time.Second
+
 type Storage struct {
 	db     *sql.DB
 	ctx    context.Context
 	logger *logrus.Logger
+
+	replicas    []*replica
+	nextReplica atomic.Uint64
+	stopWatch   context.CancelFunc
 }
 
 func New(ctx context.Context, cfg *config.PostgresConfig, logger *logrus.Logger) (*Storage, error) {
 	const fn = "storage.postgres.New"
 
-	db, err := sql.Open("postgres", connStr(cfg))
+	dsn, err := cfg.ConnString()
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	replicas, err := cfg.ReplicaConnStrings()
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, e.WrapError(fn, err)
 	}
 
-	err = db.PingContext(ctx)
+	err = connect(ctx, db, cfg.ConnectMaxWait, cfg.ConnectBackoff, logger)
 	if err != nil {
+		_ = db.Close()
 		return nil, e.WrapError(fn, err)
 	}
 
@@ -36,15 +53,80 @@ func New(ctx context.Context, cfg *config.PostgresConfig, logger *logrus.Logger)
 		return nil, e.WrapError(fn, err)
 	}
 
+	err = migrate(ctx, db, logger)
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+
 	res := &Storage{
 		db:     db,
 		ctx:    ctx,
 		logger: logger,
 	}
 
+	err = res.openReplicas(ctx, replicas, cfg.ReplicaCheckInterval)
+	if err != nil {
+		_ = db.Close()
+		return nil, e.WrapError(fn, err)
+	}
+
 	return res, nil
 }
 
+// Migrate brings the schema up to date without keeping the connection, it is used by the migrate command.
+// New applies the same migrations on start.
+func Migrate(ctx context.Context, cfg *config.PostgresConfig, logger *logrus.Logger) (int, error) {
+	const fn = "storage.postgres.Migrate"
+
+	pq, err := New(ctx, cfg, logger)
+	if err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	if err = pq.Close(); err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	return len(migrations), nil
+}
+
+// connect waits for the database with exponential backoff: on start the container may be up before postgres.
+func connect(ctx context.Context, db *sql.DB, maxWait time.Duration, backoff time.Duration, logger *logrus.Logger) error {
+	const fn = "storage.postgres.connect"
+
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			// пинг, прерванный по таймауту, менее полезен, чем причина предыдущих неудач
+			if lastErr == nil {
+				lastErr = err
+			}
+			return fmt.Errorf("postgres is not available after %d attempts: %w", attempt, lastErr)
+		}
+		lastErr = err
+
+		logger.WithField("attempt", attempt).Warnf("%s: postgres is not available, retrying in %s: %v", fn, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("postgres is not available after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+func (s *Storage) Close() error {
+	return errors.Join(s.db.Close(), s.closeReplicas())
+}
+
 func createTable(ctx context.Context, db *sql.DB, logger *logrus.Logger) error {
 	const fn = "storage.postgres.createTable"
 
@@ -99,15 +181,109 @@ func (s *Storage) MaxID() (uint64, error) {
 	}
 }
 
-func connStr(cfg *config.PostgresConfig) string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.Login, cfg.Password, cfg.DBName, cfg.SSLMode)
+// CountVariant adds the click in one upsert, so concurrent clicks of a variant aren't lost.
+func (s *Storage) CountVariant(ctx context.Context, code string, variant string) error {
+	const fn = "storage.postgres.CountVariant"
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO variant_clicks(shortenurl, variant, clicks) VALUES ($1, $2, 1)
+ON CONFLICT (shortenurl, variant) DO UPDATE SET clicks = variant_clicks.clicks + 1`, code, variant)
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "foreign_key_violation" {
+			err = storage.ErrURLNotFound
+		}
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+func (s *Storage) VariantClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	const fn = "storage.postgres.VariantClicks"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT variant, clicks FROM variant_clicks WHERE shortenurl = ($1)`, code)
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.Errorf("%s: can't close rows %v", fn, err)
+		}
+	}()
+
+	clicks := make(map[string]uint64)
+	for rows.Next() {
+		var (
+			variant string
+			count   uint64
+		)
+		if err = rows.Scan(&variant, &count); err != nil {
+			return nil, e.WrapError(fn, err)
+		}
+		clicks[variant] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	return clicks, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, shortenUrl string) error {
+// CountTarget adds the redirect in one upsert like CountVariant.
+func (s *Storage) CountTarget(ctx context.Context, code string, target string) error {
+	const fn = "storage.postgres.CountTarget"
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO target_clicks(shortenurl, target, clicks) VALUES ($1, $2, 1)
+ON CONFLICT (shortenurl, target) DO UPDATE SET clicks = target_clicks.clicks + 1`, code, target)
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "foreign_key_violation" {
+			err = storage.ErrURLNotFound
+		}
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+func (s *Storage) TargetClicks(ctx context.Context, code string) (map[string]uint64, error) {
+	const fn = "storage.postgres.TargetClicks"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT target, clicks FROM target_clicks WHERE shortenurl = ($1)`, code)
+	if err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.Errorf("%s: can't close rows %v", fn, err)
+		}
+	}()
+
+	clicks := make(map[string]uint64)
+	for rows.Next() {
+		var (
+			target string
+			count  uint64
+		)
+		if err = rows.Scan(&target, &count); err != nil {
+			return nil, e.WrapError(fn, err)
+		}
+		clicks[target] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, e.WrapError(fn, err)
+	}
+	return clicks, nil
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	const fn = "storage.postgres.Ping"
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+func (s *Storage) SaveURL(ctx context.Context, urlToSave string, shortenUrl string, opts storage.Options) error {
 	const fn = "storage.postgres.SaveURL"
 
-	query, err := s.db.Prepare(`INSERT INTO url(fullurl, shortenurl) VALUES ($1,$2)`)
+	query, err := s.db.PrepareContext(ctx, `INSERT INTO url(fullurl, shortenurl, `+optionColumns+`, clicks_left) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$7)`)
 	if err != nil {
 		return e.WrapError(fn, err)
 	}
@@ -118,7 +294,7 @@ func (s *Storage) SaveURL(urlToSave string, shortenUrl string) error {
 		}
 	}()
 
-	_, err = query.ExecContext(s.ctx, urlToSave, shortenUrl)
+	_, err = query.ExecContext(ctx, append([]any{urlToSave, shortenUrl}, optionValues(opts)...)...)
 	if err != nil {
 		if pqError, ok := err.(*pq.Error); ok {
 			switch pqError.Code.Name() {
@@ -132,35 +308,49 @@ func (s *Storage) SaveURL(urlToSave string, shortenUrl string) error {
 	return nil
 }
 
-func (s *Storage) GetFullURL(shortenURL string) (string, error) {
-	const fn = "storage.postgres.GetURL"
-
-	query, err := s.db.Prepare(`SELECT fullURL FROM url WHERE shortenurl = ($1)`)
-	if err != nil {
-		return "", e.WrapError(fn, err)
-	}
-	defer func() {
-		err = query.Close()
-		if err != nil {
-			s.logger.Errorf("%s: can't close query %v", fn, err)
+// Resolve is served by a replica when there is a healthy one. A link saved moments ago may not have reached
+// the replica yet, so a miss is checked on the primary.
+func (s *Storage) Resolve(ctx context.Context, shortenURL string) (storage.Link, error) {
+	if r := s.replica(ctx); r != nil {
+		link, err := s.resolve(ctx, r.db, shortenURL)
+		if !s.fallBack(ctx, r, err) && !errors.Is(err, storage.ErrURLNotFound) {
+			return link, err
 		}
-	}()
+	}
+	return s.resolve(ctx, s.db, shortenURL)
+}
 
-	var fullURL string
-	err = query.QueryRowContext(s.ctx, shortenURL).Scan(&fullURL)
-	if errors.Is(err, sql.ErrNoRows) {
-		return "", storage.ErrURLNotFound
+func (s *Storage) resolve(ctx context.Context, db *sql.DB, shortenURL string) (storage.Link, error) {
+	const fn = "storage.postgres.Resolve"
+
+	link, err := s.queryLink(ctx, db, `SELECT `+linkColumns+` FROM url WHERE shortenurl = ($1)`, shortenURL)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return storage.Link{}, err
 	} else if err != nil {
-		return "", e.WrapError(fn, err)
+		return storage.Link{}, e.WrapError(fn, err)
+	}
+	if link.Disabled {
+		return storage.Link{}, storage.ErrURLDisabled
 	}
 
-	return fullURL, nil
+	return link, nil
+}
+
+// GetShortenURL is served by a replica when there is a healthy one, unless ctx requires the primary.
+func (s *Storage) GetShortenURL(ctx context.Context, fullURL string) (string, error) {
+	if r := s.replica(ctx); r != nil {
+		shortenURL, err := s.getShortenURL(ctx, r.db, fullURL)
+		if !s.fallBack(ctx, r, err) {
+			return shortenURL, err
+		}
+	}
+	return s.getShortenURL(ctx, s.db, fullURL)
 }
 
-func (s *Storage) GetShortenURL(fullURL string) (string, error) {
+func (s *Storage) getShortenURL(ctx context.Context, db *sql.DB, fullURL string) (string, error) {
 	const fn = "storage.postgres.GetURL"
 
-	query, err := s.db.Prepare(`SELECT shortenurl FROM url WHERE fullurl = ($1)`)
+	query, err := db.PrepareContext(ctx, `SELECT shortenurl FROM url WHERE fullurl = ($1)`)
 	if err != nil {
 		return "", e.WrapError(fn, err)
 	}
@@ -172,7 +362,7 @@ func (s *Storage) GetShortenURL(fullURL string) (string, error) {
 	}()
 
 	var shortenURL string
-	err = query.QueryRowContext(s.ctx, fullURL).Scan(&shortenURL)
+	err = query.QueryRowContext(ctx, fullURL).Scan(&shortenURL)
 	if errors.Is(err, sql.ErrNoRows) {
 		return "", storage.ErrURLNotFound
 	} else if err != nil {
@@ -181,3 +371,19 @@ func (s *Storage) GetShortenURL(fullURL string) (string, error) {
 
 	return shortenURL, nil
 }
+
+// Click is served by the primary: the condition and the decrement are one statement, the row lock makes
+// concurrent clicks wait for each other and see the decremented value.
+func (s *Storage) Click(ctx context.Context, code string) (uint64, error) {
+	const fn = "storage.postgres.Click"
+
+	var left uint64
+	err := s.db.QueryRowContext(ctx, `UPDATE url SET clicks_left = clicks_left - 1
+WHERE shortenurl = ($1) AND max_clicks > 0 AND clicks_left > 0 RETURNING clicks_left`, code).Scan(&left)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrClicksExhausted
+	} else if err != nil {
+		return 0, e.WrapError(fn, err)
+	}
+	return left, nil
+}
@@ -4,13 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"urlShortener/internal/config"
 	st "urlShortener/internal/storage"
 )
 
+var linkColumnNames = []string{"id", "shortenurl", "fullurl", "created_at", "disabled", "redirect_status", "query_merge", "path_passthrough", "password_hash", "max_clicks", "not_before", "not_after", "fallback_url", "targets", "countries", "variants", "sticky", "clicks_left"}
+
+func linkRows(fullURL string, disabled bool) *sqlmock.Rows {
+	return sqlmock.NewRows(linkColumnNames).AddRow(1, "qewqeqwe", fullURL, time.Now(), disabled, 0, "", false, "", 0, nil, nil, "", "{}", "{}", "[]", "", 0)
+}
+
 func TestMaxIDdbNotEmpty(t *testing.T) {
 	db, mock, err := sqlmock.New()
 
@@ -58,10 +71,10 @@ func TestSaveURLSuccess(t *testing.T) {
 
 	fullURL := "https://ya.ru"
 	shortURL := "qewqeqwe"
-	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl\) VALUES \(\$1,\$2\)`).
-		ExpectExec().WithArgs(fullURL, shortURL).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$7\)`).
+		ExpectExec().WithArgs(fullURL, shortURL, 0, "", false, "", uint64(0), nil, nil, "", "{}", "{}", "[]", "").WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err = storage.SaveURL(fullURL, shortURL)
+	err = storage.SaveURL(context.Background(), fullURL, shortURL, st.Options{})
 	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -77,10 +90,10 @@ func TestSaveURLRepeatedURL(t *testing.T) {
 
 	fullURL := "https://ya.ru"
 	shortURL := "qewqeqwe"
-	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl\) VALUES \(\$1,\$2\)`).
-		ExpectExec().WithArgs(fullURL, shortURL).WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$7\)`).
+		ExpectExec().WithArgs(fullURL, shortURL, 0, "", false, "", uint64(0), nil, nil, "", "{}", "{}", "[]", "").WillReturnError(&pq.Error{Code: "23505"})
 
-	err = storage.SaveURL(fullURL, shortURL)
+	err = storage.SaveURL(context.Background(), fullURL, shortURL, st.Options{})
 	assert.True(t, errors.Is(err, st.ErrURLExists))
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -96,10 +109,10 @@ func TestSaveURLUnknownError(t *testing.T) {
 
 	fullURL := "https://ya.ru"
 	shortURL := "qewqeqwe"
-	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl\) VALUES \(\$1,\$2\)`).
-		ExpectExec().WithArgs(fullURL, shortURL).WillReturnError(errors.New("unknown error"))
+	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$7\)`).
+		ExpectExec().WithArgs(fullURL, shortURL, 0, "", false, "", uint64(0), nil, nil, "", "{}", "{}", "[]", "").WillReturnError(errors.New("unknown error"))
 
-	err = storage.SaveURL(fullURL, shortURL)
+	err = storage.SaveURL(context.Background(), fullURL, shortURL, st.Options{})
 	assert.Error(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -115,12 +128,12 @@ func TestGetFullURLSuccess(t *testing.T) {
 
 	fullURL := "https://ya.ru"
 	shortURL := "qewqeqwe"
-	mock.ExpectPrepare(`SELECT fullURL FROM url WHERE shortenurl = \(\$1\)`).
-		ExpectQuery().WithArgs(shortURL).WillReturnRows(sqlmock.NewRows([]string{"fullurl"}).AddRow(fullURL))
+	mock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs(shortURL).WillReturnRows(linkRows(fullURL, false))
 
-	resultFullURL, err := storage.GetFullURL(shortURL)
+	link, err := storage.Resolve(context.Background(), shortURL)
 	assert.NoError(t, err)
-	assert.Equal(t, resultFullURL, fullURL)
+	assert.Equal(t, link.FullURL, fullURL)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -134,10 +147,10 @@ func TestGetFullURLNotFound(t *testing.T) {
 	}
 
 	shortURL := "qewqeqwe"
-	mock.ExpectPrepare(`SELECT fullURL FROM url WHERE shortenurl = \(\$1\)`).
+	mock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
 		ExpectQuery().WithArgs(shortURL).WillReturnError(sql.ErrNoRows)
 
-	_, err = storage.GetFullURL(shortURL)
+	_, err = storage.Resolve(context.Background(), shortURL)
 	assert.True(t, errors.Is(err, st.ErrURLNotFound))
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -152,15 +165,51 @@ func TestGetFullURLUnexpectedError(t *testing.T) {
 	}
 
 	shortURL := "qewqeqwe"
-	mock.ExpectPrepare(`SELECT fullURL FROM url WHERE shortenurl = \(\$1\)`).
+	mock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
 		ExpectQuery().WithArgs(shortURL).WillReturnError(errors.New("error"))
 
-	_, err = storage.GetFullURL(shortURL)
+	_, err = storage.Resolve(context.Background(), shortURL)
 	assert.Error(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSaveURLWithOptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, ctx: context.Background()}
+
+	notAfter := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$7\)`).
+		ExpectExec().WithArgs("https://ya.ru", "qewqeqwe", 308, "append", true, "hash", uint64(5), nil, notAfter, "https://ya.ru/gone", `{"android":"market://details?id=ru.ya"}`, "{}", "[]", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = storage.SaveURL(context.Background(), "https://ya.ru", "qewqeqwe", st.Options{RedirectStatus: 308, Query: st.QueryAppend,
+		PathPassthrough: true, PasswordHash: "hash", MaxClicks: 5, NotAfter: notAfter, FallbackURL: "https://ya.ru/gone",
+		Targets: st.Targets{Android: "market://details?id=ru.ya"}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveWithOptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, ctx: context.Background()}
+
+	mock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs("qewqeqwe").
+		WillReturnRows(sqlmock.NewRows(linkColumnNames).AddRow(7, "qewqeqwe", "https://ya.ru", time.Now(), false, 307, "override", true, "", 2, nil, nil, "", "{}", "{}", "[]", "", 1))
+
+	link, err := storage.Resolve(context.Background(), "qewqeqwe")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), link.ID)
+	assert.Equal(t, st.Options{RedirectStatus: 307, Query: st.QueryOverride, PathPassthrough: true, MaxClicks: 2}, link.Options)
+	assert.Equal(t, uint64(1), link.ClicksLeft)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetShortenURLSuccess(t *testing.T) {
 	db, mock, err := sqlmock.New()
 
@@ -174,7 +223,7 @@ func TestGetShortenURLSuccess(t *testing.T) {
 	mock.ExpectPrepare(`SELECT shortenurl FROM url WHERE fullurl = \(\$1\)`).
 		ExpectQuery().WithArgs(fullURL).WillReturnRows(sqlmock.NewRows([]string{"shortenurl"}).AddRow(shortURL))
 
-	resultShortURL, err := storage.GetShortenURL(fullURL)
+	resultShortURL, err := storage.GetShortenURL(context.Background(), fullURL)
 	assert.NoError(t, err)
 	assert.Equal(t, resultShortURL, shortURL)
 
@@ -193,7 +242,7 @@ func TestGetShortenURLEmpty(t *testing.T) {
 	mock.ExpectPrepare(`SELECT shortenurl FROM url WHERE fullurl = \(\$1\)`).
 		ExpectQuery().WithArgs(fullURL).WillReturnError(sql.ErrNoRows)
 
-	_, err = storage.GetShortenURL(fullURL)
+	_, err = storage.GetShortenURL(context.Background(), fullURL)
 	assert.True(t, errors.Is(err, st.ErrURLNotFound))
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -211,8 +260,187 @@ func TestGetShortenURLUnknownError(t *testing.T) {
 	mock.ExpectPrepare(`SELECT shortenurl FROM url WHERE fullurl = \(\$1\)`).
 		ExpectQuery().WithArgs(fullURL).WillReturnError(errors.New("unknown"))
 
-	_, err = storage.GetShortenURL(fullURL)
+	_, err = storage.GetShortenURL(context.Background(), fullURL)
 	assert.Error(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestPingSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+
+	storage := &Storage{db: db, ctx: context.Background()}
+
+	mock.ExpectPing()
+
+	assert.NoError(t, storage.Ping(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPingErr(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+
+	storage := &Storage{db: db, ctx: context.Background()}
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	assert.Error(t, storage.Ping(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFullURLDisabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	storage := &Storage{
+		db:  db,
+		ctx: context.Background(),
+	}
+
+	shortURL := "qewqeqwe"
+	mock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs(shortURL).WillReturnRows(linkRows("https://ya.ru", true))
+
+	_, err = storage.Resolve(context.Background(), shortURL)
+	assert.True(t, errors.Is(err, st.ErrURLDisabled))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConnectRetries(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing()
+
+	err = connect(context.Background(), db, time.Second, time.Millisecond, logger)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConnectGivesUp(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	pingErr := errors.New("connection refused")
+	for i := 0; i < 10; i++ {
+		mock.ExpectPing().WillReturnError(pingErr)
+	}
+
+	err = connect(context.Background(), db, 20*time.Millisecond, 5*time.Millisecond, logger)
+	assert.ErrorIs(t, err, pingErr)
+}
+
+func TestClick(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, ctx: context.Background()}
+
+	clickQuery := `UPDATE url SET clicks_left = clicks_left - 1\s+WHERE shortenurl = \(\$1\) AND max_clicks > 0 AND clicks_left > 0 RETURNING clicks_left`
+	mock.ExpectQuery(clickQuery).WithArgs("qewqeqwe").WillReturnRows(sqlmock.NewRows([]string{"clicks_left"}).AddRow(4))
+	mock.ExpectQuery(clickQuery).WithArgs("qewqeqwe").WillReturnRows(sqlmock.NewRows([]string{"clicks_left"}))
+
+	left, err := storage.Click(context.Background(), "qewqeqwe")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), left)
+
+	_, err = storage.Click(context.Background(), "qewqeqwe")
+	assert.True(t, errors.Is(err, st.ErrClicksExhausted))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountVariant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	countQuery := `INSERT INTO variant_clicks\(shortenurl, variant, clicks\) VALUES \(\$1, \$2, 1\)\s+ON CONFLICT \(shortenurl, variant\) DO UPDATE SET clicks = variant_clicks.clicks \+ 1`
+	mock.ExpectExec(countQuery).WithArgs("qewqeqwe", "B").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(countQuery).WithArgs("qewqeqwa", "A").WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectQuery(`SELECT variant, clicks FROM variant_clicks WHERE shortenurl = \(\$1\)`).WithArgs("qewqeqwe").
+		WillReturnRows(sqlmock.NewRows([]string{"variant", "clicks"}).AddRow("A", 3).AddRow("B", 5))
+
+	assert.NoError(t, storage.CountVariant(context.Background(), "qewqeqwe", "B"))
+	err = storage.CountVariant(context.Background(), "qewqeqwa", "A")
+	assert.True(t, errors.Is(err, st.ErrURLNotFound))
+
+	clicks, err := storage.VariantClicks(context.Background(), "qewqeqwe")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"A": 3, "B": 5}, clicks)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountTarget(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	storage := &Storage{db: db, logger: logrus.New()}
+
+	countQuery := `INSERT INTO target_clicks\(shortenurl, target, clicks\) VALUES \(\$1, \$2, 1\)\s+ON CONFLICT \(shortenurl, target\) DO UPDATE SET clicks = target_clicks.clicks \+ 1`
+	mock.ExpectExec(countQuery).WithArgs("qewqeqwe", "ios").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(countQuery).WithArgs("qewqeqwa", "default").WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectQuery(`SELECT target, clicks FROM target_clicks WHERE shortenurl = \(\$1\)`).WithArgs("qewqeqwe").
+		WillReturnRows(sqlmock.NewRows([]string{"target", "clicks"}).AddRow("ios", 3).AddRow("default", 5))
+
+	assert.NoError(t, storage.CountTarget(context.Background(), "qewqeqwe", "ios"))
+	err = storage.CountTarget(context.Background(), "qewqeqwa", "default")
+	assert.True(t, errors.Is(err, st.ErrURLNotFound))
+
+	clicks, err := storage.TargetClicks(context.Background(), "qewqeqwe")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"ios": 3, "default": 5}, clicks)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestClickContentionPostgres runs against a real database, sqlmock can't show how row locks serialize
+// the updates: URLSHORTENER_TEST_POSTGRES_DSN=postgres://... go test ./internal/storage/postgres/
+func TestClickContentionPostgres(t *testing.T) {
+	dsn := os.Getenv("URLSHORTENER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("URLSHORTENER_TEST_POSTGRES_DSN is not set")
+	}
+	const (
+		maxClicks = 25
+		clickers  = 500
+	)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	ctx := context.Background()
+	storage, err := New(ctx, &config.PostgresConfig{DSN: dsn, ConnectBackoff: time.Second, ReplicaCheckInterval: time.Second}, logger)
+	assert.NoError(t, err)
+	defer storage.Close()
+
+	code := fmt.Sprintf("c%d", time.Now().UnixNano()%1_000_000_000)
+	assert.NoError(t, storage.SaveURL(ctx, "https://example.com/"+code, code, st.Options{MaxClicks: maxClicks}))
+	defer storage.DeleteLink(ctx, code)
+
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		clicked atomic.Int64
+	)
+	for i := 0; i < clickers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := storage.Click(ctx, code); err == nil {
+				clicked.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(maxClicks), clicked.Load())
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+// replica is a read-only pool. It serves lookups while healthy: a failed query takes it out of rotation,
+// the next successful ping brings it back.
+type replica struct {
+	// name is used in logs instead of the DSN, which may hold a password.
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+func (s *Storage) openReplicas(ctx context.Context, dsns []string, checkInterval time.Duration) error {
+	const fn = "storage.postgres.openReplicas"
+
+	for i, dsn := range dsns {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			_ = s.closeReplicas()
+			return e.WrapError(fn, fmt.Errorf("replica %d: %w", i+1, err))
+		}
+		s.replicas = append(s.replicas, &replica{name: fmt.Sprintf("replica %d", i+1), db: db})
+	}
+	if len(s.replicas) == 0 {
+		return nil
+	}
+
+	// недоступная на старте реплика не мешает запуску, ее вернет следующая проверка
+	s.checkReplicas(ctx, checkInterval)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	s.stopWatch = cancel
+	go s.watchReplicas(watchCtx, checkInterval)
+	return nil
+}
+
+func (s *Storage) watchReplicas(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkReplicas(ctx, interval)
+		}
+	}
+}
+
+// checkReplicas pings every replica, a ping gets at most timeout.
+func (s *Storage) checkReplicas(ctx context.Context, timeout time.Duration) {
+	for _, r := range s.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		s.setHealthy(r, err)
+	}
+}
+
+func (s *Storage) setHealthy(r *replica, err error) {
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		s.logger.WithField("replica", r.name).Info("storage.postgres: replica is back, reads go to it")
+	} else {
+		s.logger.WithField("replica", r.name).Warnf("storage.postgres: replica failed, reads go to other replicas or the primary: %v", err)
+	}
+}
+
+// replica picks a healthy replica round-robin. It returns nil when the read must go to the primary.
+func (s *Storage) replica(ctx context.Context) *replica {
+	if len(s.replicas) == 0 || storage.PrimaryRequired(ctx) {
+		return nil
+	}
+
+	start := s.nextReplica.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// fallBack reports whether a read from the replica r must be repeated on the primary. An unexpected error
+// takes the replica out of rotation.
+func (s *Storage) fallBack(ctx context.Context, r *replica, err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, storage.ErrURLNotFound),
+		errors.Is(err, storage.ErrURLDisabled):
+		return false
+	case ctx.Err() != nil:
+		// клиент ушел, primary тут не поможет
+		return false
+	default:
+		s.setHealthy(r, err)
+		return true
+	}
+}
+
+func (s *Storage) closeReplicas() error {
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
+
+	var errs []error
+	for _, r := range s.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
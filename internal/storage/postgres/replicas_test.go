@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	st "urlShortener/internal/storage"
+)
+
+func newReplicatedStorage(t *testing.T) (*Storage, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+	primary, primaryMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	r := &replica{name: "replica 1", db: replicaDB}
+	r.healthy.Store(true)
+	storage := &Storage{
+		db:       primary,
+		ctx:      context.Background(),
+		logger:   logger,
+		replicas: []*replica{r},
+	}
+	return storage, primaryMock, replicaMock
+}
+
+func TestGetFullURLFromReplica(t *testing.T) {
+	storage, primaryMock, replicaMock := newReplicatedStorage(t)
+
+	replicaMock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs("aaaaaaaaaa").
+		WillReturnRows(linkRows("https://ozon.ru", false))
+
+	link, err := storage.Resolve(context.Background(), "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullURL)
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestGetFullURLNotReplicatedYet(t *testing.T) {
+	storage, primaryMock, replicaMock := newReplicatedStorage(t)
+
+	replicaMock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs("aaaaaaaaaa").
+		WillReturnRows(sqlmock.NewRows(linkColumnNames))
+	primaryMock.ExpectPrepare(`SELECT id, shortenurl, fullurl, created_at, disabled, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left FROM url WHERE shortenurl = \(\$1\)`).
+		ExpectQuery().WithArgs("aaaaaaaaaa").
+		WillReturnRows(linkRows("https://ozon.ru", false))
+
+	link, err := storage.Resolve(context.Background(), "aaaaaaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", link.FullURL)
+	assert.True(t, storage.replicas[0].healthy.Load())
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaFailover(t *testing.T) {
+	storage, primaryMock, replicaMock := newReplicatedStorage(t)
+
+	replicaMock.ExpectPrepare(`SELECT shortenurl FROM url WHERE fullurl = \(\$1\)`).
+		WillReturnError(errors.New("connection refused"))
+	for i := 0; i < 2; i++ {
+		primaryMock.ExpectPrepare(`SELECT shortenurl FROM url WHERE fullurl = \(\$1\)`).
+			ExpectQuery().WithArgs("https://ozon.ru").
+			WillReturnRows(sqlmock.NewRows([]string{"shortenurl"}).AddRow("aaaaaaaaaa"))
+	}
+
+	// первый запрос падает на реплике и повторяется на primary, второй сразу идет на primary
+	for i := 0; i < 2; i++ {
+		shortenURL, err := storage.GetShortenURL(context.Background(), "https://ozon.ru")
+		assert.NoError(t, err)
+		assert.Equal(t, "aaaaaaaaaa", shortenURL)
+	}
+	assert.False(t, storage.replicas[0].healthy.Load())
+
+	replicaMock.ExpectPing()
+	storage.checkReplicas(context.Background(), time.Second)
+	assert.True(t, storage.replicas[0].healthy.Load())
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestGetShortenURLWithPrimary(t *testing.T) {
+	storage, primaryMock, replicaMock := newReplicatedStorage(t)
+
+	primaryMock.ExpectPrepare(`SELECT shortenurl FROM url WHERE fullurl = \(\$1\)`).
+		ExpectQuery().WithArgs("https://ozon.ru").
+		WillReturnRows(sqlmock.NewRows([]string{"shortenurl"}).AddRow("aaaaaaaaaa"))
+
+	shortenURL, err := storage.GetShortenURL(st.WithPrimary(context.Background()), "https://ozon.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", shortenURL)
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestWritesStayOnPrimary(t *testing.T) {
+	storage, primaryMock, replicaMock := newReplicatedStorage(t)
+
+	primaryMock.ExpectPrepare(`INSERT INTO url\(fullurl, shortenurl, redirect_status, query_merge, path_passthrough, password_hash, max_clicks, not_before, not_after, fallback_url, targets, countries, variants, sticky, clicks_left\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$7\)`).
+		ExpectExec().WithArgs("https://ozon.ru", "aaaaaaaaaa", 0, "", false, "", uint64(0), nil, nil, "", "{}", "{}", "[]", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := storage.SaveURL(context.Background(), "https://ozon.ru", "aaaaaaaaaa", st.Options{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
@@ -1,14 +1,216 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
 
 var (
 	ErrURLExists   = errors.New("URL already exists")
 	ErrURLNotFound = errors.New("URL not found")
+	ErrURLDisabled = errors.New("URL is disabled")
+	// ErrNotSupported is returned by wrappers when the wrapped storage doesn't implement an optional interface.
+	ErrNotSupported = errors.New("operation is not supported by the storage")
+	// ErrUnavailable is returned without calling the storage while it's considered down.
+	ErrUnavailable = errors.New("storage is unavailable")
+	// ErrClicksExhausted is returned by Clicker when the link has used all its clicks.
+	ErrClicksExhausted = errors.New("link has no clicks left")
 )
 
 type Storager interface {
-	SaveURL(urlToSave string, shortenUrl string) error
-	GetFullURL(shortenURL string) (string, error)
-	GetShortenURL(fullURL string) (string, error)
+	SaveURL(ctx context.Context, urlToSave string, shortenUrl string, opts Options) error
+	// Resolve returns the link to redirect to, ErrURLDisabled if it's disabled.
+	Resolve(ctx context.Context, shortenURL string) (Link, error)
+	GetShortenURL(ctx context.Context, fullURL string) (string, error)
+}
+
+// Clicker is implemented by storages that can count clicks of links with MaxClicks.
+type Clicker interface {
+	// Click uses one click of the link and returns how many are left. The check and the decrement are
+	// atomic, concurrent clicks never use more than MaxClicks. ErrClicksExhausted is returned when none
+	// are left, also for links without a limit.
+	Click(ctx context.Context, code string) (uint64, error)
+}
+
+// VariantCounter is implemented by storages that keep the clicks of the variants of links.
+type VariantCounter interface {
+	// CountVariant adds a click to the variant of the link.
+	CountVariant(ctx context.Context, code string, variant string) error
+	// VariantClicks returns the clicks of the link by variant name, variants without clicks are missing.
+	VariantClicks(ctx context.Context, code string) (map[string]uint64, error)
+}
+
+// TargetCounter is implemented by storages that keep which targets the redirects of links choose.
+type TargetCounter interface {
+	// CountTarget adds a redirect to the target of the link: the device, the country code or "default".
+	CountTarget(ctx context.Context, code string, target string) error
+	// TargetClicks returns the redirects of the link by target, targets never chosen are missing.
+	TargetClicks(ctx context.Context, code string) (map[string]uint64, error)
+}
+
+// Pinger is implemented by storages that depend on an external service and can check its reachability.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Options are the settings of a link chosen when it is created.
+type Options struct {
+	// RedirectStatus is the HTTP status of the redirect, zero means the server default.
+	RedirectStatus int
+	// Query tells how the query of the request is merged into the destination.
+	Query QueryMerge
+	// PathPassthrough appends the path after the code to the destination path.
+	PathPassthrough bool
+	// PasswordHash is the bcrypt hash of the password that opens the link, empty for an open link.
+	PasswordHash string
+	// MaxClicks is how many redirects the link serves, zero means no limit.
+	MaxClicks uint64
+	// NotBefore and NotAfter are the window when the link redirects, a zero time leaves that side open.
+	NotBefore time.Time
+	NotAfter  time.Time
+	// FallbackURL is where the link redirects outside of its window, empty means it's not available there.
+	FallbackURL string
+	// Targets replace the URL for some devices, the URL stays the default.
+	Targets Targets
+	// Countries replace the URL for visitors from some countries.
+	Countries Countries
+	// Variants split the visitors between several URLs by weight, they replace the URL of the link.
+	Variants Variants
+	// Sticky tells how a visitor keeps the variant chosen for them, the empty value picks on every visit.
+	Sticky Stickiness
+}
+
+// Equal reports whether the options are the same, the times of the window are compared as instants.
+func (o Options) Equal(other Options) bool {
+	return o.RedirectStatus == other.RedirectStatus &&
+		o.Query == other.Query &&
+		o.PathPassthrough == other.PathPassthrough &&
+		o.PasswordHash == other.PasswordHash &&
+		o.MaxClicks == other.MaxClicks &&
+		o.NotBefore.Equal(other.NotBefore) &&
+		o.NotAfter.Equal(other.NotAfter) &&
+		o.FallbackURL == other.FallbackURL &&
+		o.Targets == other.Targets &&
+		maps.Equal(o.Countries, other.Countries) &&
+		slices.Equal(o.Variants, other.Variants) &&
+		o.Sticky == other.Sticky
+}
+
+// Targets are the destinations of a link by the device of the visitor, empty ones use the URL of the link.
+type Targets struct {
+	IOS     string `json:"ios,omitempty"`
+	Android string `json:"android,omitempty"`
+	Desktop string `json:"desktop,omitempty"`
+	Bot     string `json:"bot,omitempty"`
+}
+
+// QueryMerge is the policy for the query of the request to a short link.
+type QueryMerge string
+
+const (
+	// QueryDrop ignores the query of the request.
+	QueryDrop QueryMerge = ""
+	// QueryKeep adds the request parameters, the destination keeps its own values of the same names.
+	QueryKeep QueryMerge = "keep"
+	// QueryOverride adds the request parameters, replacing the destination values of the same names.
+	QueryOverride QueryMerge = "override"
+	// QueryAppend adds the request values after the destination values of the same names.
+	QueryAppend QueryMerge = "append"
+)
+
+func ValidQueryMerge(merge QueryMerge) bool {
+	switch merge {
+	case QueryDrop, QueryKeep, QueryOverride, QueryAppend:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidRedirectStatus reports whether a link may redirect with status, zero stands for the server default.
+func ValidRedirectStatus(status int) bool {
+	switch status {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// Link is a short link with its metadata as it is kept in the storage.
+type Link struct {
+	ID        uint64
+	Code      string
+	FullURL   string
+	CreatedAt time.Time
+	Disabled  bool
+	Options
+	// ClicksLeft is how many redirects a link with MaxClicks still serves.
+	ClicksLeft uint64
+}
+
+type Stats struct {
+	Links         uint64
+	DisabledLinks uint64
+}
+
+// Manager is implemented by storages that support link administration.
+type Manager interface {
+	GetLink(ctx context.Context, code string) (Link, error)
+	FindByFullURL(ctx context.Context, fullURL string) (Link, error)
+	DeleteLink(ctx context.Context, code string) error
+	SetDisabled(ctx context.Context, code string, disabled bool) error
+	// ReassignDomain replaces the host from with the host to in every destination and returns
+	// the number of changed links. It fails with ErrURLExists if the new destination is already stored.
+	ReassignDomain(ctx context.Context, from string, to string) (uint64, error)
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// Lister is implemented by storages that can stream all links ordered by ID.
+type Lister interface {
+	ForEachLink(ctx context.Context, visit func(link Link) error) error
+}
+
+// Restorer is implemented by storages that can save a link with its original ID and metadata.
+type Restorer interface {
+	// RestoreLink saves the link as is, a zero ID is assigned by the storage and a zero CreatedAt becomes now.
+	// The link with the same code is replaced only with overwrite, otherwise ErrURLExists is returned.
+	// ErrURLExists is also returned if the URL belongs to another code. It returns the ID the link is
+	// stored with, an overwritten link keeps its ID.
+	RestoreLink(ctx context.Context, link Link, overwrite bool) (uint64, error)
+}
+
+type primaryKey struct{}
+
+// WithPrimary marks the reads made with ctx to be served by the primary database, for lookups that must
+// see a write made just before. Storages without read replicas ignore it.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryRequired reports whether ctx was marked by WithPrimary.
+func PrimaryRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(primaryKey{}).(bool)
+	return required
+}
+
+// ReplaceHost returns fullURL with the host from replaced by to. The port is kept.
+func ReplaceHost(fullURL string, from string, to string) (string, bool) {
+	parsed, err := url.Parse(fullURL)
+	if err != nil || !strings.EqualFold(parsed.Hostname(), from) {
+		return fullURL, false
+	}
+
+	if port := parsed.Port(); port != "" {
+		parsed.Host = to + ":" + port
+	} else {
+		parsed.Host = to
+	}
+	return parsed.String(), true
 }
@@ -0,0 +1,57 @@
+package storage
+
+import "strconv"
+
+// Variant is one of the URLs a link splits its visitors between.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight uint32 `json:"weight"`
+}
+
+// Variants are the destinations of an A/B link in the order they were given.
+type Variants []Variant
+
+// NewVariants keeps the order of the variants, empty names become A, B, C and so on by position.
+func NewVariants(variants []Variant) Variants {
+	if len(variants) == 0 {
+		return nil
+	}
+	named := make(Variants, len(variants))
+	for i, variant := range variants {
+		if variant.Name == "" {
+			variant.Name = VariantName(i)
+		}
+		named[i] = variant
+	}
+	return named
+}
+
+// VariantName is the default name of the variant at position i: A to Z, then V27, V28 and so on.
+func VariantName(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return "V" + strconv.Itoa(i+1)
+}
+
+// Stickiness tells how a visitor keeps seeing the same variant of a link.
+type Stickiness string
+
+const (
+	// StickyNone picks a variant on every visit.
+	StickyNone Stickiness = ""
+	// StickyCookie remembers the variant in a cookie of the visitor.
+	StickyCookie Stickiness = "cookie"
+	// StickyHash derives the variant from the client IP and the User-Agent, so it needs no cookie.
+	StickyHash Stickiness = "hash"
+)
+
+func ValidStickiness(sticky Stickiness) bool {
+	switch sticky {
+	case StickyNone, StickyCookie, StickyHash:
+		return true
+	default:
+		return false
+	}
+}
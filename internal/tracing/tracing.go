@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"urlShortener/internal/config"
+	"urlShortener/utils/e"
+)
+
+const TracerName = "urlShortener"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+// ShutdownFunc flushes the remaining spans and releases the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// New installs the global tracer provider and the W3C trace context propagator.
+// With ExporterNone spans are still propagated, but not exported anywhere.
+func New(ctx context.Context, cfg config.TracingConfig) (ShutdownFunc, error) {
+	const fn = "tracing.New"
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	closeFile := func() error { return nil }
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, e.WrapError(fn, err)
+		}
+		exporter = exp
+	case ExporterFile:
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, e.WrapError(fn, err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, e.WrapError(fn, err)
+		}
+		exporter, closeFile = exp, file.Close
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, e.WrapError(fn, err)
+		}
+		exporter = exp
+	default:
+		return nil, e.WrapError(fn, fmt.Errorf("%w: %s", ErrUnknownExporter, cfg.Exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		return errors.Join(err, closeFile())
+	}, nil
+}
+
+// Tracer returns the tracer of the application from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// End marks the span as failed if err is not nil and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"os"
+	"path/filepath"
+	"testing"
+	"urlShortener/internal/config"
+)
+
+func TestNewFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := New(context.Background(), config.TracingConfig{
+		Exporter:    ExporterFile,
+		FilePath:    path,
+		ServiceName: "test",
+		SampleRatio: 1,
+	})
+	assert.NoError(t, err)
+
+	_, span := Tracer().Start(context.Background(), "test-span")
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "test-span")
+}
+
+func TestNewNoneExporter(t *testing.T) {
+	shutdown, err := New(context.Background(), config.TracingConfig{Exporter: ExporterNone})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestNewUnknownExporter(t *testing.T) {
+	_, err := New(context.Background(), config.TracingConfig{Exporter: "jaeger"})
+	assert.True(t, errors.Is(err, ErrUnknownExporter))
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, span := Tracer().Start(context.Background(), "failed")
+	End(span, errors.New("boom"))
+	_, span = Tracer().Start(context.Background(), "succeeded")
+	End(span, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
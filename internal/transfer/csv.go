@@ -0,0 +1,382 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"urlShortener/internal/storage"
+)
+
+// Formats for NewReader and NewWriter. Only JSONLines and CSV can be written, the rest are
+// CSV exports of other shorteners that can be imported.
+const (
+	JSONLines = "jsonl"
+	CSV       = "csv"
+	Bitly     = "bitly"
+	Rebrandly = "rebrandly"
+	ShortIO   = "shortio"
+)
+
+// Columns maps record fields to CSV header names, empty names are not read.
+// The code is taken from Code or, if it is empty, from the last path segment of ShortURL.
+type Columns struct {
+	ID              string
+	Code            string
+	ShortURL        string
+	URL             string
+	CreatedAt       string
+	Disabled        string
+	Redirect        string
+	Query           string
+	PathPassthrough string
+	PasswordHash    string
+	MaxClicks       string
+	ClicksLeft      string
+	NotBefore       string
+	NotAfter        string
+	FallbackURL     string
+	TargetIOS       string
+	TargetAndroid   string
+	TargetDesktop   string
+	TargetBot       string
+	// Countries is a JSON object of country codes to URLs, like {"DE":"https://..."}.
+	Countries string
+	// Variants is a JSON array of variants, like [{"name":"A","url":"https://...","weight":1}].
+	Variants string
+	Sticky   string
+}
+
+var nativeColumns = Columns{
+	ID:              "id",
+	Code:            "code",
+	URL:             "url",
+	CreatedAt:       "created_at",
+	Disabled:        "disabled",
+	Redirect:        "redirect",
+	Query:           "query",
+	PathPassthrough: "path_passthrough",
+	PasswordHash:    "password_hash",
+	MaxClicks:       "max_clicks",
+	ClicksLeft:      "clicks_left",
+	NotBefore:       "not_before",
+	NotAfter:        "not_after",
+	FallbackURL:     "fallback_url",
+	TargetIOS:       "target_ios",
+	TargetAndroid:   "target_android",
+	TargetDesktop:   "target_desktop",
+	TargetBot:       "target_bot",
+	Countries:       "countries",
+	Variants:        "variants",
+	Sticky:          "sticky",
+}
+
+// presets - заголовки CSV-выгрузок популярных сервисов, регистр не важен.
+var presets = map[string]Columns{
+	CSV:       nativeColumns,
+	Bitly:     {ShortURL: "link", URL: "long_url", CreatedAt: "created_at"},
+	Rebrandly: {Code: "slashtag", URL: "destination", CreatedAt: "createdAt"},
+	ShortIO:   {ShortURL: "shortURL", URL: "originalURL", CreatedAt: "createdAt"},
+}
+
+// NewReader returns a reader of one of the formats.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	if format == JSONLines {
+		return NewJSONLinesReader(r), nil
+	}
+	columns, ok := presets[format]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	return NewCSVReader(r, columns), nil
+}
+
+// NewWriter returns a writer of JSONLines or CSV.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case JSONLines:
+		return NewJSONLinesWriter(w), nil
+	case CSV:
+		return NewCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns Columns
+	index   map[string]int
+}
+
+// NewCSVReader reads a CSV file with a header, columns may go in any order.
+func NewCSVReader(r io.Reader, columns Columns) Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return &csvReader{reader: reader, columns: columns}
+}
+
+func (r *csvReader) Read() (Record, error) {
+	if r.index == nil {
+		if err := r.readHeader(); err != nil {
+			return Record{}, err
+		}
+	}
+
+	row, err := r.reader.Read()
+	if err == io.EOF {
+		return Record{}, err
+	} else if err != nil {
+		return Record{}, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+
+	var record Record
+	record.URL = r.field(row, r.columns.URL)
+	record.Code = r.field(row, r.columns.Code)
+	if record.Code == "" {
+		record.Code = codeFromShortURL(r.field(row, r.columns.ShortURL))
+	}
+
+	if id := r.field(row, r.columns.ID); id != "" {
+		if record.ID, err = strconv.ParseUint(id, 10, 64); err != nil {
+			return Record{}, fmt.Errorf("%w: id %q", ErrInvalidRecord, id)
+		}
+	}
+	if createdAt := r.field(row, r.columns.CreatedAt); createdAt != "" {
+		if record.CreatedAt, err = parseTime(createdAt); err != nil {
+			return Record{}, fmt.Errorf("%w: created at %q", ErrInvalidRecord, createdAt)
+		}
+	}
+	if disabled := r.field(row, r.columns.Disabled); disabled != "" {
+		if record.Disabled, err = strconv.ParseBool(disabled); err != nil {
+			return Record{}, fmt.Errorf("%w: disabled %q", ErrInvalidRecord, disabled)
+		}
+	}
+	if redirect := r.field(row, r.columns.Redirect); redirect != "" {
+		if record.Redirect, err = strconv.Atoi(redirect); err != nil {
+			return Record{}, fmt.Errorf("%w: redirect %q", ErrInvalidRecord, redirect)
+		}
+	}
+	record.Query = r.field(row, r.columns.Query)
+	if passthrough := r.field(row, r.columns.PathPassthrough); passthrough != "" {
+		if record.PathPassthrough, err = strconv.ParseBool(passthrough); err != nil {
+			return Record{}, fmt.Errorf("%w: path passthrough %q", ErrInvalidRecord, passthrough)
+		}
+	}
+	record.PasswordHash = r.field(row, r.columns.PasswordHash)
+	if maxClicks := r.field(row, r.columns.MaxClicks); maxClicks != "" {
+		if record.MaxClicks, err = strconv.ParseUint(maxClicks, 10, 64); err != nil {
+			return Record{}, fmt.Errorf("%w: max clicks %q", ErrInvalidRecord, maxClicks)
+		}
+	}
+	if clicksLeft := r.field(row, r.columns.ClicksLeft); clicksLeft != "" {
+		if record.ClicksLeft, err = strconv.ParseUint(clicksLeft, 10, 64); err != nil {
+			return Record{}, fmt.Errorf("%w: clicks left %q", ErrInvalidRecord, clicksLeft)
+		}
+	}
+	if notBefore := r.field(row, r.columns.NotBefore); notBefore != "" {
+		parsed, err := parseTime(notBefore)
+		if err != nil {
+			return Record{}, fmt.Errorf("%w: not before %q", ErrInvalidRecord, notBefore)
+		}
+		record.NotBefore = &parsed
+	}
+	if notAfter := r.field(row, r.columns.NotAfter); notAfter != "" {
+		parsed, err := parseTime(notAfter)
+		if err != nil {
+			return Record{}, fmt.Errorf("%w: not after %q", ErrInvalidRecord, notAfter)
+		}
+		record.NotAfter = &parsed
+	}
+	record.FallbackURL = r.field(row, r.columns.FallbackURL)
+	targets := storage.Targets{
+		IOS:     r.field(row, r.columns.TargetIOS),
+		Android: r.field(row, r.columns.TargetAndroid),
+		Desktop: r.field(row, r.columns.TargetDesktop),
+		Bot:     r.field(row, r.columns.TargetBot),
+	}
+	if targets != (storage.Targets{}) {
+		record.Targets = &targets
+	}
+	if countries := r.field(row, r.columns.Countries); countries != "" {
+		if err = json.Unmarshal([]byte(countries), &record.Countries); err != nil {
+			return Record{}, fmt.Errorf("%w: countries %q", ErrInvalidRecord, countries)
+		}
+	}
+	if variants := r.field(row, r.columns.Variants); variants != "" {
+		if err = json.Unmarshal([]byte(variants), &record.Variants); err != nil {
+			return Record{}, fmt.Errorf("%w: variants %q", ErrInvalidRecord, variants)
+		}
+	}
+	record.Sticky = r.field(row, r.columns.Sticky)
+
+	return record, nil
+}
+
+func (r *csvReader) readHeader() error {
+	header, err := r.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("%w: header: %v", ErrInvalidRecord, err)
+	}
+
+	r.index = make(map[string]int, len(header))
+	for i, name := range header {
+		r.index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := r.index[strings.ToLower(r.columns.URL)]; !ok {
+		return fmt.Errorf("%w: header has no %q column", ErrInvalidRecord, r.columns.URL)
+	}
+	return nil
+}
+
+func (r *csvReader) field(row []string, column string) string {
+	if column == "" {
+		return ""
+	}
+	i, ok := r.index[strings.ToLower(column)]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func codeFromShortURL(shortURL string) string {
+	if shortURL == "" {
+		return ""
+	}
+	if !strings.Contains(shortURL, "://") {
+		shortURL = "https://" + shortURL
+	}
+	parsed, err := url.Parse(shortURL)
+	if err != nil {
+		return ""
+	}
+	code := path.Base(parsed.Path)
+	if code == "/" || code == "." {
+		return ""
+	}
+	return code
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTime accepts the layouts seen in CSV exports, times without a zone are UTC.
+func parseTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	if unix, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	return time.Time{}, err
+}
+
+type csvWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) Write(record Record) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	var targets storage.Targets
+	if record.Targets != nil {
+		targets = *record.Targets
+	}
+	var countries string
+	if len(record.Countries) > 0 {
+		encoded, err := json.Marshal(record.Countries)
+		if err != nil {
+			return err
+		}
+		countries = string(encoded)
+	}
+	var variants string
+	if len(record.Variants) > 0 {
+		encoded, err := json.Marshal(record.Variants)
+		if err != nil {
+			return err
+		}
+		variants = string(encoded)
+	}
+
+	return w.writer.Write([]string{
+		strconv.FormatUint(record.ID, 10),
+		record.Code,
+		record.URL,
+		record.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatBool(record.Disabled),
+		strconv.Itoa(record.Redirect),
+		record.Query,
+		strconv.FormatBool(record.PathPassthrough),
+		record.PasswordHash,
+		strconv.FormatUint(record.MaxClicks, 10),
+		strconv.FormatUint(record.ClicksLeft, 10),
+		formatBound(record.NotBefore),
+		formatBound(record.NotAfter),
+		record.FallbackURL,
+		targets.IOS,
+		targets.Android,
+		targets.Desktop,
+		targets.Bot,
+		countries,
+		variants,
+		record.Sticky,
+	})
+}
+
+// formatBound leaves an open side of the window empty.
+func formatBound(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Flush writes the header even for an empty storage, so the file can be imported back.
+func (w *csvWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.writer.Write([]string{nativeColumns.ID, nativeColumns.Code, nativeColumns.URL, nativeColumns.CreatedAt, nativeColumns.Disabled, nativeColumns.Redirect,
+		nativeColumns.Query, nativeColumns.PathPassthrough, nativeColumns.PasswordHash,
+		nativeColumns.MaxClicks, nativeColumns.ClicksLeft, nativeColumns.NotBefore, nativeColumns.NotAfter, nativeColumns.FallbackURL,
+		nativeColumns.TargetIOS, nativeColumns.TargetAndroid, nativeColumns.TargetDesktop, nativeColumns.TargetBot,
+		nativeColumns.Countries, nativeColumns.Variants, nativeColumns.Sticky})
+}
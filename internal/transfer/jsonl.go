@@ -0,0 +1,45 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type jsonLinesReader struct {
+	decoder *json.Decoder
+}
+
+func NewJSONLinesReader(r io.Reader) Reader {
+	return &jsonLinesReader{decoder: json.NewDecoder(r)}
+}
+
+func (r *jsonLinesReader) Read() (Record, error) {
+	var record Record
+	if err := r.decoder.Decode(&record); err != nil {
+		if err == io.EOF {
+			return Record{}, err
+		}
+		return Record{}, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+	return record, nil
+}
+
+type jsonLinesWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func NewJSONLinesWriter(w io.Writer) Writer {
+	buffered := bufio.NewWriter(w)
+	return &jsonLinesWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}
+}
+
+func (w *jsonLinesWriter) Write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonLinesWriter) Flush() error {
+	return w.buffered.Flush()
+}
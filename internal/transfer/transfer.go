@@ -0,0 +1,374 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+	"urlShortener/internal/storage"
+	"urlShortener/utils/e"
+)
+
+var (
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrExists is returned in ModeStrict when the code or the URL is already stored.
+	ErrExists        = errors.New("link already exists")
+	ErrUnknownMode   = errors.New("unknown import mode")
+	ErrUnknownFormat = errors.New("unknown format")
+)
+
+// Record is one link in the portable formats.
+type Record struct {
+	ID        uint64    `json:"id,omitempty"`
+	Code      string    `json:"code"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+	Disabled  bool      `json:"disabled,omitempty"`
+	// Redirect is the HTTP status of the redirect, zero means the server default.
+	Redirect        int    `json:"redirect,omitempty"`
+	Query           string `json:"query,omitempty"`
+	PathPassthrough bool   `json:"pathPassthrough,omitempty"`
+	// PasswordHash is the bcrypt hash of the link password, the password itself is never exported.
+	PasswordHash string `json:"passwordHash,omitempty"`
+	// MaxClicks limits the redirects of the link, ClicksLeft is how many it still serves.
+	MaxClicks  uint64 `json:"maxClicks,omitempty"`
+	ClicksLeft uint64 `json:"clicksLeft,omitempty"`
+	// NotBefore and NotAfter are the window when the link redirects, nil for an open side.
+	NotBefore   *time.Time `json:"notBefore,omitempty"`
+	NotAfter    *time.Time `json:"notAfter,omitempty"`
+	FallbackURL string     `json:"fallbackURL,omitempty"`
+	// Targets are the URLs by the device of the visitor, nil if the link has none.
+	Targets *storage.Targets `json:"targets,omitempty"`
+	// Countries are the URLs by the ISO 3166-1 alpha-2 code of the country of the visitor.
+	Countries map[string]string `json:"countries,omitempty"`
+	// Variants split the visitors between several URLs by weight, Sticky keeps a visitor on one of them.
+	Variants []storage.Variant `json:"variants,omitempty"`
+	Sticky   string            `json:"sticky,omitempty"`
+}
+
+// Mode tells what to do with a record whose code is already stored.
+type Mode string
+
+const (
+	// ModeSkip keeps the stored link, repeated imports of the same file change nothing.
+	ModeSkip Mode = "skip"
+	// ModeUpdate replaces the stored link with the record.
+	ModeUpdate Mode = "update"
+	// ModeStrict stops the import on the first existing link.
+	ModeStrict Mode = "strict"
+)
+
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case ModeSkip, ModeUpdate, ModeStrict:
+		return Mode(mode), nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownMode, mode)
+	}
+}
+
+type Options struct {
+	Mode Mode
+	// DryRun only counts what would be done with the current storage, records of one file don't see each other.
+	DryRun bool
+}
+
+type Result struct {
+	Imported  uint64 `json:"imported"`
+	Updated   uint64 `json:"updated"`
+	Unchanged uint64 `json:"unchanged"`
+	// Skipped counts existing codes in ModeSkip and URLs that are already shortened with another code.
+	Skipped uint64 `json:"skipped"`
+	// MaxID is the largest ID among the written records.
+	MaxID uint64 `json:"-"`
+}
+
+// Reader returns records one by one and io.EOF after the last one.
+type Reader interface {
+	Read() (Record, error)
+}
+
+type Writer interface {
+	Write(record Record) error
+	Flush() error
+}
+
+// Store is what Import needs from the storage.
+type Store interface {
+	GetLink(ctx context.Context, code string) (storage.Link, error)
+	FindByFullURL(ctx context.Context, fullURL string) (storage.Link, error)
+	RestoreLink(ctx context.Context, link storage.Link, overwrite bool) (uint64, error)
+}
+
+// Export writes every link ordered by ID.
+func Export(ctx context.Context, lister storage.Lister, w Writer) error {
+	const fn = "transfer.Export"
+
+	err := lister.ForEachLink(ctx, func(link storage.Link) error {
+		return w.Write(Record{
+			ID:              link.ID,
+			Code:            link.Code,
+			URL:             link.FullURL,
+			CreatedAt:       link.CreatedAt,
+			Disabled:        link.Disabled,
+			Redirect:        link.RedirectStatus,
+			Query:           string(link.Query),
+			PathPassthrough: link.PathPassthrough,
+			PasswordHash:    link.PasswordHash,
+			MaxClicks:       link.MaxClicks,
+			ClicksLeft:      link.ClicksLeft,
+			NotBefore:       toBound(link.NotBefore),
+			NotAfter:        toBound(link.NotAfter),
+			FallbackURL:     link.FallbackURL,
+			Targets:         toTargets(link.Targets),
+			Countries:       link.Countries,
+			Variants:        link.Variants,
+			Sticky:          string(link.Sticky),
+		})
+	})
+	if err != nil {
+		return e.WrapError(fn, err)
+	}
+
+	if err = w.Flush(); err != nil {
+		return e.WrapError(fn, err)
+	}
+	return nil
+}
+
+// Import streams records into the store. Errors name the record number, records before it stay imported.
+func Import(ctx context.Context, store Store, r Reader, opts Options) (Result, error) {
+	const fn = "transfer.Import"
+
+	if opts.Mode == "" {
+		opts.Mode = ModeSkip
+	}
+	if _, err := ParseMode(string(opts.Mode)); err != nil {
+		return Result{}, e.WrapError(fn, err)
+	}
+
+	var result Result
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		} else if err != nil {
+			return result, e.WrapError(fn, fmt.Errorf("record %d: %w", n, err))
+		}
+
+		if err = importRecord(ctx, store, record, opts, &result); err != nil {
+			return result, e.WrapError(fn, fmt.Errorf("record %d: %w", n, err))
+		}
+	}
+}
+
+func importRecord(ctx context.Context, store Store, record Record, opts Options, result *Result) error {
+	if err := validate(record); err != nil {
+		return err
+	}
+
+	link := storage.Link{
+		ID:        record.ID,
+		Code:      record.Code,
+		FullURL:   record.URL,
+		CreatedAt: record.CreatedAt,
+		Disabled:  record.Disabled,
+		Options: storage.Options{
+			RedirectStatus:  record.Redirect,
+			Query:           storage.QueryMerge(record.Query),
+			PathPassthrough: record.PathPassthrough,
+			PasswordHash:    record.PasswordHash,
+			MaxClicks:       record.MaxClicks,
+			NotBefore:       fromBound(record.NotBefore),
+			NotAfter:        fromBound(record.NotAfter),
+			FallbackURL:     record.FallbackURL,
+			Targets:         fromTargets(record.Targets),
+			Countries:       storage.NewCountries(record.Countries),
+			Variants:        storage.NewVariants(record.Variants),
+			Sticky:          storage.Stickiness(record.Sticky),
+		},
+		ClicksLeft: record.ClicksLeft,
+	}
+
+	existing, err := store.GetLink(ctx, record.Code)
+	if err == nil {
+		if existing.FullURL == record.URL && existing.Disabled == record.Disabled && existing.Options.Equal(link.Options) {
+			result.Unchanged++
+			return nil
+		}
+		switch opts.Mode {
+		case ModeStrict:
+			return fmt.Errorf("%w: code %s", ErrExists, record.Code)
+		case ModeSkip:
+			result.Skipped++
+			return nil
+		}
+		return write(ctx, store, link, true, opts, result, &result.Updated)
+	} else if !errors.Is(err, storage.ErrURLNotFound) {
+		return err
+	}
+
+	// один URL не может иметь два кода, такую запись можно только пропустить
+	owner, err := store.FindByFullURL(ctx, record.URL)
+	if err == nil {
+		if opts.Mode == ModeStrict {
+			return fmt.Errorf("%w: URL is shortened as %s", ErrExists, owner.Code)
+		}
+		result.Skipped++
+		return nil
+	} else if !errors.Is(err, storage.ErrURLNotFound) {
+		return err
+	}
+
+	return write(ctx, store, link, false, opts, result, &result.Imported)
+}
+
+func write(ctx context.Context, store Store, link storage.Link, overwrite bool, opts Options, result *Result, counter *uint64) error {
+	id := link.ID
+	if !opts.DryRun {
+		var err error
+		id, err = store.RestoreLink(ctx, link, overwrite)
+		if errors.Is(err, storage.ErrURLExists) && opts.Mode != ModeStrict {
+			result.Skipped++
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	*counter++
+	// записи без ID получают его от хранилища, счетчик должен пройти и их
+	if id > result.MaxID {
+		result.MaxID = id
+	}
+	return nil
+}
+
+func validate(record Record) error {
+	if record.Code == "" {
+		return fmt.Errorf("%w: code is empty", ErrInvalidRecord)
+	}
+	parsed, err := url.Parse(record.URL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("%w: %q is not an absolute URL", ErrInvalidRecord, record.URL)
+	}
+	if !storage.ValidRedirectStatus(record.Redirect) {
+		return fmt.Errorf("%w: redirect status %d is not supported", ErrInvalidRecord, record.Redirect)
+	}
+	if !storage.ValidQueryMerge(storage.QueryMerge(record.Query)) {
+		return fmt.Errorf("%w: query merge %q is not supported", ErrInvalidRecord, record.Query)
+	}
+	if record.ClicksLeft > record.MaxClicks {
+		return fmt.Errorf("%w: %d clicks left of %d", ErrInvalidRecord, record.ClicksLeft, record.MaxClicks)
+	}
+	if record.PasswordHash != "" {
+		if _, err = bcrypt.Cost([]byte(record.PasswordHash)); err != nil {
+			return fmt.Errorf("%w: password hash is not a bcrypt hash", ErrInvalidRecord)
+		}
+	}
+	if record.NotBefore != nil && record.NotAfter != nil && !record.NotAfter.After(*record.NotBefore) {
+		return fmt.Errorf("%w: not after %s is not after not before %s", ErrInvalidRecord,
+			record.NotAfter.Format(time.RFC3339), record.NotBefore.Format(time.RFC3339))
+	}
+	others := append([]string{record.FallbackURL}, targetURLs(record.Targets)...)
+	for _, country := range sortedCountries(record.Countries) {
+		if !storage.ValidCountry(country) {
+			return fmt.Errorf("%w: %q is not a country code", ErrInvalidRecord, country)
+		}
+		others = append(others, record.Countries[country])
+	}
+	if err = validateVariants(record); err != nil {
+		return err
+	}
+	for _, variant := range record.Variants {
+		others = append(others, variant.URL)
+	}
+	for _, other := range others {
+		if other == "" {
+			continue
+		}
+		parsed, err = url.Parse(other)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("%w: %q is not an absolute URL", ErrInvalidRecord, other)
+		}
+	}
+	return nil
+}
+
+// validateVariants checks the stickiness and that the variants have unique names and positive weights.
+// Their URLs are checked with the other ones.
+func validateVariants(record Record) error {
+	sticky := storage.Stickiness(record.Sticky)
+	if !storage.ValidStickiness(sticky) {
+		return fmt.Errorf("%w: sticky %q is not supported", ErrInvalidRecord, record.Sticky)
+	}
+	if sticky != storage.StickyNone && len(record.Variants) == 0 {
+		return fmt.Errorf("%w: sticky %q without variants", ErrInvalidRecord, record.Sticky)
+	}
+
+	names := make(map[string]struct{}, len(record.Variants))
+	for i, variant := range record.Variants {
+		name := variant.Name
+		if name == "" {
+			name = storage.VariantName(i)
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("%w: variant %q is repeated", ErrInvalidRecord, name)
+		}
+		names[name] = struct{}{}
+		if variant.URL == "" || variant.Weight == 0 {
+			return fmt.Errorf("%w: variant %q needs a URL and a positive weight", ErrInvalidRecord, name)
+		}
+	}
+	return nil
+}
+
+func targetURLs(targets *storage.Targets) []string {
+	if targets == nil {
+		return nil
+	}
+	return []string{targets.IOS, targets.Android, targets.Desktop, targets.Bot}
+}
+
+// sortedCountries lists the codes in order, so an invalid record always reports the same country.
+func sortedCountries(countries map[string]string) []string {
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func toTargets(targets storage.Targets) *storage.Targets {
+	if targets == (storage.Targets{}) {
+		return nil
+	}
+	return &targets
+}
+
+func fromTargets(targets *storage.Targets) storage.Targets {
+	if targets == nil {
+		return storage.Targets{}
+	}
+	return *targets
+}
+
+func toBound(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// fromBound keeps the window in UTC like the service does, so an unchanged record compares equal.
+func fromBound(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
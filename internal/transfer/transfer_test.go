@@ -0,0 +1,202 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"urlShortener/internal/storage"
+	"urlShortener/internal/storage/inMemmory"
+)
+
+func newSource(t *testing.T) *inMemmory.Storage {
+	ctx := context.Background()
+	source := inMemmory.New()
+	assert.NoError(t, source.SaveURL(ctx, "https://ozon.ru", "qqqqqqqqqq", storage.Options{}))
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	assert.NoError(t, source.SaveURL(ctx, "https://ya.ru", "qqqqqqqqqw", storage.Options{RedirectStatus: 308, Query: storage.QueryAppend, PathPassthrough: true, PasswordHash: string(hash), MaxClicks: 3,
+		NotBefore: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), NotAfter: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC), FallbackURL: "https://ya.ru/soon",
+		Targets:   storage.Targets{IOS: "itms-apps://apps.apple.com/app/id1", Bot: "https://ya.ru/card"},
+		Countries: storage.NewCountries(map[string]string{"KZ": "https://ya.kz", "BY": "https://ya.by"}),
+		Variants:  storage.NewVariants([]storage.Variant{{URL: "https://ya.ru/a", Weight: 3}, {Name: "new", URL: "https://ya.ru/b", Weight: 1}}),
+		Sticky:    storage.StickyHash}))
+	assert.NoError(t, source.SetDisabled(ctx, "qqqqqqqqqw", true))
+	return source
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{JSONLines, CSV} {
+		t.Run(format, func(t *testing.T) {
+			ctx := context.Background()
+			source := newSource(t)
+
+			var buf bytes.Buffer
+			w, err := NewWriter(format, &buf)
+			assert.NoError(t, err)
+			assert.NoError(t, Export(ctx, source, w))
+
+			target := inMemmory.New()
+			r, err := NewReader(format, &buf)
+			assert.NoError(t, err)
+			result, err := Import(ctx, target, r, Options{})
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(2), result.Imported)
+			assert.Equal(t, uint64(2), result.MaxID)
+
+			for _, code := range []string{"qqqqqqqqqq", "qqqqqqqqqw"} {
+				want, err := source.GetLink(ctx, code)
+				assert.NoError(t, err)
+				got, err := target.GetLink(ctx, code)
+				assert.NoError(t, err)
+				assert.Equal(t, want.ID, got.ID)
+				assert.Equal(t, want.FullURL, got.FullURL)
+				assert.Equal(t, want.Disabled, got.Disabled)
+				assert.Equal(t, want.Options, got.Options)
+				assert.Equal(t, want.ClicksLeft, got.ClicksLeft)
+				assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+			}
+		})
+	}
+}
+
+func TestImportModes(t *testing.T) {
+	input := `{"code":"qqqqqqqqqq","url":"https://ozon.ru"}
+{"code":"qqqqqqqqqw","url":"https://ya.ru/new"}
+{"code":"qqqqqqqqqe","url":"https://ya.ru"}
+{"code":"qqqqqqqqqr","url":"https://avito.ru"}
+`
+	tests := []struct {
+		name   string
+		opts   Options
+		result Result
+		err    error
+	}{
+		// записи без ID получают следующие ID хранилища
+		{"skip", Options{Mode: ModeSkip}, Result{Imported: 1, Unchanged: 1, Skipped: 2, MaxID: 3}, nil},
+		// вторая запись освобождает https://ya.ru, поэтому третья импортируется
+		{"update", Options{Mode: ModeUpdate}, Result{Imported: 2, Updated: 1, Unchanged: 1, MaxID: 4}, nil},
+		{"strict", Options{Mode: ModeStrict}, Result{Unchanged: 1}, ErrExists},
+		{"dry run", Options{Mode: ModeUpdate, DryRun: true}, Result{Imported: 1, Updated: 1, Unchanged: 1, Skipped: 1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := inMemmory.New()
+			assert.NoError(t, st.SaveURL(ctx, "https://ozon.ru", "qqqqqqqqqq", storage.Options{}))
+			assert.NoError(t, st.SaveURL(ctx, "https://ya.ru", "qqqqqqqqqw", storage.Options{}))
+
+			result, err := Import(ctx, st, NewJSONLinesReader(strings.NewReader(input)), tt.opts)
+			assert.True(t, errors.Is(err, tt.err))
+			assert.Equal(t, tt.result, result)
+
+			stats, err := st.Stats(ctx)
+			assert.NoError(t, err)
+			if tt.opts.DryRun {
+				assert.Equal(t, uint64(2), stats.Links)
+			}
+		})
+	}
+}
+
+func TestImportInvalidRecord(t *testing.T) {
+	st := inMemmory.New()
+	input := "{\"code\":\"qqqqqqqqqq\",\"url\":\"https://ozon.ru\"}\n{\"code\":\"qqqqqqqqqw\",\"url\":\"ozon.ru\"}\n"
+
+	result, err := Import(context.Background(), st, NewJSONLinesReader(strings.NewReader(input)), Options{})
+	assert.True(t, errors.Is(err, ErrInvalidRecord))
+	assert.ErrorContains(t, err, "record 2")
+	assert.Equal(t, uint64(1), result.Imported)
+}
+
+func TestImportInvalidRedirect(t *testing.T) {
+	st := inMemmory.New()
+	input := `{"code":"qqqqqqqqqq","url":"https://ozon.ru","redirect":200}`
+
+	_, err := Import(context.Background(), st, NewJSONLinesReader(strings.NewReader(input)), Options{})
+	assert.True(t, errors.Is(err, ErrInvalidRecord))
+}
+
+func TestImportInvalidPasswordHash(t *testing.T) {
+	st := inMemmory.New()
+	input := `{"code":"qqqqqqqqqq","url":"https://ozon.ru","passwordHash":"secret"}`
+
+	_, err := Import(context.Background(), st, NewJSONLinesReader(strings.NewReader(input)), Options{})
+	assert.True(t, errors.Is(err, ErrInvalidRecord))
+}
+
+func TestImportInvalidWindow(t *testing.T) {
+	st := inMemmory.New()
+	input := `{"code":"qqqqqqqqqq","url":"https://ozon.ru","notBefore":"2024-07-01T09:00:00Z","notAfter":"2024-06-01T09:00:00Z"}`
+
+	_, err := Import(context.Background(), st, NewJSONLinesReader(strings.NewReader(input)), Options{})
+	assert.True(t, errors.Is(err, ErrInvalidRecord))
+}
+
+func TestImportInvalidCountry(t *testing.T) {
+	st := inMemmory.New()
+	input := `{"code":"qqqqqqqqqq","url":"https://ozon.ru","countries":{"KAZ":"https://ozon.kz"}}`
+
+	_, err := Import(context.Background(), st, NewJSONLinesReader(strings.NewReader(input)), Options{})
+	assert.True(t, errors.Is(err, ErrInvalidRecord))
+}
+
+func TestImportInvalidVariants(t *testing.T) {
+	inputs := []string{
+		`{"code":"qqqqqqqqqq","url":"https://ozon.ru","variants":[{"url":"https://ozon.ru/a","weight":1},{"url":"ozon.ru/b","weight":1}]}`,
+		`{"code":"qqqqqqqqqq","url":"https://ozon.ru","variants":[{"url":"https://ozon.ru/a","weight":1},{"url":"https://ozon.ru/b"}]}`,
+		`{"code":"qqqqqqqqqq","url":"https://ozon.ru","variants":[{"name":"B","url":"https://ozon.ru/a","weight":1},{"url":"https://ozon.ru/b","weight":1}]}`,
+		`{"code":"qqqqqqqqqq","url":"https://ozon.ru","sticky":"cookie"}`,
+	}
+	for _, input := range inputs {
+		_, err := Import(context.Background(), inMemmory.New(), NewJSONLinesReader(strings.NewReader(input)), Options{})
+		assert.True(t, errors.Is(err, ErrInvalidRecord), input)
+	}
+}
+
+func TestImportForeignCSV(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{Bitly, "Link,Long_URL,Created_At,Title\nhttps://bit.ly/3xYz,https://ozon.ru/promo,2023-05-01T10:00:00+0000,Promo\n"},
+		{Rebrandly, "slashtag,destination,createdAt\n3xYz,https://ozon.ru/promo,2023-05-01T10:00:00Z\n"},
+		{ShortIO, "originalURL,shortURL,createdAt\nhttps://ozon.ru/promo,short.io/3xYz,1682935200\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			ctx := context.Background()
+			st := inMemmory.New()
+			r, err := NewReader(tt.format, strings.NewReader(tt.input))
+			assert.NoError(t, err)
+
+			result, err := Import(ctx, st, r, Options{})
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), result.Imported)
+
+			link, err := st.GetLink(ctx, "3xYz")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://ozon.ru/promo", link.FullURL)
+			assert.True(t, time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC).Equal(link.CreatedAt))
+		})
+	}
+}
+
+func TestCSVWriterEmptyStorage(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Export(context.Background(), inMemmory.New(), NewCSVWriter(&buf)))
+	assert.Equal(t, "id,code,url,created_at,disabled,redirect,query,path_passthrough,password_hash,max_clicks,clicks_left,not_before,not_after,fallback_url,target_ios,target_android,target_desktop,target_bot,countries,variants,sticky\n", buf.String())
+
+	_, err := NewCSVReader(&buf, nativeColumns).Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+var _ Store = (*inMemmory.Storage)(nil)
+var _ storage.Lister = (*inMemmory.Storage)(nil)
@@ -1,16 +1,74 @@
 package logger
 
 import (
+	"errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 	"os"
+	"urlShortener/pkg/requestID"
 )
 
-func New() *logrus.Logger {
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// New creates a logger writing to stdout. Entries created with WithContext get
+// request_id and trace_id fields from the context.
+func New(level, format string) (*logrus.Logger, error) {
 	logger := logrus.New()
 	logger.SetOutput(os.Stdout)
-	logger.SetFormatter(&logrus.TextFormatter{
-		ForceColors: true,
-	})
 
-	return logger
+	if err := Configure(logger, level, format); err != nil {
+		return nil, err
+	}
+
+	logger.AddHook(contextHook{})
+
+	return logger, nil
+}
+
+// Configure sets the level and the format of a running logger, it's used on config reload too.
+// Nothing is changed if either is invalid.
+func Configure(logger *logrus.Logger, level, format string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	var formatter logrus.Formatter
+	switch format {
+	case FormatJSON:
+		formatter = &logrus.JSONFormatter{}
+	case FormatText:
+		formatter = &logrus.TextFormatter{}
+	default:
+		return ErrUnknownFormat
+	}
+
+	logger.SetLevel(lvl)
+	logger.SetFormatter(formatter)
+	return nil
+}
+
+// contextHook adds request-scoped fields to entries that carry a context.
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := requestID.FromContext(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+	if spanCtx := trace.SpanContextFromContext(entry.Context); spanCtx.IsValid() {
+		entry.Data["trace_id"] = spanCtx.TraceID().String()
+	}
+	return nil
 }
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"urlShortener/pkg/requestID"
+)
+
+func TestNewJSONWithRequestID(t *testing.T) {
+	logger, err := New("debug", FormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.DebugLevel, logger.GetLevel())
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	ctx := requestID.NewContext(context.Background(), "req-42")
+	logger.WithContext(ctx).WithField("status", 200).Info("request completed")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "req-42", line["request_id"])
+	assert.Equal(t, "request completed", line["msg"])
+	assert.Equal(t, float64(200), line["status"])
+}
+
+func TestNewWrongLevel(t *testing.T) {
+	_, err := New("loud", FormatJSON)
+	assert.Error(t, err)
+}
+
+func TestNewWrongFormat(t *testing.T) {
+	_, err := New("info", "xml")
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+}
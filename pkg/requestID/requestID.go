@@ -0,0 +1,53 @@
+package requestID
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// Header is the HTTP header used to pass the request ID.
+	Header = "X-Request-ID"
+	// MetadataKey is the gRPC metadata key used to pass the request ID.
+	MetadataKey = "x-request-id"
+	// maxLen ограничивает длину входящего ID, чтобы клиент не мог раздуть логи.
+	maxLen = 128
+)
+
+type ctxKey struct{}
+
+// New generates a random request ID.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// FromIncoming returns the incoming ID if it is usable, otherwise generates a new one.
+func FromIncoming(id string) string {
+	if id == "" || len(id) > maxLen {
+		return New()
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return New()
+		}
+	}
+	return id
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID or an empty string.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
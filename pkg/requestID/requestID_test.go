@@ -0,0 +1,25 @@
+package requestID
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestContextRoundTrip(t *testing.T) {
+	ctx := NewContext(context.Background(), "abc")
+	assert.Equal(t, "abc", FromContext(ctx))
+	assert.Equal(t, "", FromContext(context.Background()))
+}
+
+func TestFromIncoming(t *testing.T) {
+	assert.Equal(t, "req-1", FromIncoming("req-1"))
+	assert.Len(t, FromIncoming(""), 32)
+	assert.Len(t, FromIncoming(strings.Repeat("a", maxLen+1)), 32)
+	assert.Len(t, FromIncoming("bad\nid"), 32)
+}
+
+func TestNewUnique(t *testing.T) {
+	assert.NotEqual(t, New(), New())
+}
@@ -12,8 +12,12 @@ func ValidateErrors(errs validator.ValidationErrors) error {
 
 	for _, err := range errs {
 		switch err.ActualTag() {
-		case "required":
+		case "required", "required_if", "required_without":
 			errorMsgs = append(errorMsgs, fmt.Sprintf("field %s was not filled", err.Field()))
+		case "oneof":
+			errorMsgs = append(errorMsgs, fmt.Sprintf("field %s must be one of: %s", err.Field(), err.Param()))
+		case "gte", "lte":
+			errorMsgs = append(errorMsgs, fmt.Sprintf("field %s is out of range", err.Field()))
 		case "url":
 			errorMsgs = append(errorMsgs, fmt.Sprintf("field %s url is wrong", err.Field()))
 		case "numeric":
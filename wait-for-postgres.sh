@@ -1,12 +0,0 @@
-#!/bin/sh
-set -e
-
-host="$1"
-shift
-
-until PGPASSWORD="postgres" psql -h "$host" -U "postgres" -c '\q'; do
-  >&2 echo "Postgres is unavailable - sleeping"
-  sleep 1
-done
-
->&2 echo "Postgres is up"
\ No newline at end of file